@@ -27,13 +27,11 @@ var CoreGressRulesCombinedANB = []*v1alpha1.AdminNetworkPolicy{
 				{
 					Name:   "allow-to-ravenclaw-everything",
 					Action: v1alpha1.AdminNetworkPolicyRuleActionAllow,
-					To: []v1alpha1.AdminNetworkPolicyPeer{
+					To: []v1alpha1.AdminNetworkPolicyEgressPeer{
 						{
-							Namespaces: &v1alpha1.NamespacedPeer{
-								NamespaceSelector: &v1.LabelSelector{
-									MatchLabels: map[string]string{
-										"kubernetes.io/metadata.name": "network-policy-conformance-ravenclaw",
-									},
+							Namespaces: &v1.LabelSelector{
+								MatchLabels: map[string]string{
+									"kubernetes.io/metadata.name": "network-policy-conformance-ravenclaw",
 								},
 							},
 						},
@@ -42,13 +40,11 @@ var CoreGressRulesCombinedANB = []*v1alpha1.AdminNetworkPolicy{
 				{
 					Name:   "deny-to-ravenclaw-everything",
 					Action: v1alpha1.AdminNetworkPolicyRuleActionDeny,
-					To: []v1alpha1.AdminNetworkPolicyPeer{
+					To: []v1alpha1.AdminNetworkPolicyEgressPeer{
 						{
-							Namespaces: &v1alpha1.NamespacedPeer{
-								NamespaceSelector: &v1.LabelSelector{
-									MatchLabels: map[string]string{
-										"kubernetes.io/metadata.name": "network-policy-conformance-ravenclaw",
-									},
+							Namespaces: &v1.LabelSelector{
+								MatchLabels: map[string]string{
+									"kubernetes.io/metadata.name": "network-policy-conformance-ravenclaw",
 								},
 							},
 						},
@@ -57,13 +53,11 @@ var CoreGressRulesCombinedANB = []*v1alpha1.AdminNetworkPolicy{
 				{
 					Name:   "pass-to-ravenclaw-everything",
 					Action: v1alpha1.AdminNetworkPolicyRuleActionPass,
-					To: []v1alpha1.AdminNetworkPolicyPeer{
+					To: []v1alpha1.AdminNetworkPolicyEgressPeer{
 						{
-							Namespaces: &v1alpha1.NamespacedPeer{
-								NamespaceSelector: &v1.LabelSelector{
-									MatchLabels: map[string]string{
-										"kubernetes.io/metadata.name": "network-policy-conformance-ravenclaw",
-									},
+							Namespaces: &v1.LabelSelector{
+								MatchLabels: map[string]string{
+									"kubernetes.io/metadata.name": "network-policy-conformance-ravenclaw",
 								},
 							},
 						},
@@ -72,13 +66,11 @@ var CoreGressRulesCombinedANB = []*v1alpha1.AdminNetworkPolicy{
 				{
 					Name:   "deny-to-slytherin-at-ports-80-53-9003",
 					Action: v1alpha1.AdminNetworkPolicyRuleActionDeny,
-					To: []v1alpha1.AdminNetworkPolicyPeer{
+					To: []v1alpha1.AdminNetworkPolicyEgressPeer{
 						{
-							Namespaces: &v1alpha1.NamespacedPeer{
-								NamespaceSelector: &v1.LabelSelector{
-									MatchLabels: map[string]string{
-										"kubernetes.io/metadata.name": "network-policy-conformance-slytherin",
-									},
+							Namespaces: &v1.LabelSelector{
+								MatchLabels: map[string]string{
+									"kubernetes.io/metadata.name": "network-policy-conformance-slytherin",
 								},
 							},
 						},
@@ -98,13 +90,11 @@ var CoreGressRulesCombinedANB = []*v1alpha1.AdminNetworkPolicy{
 				{
 					Name:   "pass-to-slytherin-at-port-80-53-9003",
 					Action: v1alpha1.AdminNetworkPolicyRuleActionPass,
-					To: []v1alpha1.AdminNetworkPolicyPeer{
+					To: []v1alpha1.AdminNetworkPolicyEgressPeer{
 						{
-							Namespaces: &v1alpha1.NamespacedPeer{
-								NamespaceSelector: &v1.LabelSelector{
-									MatchLabels: map[string]string{
-										"kubernetes.io/metadata.name": "network-policy-conformance-slytherin",
-									},
+							Namespaces: &v1.LabelSelector{
+								MatchLabels: map[string]string{
+									"kubernetes.io/metadata.name": "network-policy-conformance-slytherin",
 								},
 							},
 						},
@@ -124,13 +114,11 @@ var CoreGressRulesCombinedANB = []*v1alpha1.AdminNetworkPolicy{
 				{
 					Name:   "allow-to-hufflepuff-at-ports-8080-5353",
 					Action: v1alpha1.AdminNetworkPolicyRuleActionAllow,
-					To: []v1alpha1.AdminNetworkPolicyPeer{
+					To: []v1alpha1.AdminNetworkPolicyEgressPeer{
 						{
-							Namespaces: &v1alpha1.NamespacedPeer{
-								NamespaceSelector: &v1.LabelSelector{
-									MatchLabels: map[string]string{
-										"kubernetes.io/metadata.name": "network-policy-conformance-hufflepuff",
-									},
+							Namespaces: &v1.LabelSelector{
+								MatchLabels: map[string]string{
+									"kubernetes.io/metadata.name": "network-policy-conformance-hufflepuff",
 								},
 							},
 						},
@@ -150,13 +138,11 @@ var CoreGressRulesCombinedANB = []*v1alpha1.AdminNetworkPolicy{
 				{
 					Name:   "deny-to-hufflepuff-everything-else",
 					Action: v1alpha1.AdminNetworkPolicyRuleActionDeny,
-					To: []v1alpha1.AdminNetworkPolicyPeer{
+					To: []v1alpha1.AdminNetworkPolicyEgressPeer{
 						{
-							Namespaces: &v1alpha1.NamespacedPeer{
-								NamespaceSelector: &v1.LabelSelector{
-									MatchLabels: map[string]string{
-										"kubernetes.io/metadata.name": "network-policy-conformance-hufflepuff",
-									},
+							Namespaces: &v1.LabelSelector{
+								MatchLabels: map[string]string{
+									"kubernetes.io/metadata.name": "network-policy-conformance-hufflepuff",
 								},
 							},
 						},
@@ -167,13 +153,11 @@ var CoreGressRulesCombinedANB = []*v1alpha1.AdminNetworkPolicy{
 				{
 					Name:   "allow-from-ravenclaw-everything",
 					Action: v1alpha1.AdminNetworkPolicyRuleActionAllow,
-					From: []v1alpha1.AdminNetworkPolicyPeer{
+					From: []v1alpha1.AdminNetworkPolicyIngressPeer{
 						{
-							Namespaces: &v1alpha1.NamespacedPeer{
-								NamespaceSelector: &v1.LabelSelector{
-									MatchLabels: map[string]string{
-										"kubernetes.io/metadata.name": "network-policy-conformance-ravenclaw",
-									},
+							Namespaces: &v1.LabelSelector{
+								MatchLabels: map[string]string{
+									"kubernetes.io/metadata.name": "network-policy-conformance-ravenclaw",
 								},
 							},
 						},
@@ -182,13 +166,11 @@ var CoreGressRulesCombinedANB = []*v1alpha1.AdminNetworkPolicy{
 				{
 					Name:   "deny-from-ravenclaw-everything",
 					Action: v1alpha1.AdminNetworkPolicyRuleActionDeny,
-					From: []v1alpha1.AdminNetworkPolicyPeer{
+					From: []v1alpha1.AdminNetworkPolicyIngressPeer{
 						{
-							Namespaces: &v1alpha1.NamespacedPeer{
-								NamespaceSelector: &v1.LabelSelector{
-									MatchLabels: map[string]string{
-										"kubernetes.io/metadata.name": "network-policy-conformance-ravenclaw",
-									},
+							Namespaces: &v1.LabelSelector{
+								MatchLabels: map[string]string{
+									"kubernetes.io/metadata.name": "network-policy-conformance-ravenclaw",
 								},
 							},
 						},
@@ -197,13 +179,11 @@ var CoreGressRulesCombinedANB = []*v1alpha1.AdminNetworkPolicy{
 				{
 					Name:   "pass-from-ravenclaw-everything",
 					Action: v1alpha1.AdminNetworkPolicyRuleActionPass,
-					From: []v1alpha1.AdminNetworkPolicyPeer{
+					From: []v1alpha1.AdminNetworkPolicyIngressPeer{
 						{
-							Namespaces: &v1alpha1.NamespacedPeer{
-								NamespaceSelector: &v1.LabelSelector{
-									MatchLabels: map[string]string{
-										"kubernetes.io/metadata.name": "network-policy-conformance-ravenclaw",
-									},
+							Namespaces: &v1.LabelSelector{
+								MatchLabels: map[string]string{
+									"kubernetes.io/metadata.name": "network-policy-conformance-ravenclaw",
 								},
 							},
 						},
@@ -212,13 +192,11 @@ var CoreGressRulesCombinedANB = []*v1alpha1.AdminNetworkPolicy{
 				{
 					Name:   "deny-from-slytherin-at-port-80-53-9003",
 					Action: v1alpha1.AdminNetworkPolicyRuleActionDeny,
-					From: []v1alpha1.AdminNetworkPolicyPeer{
+					From: []v1alpha1.AdminNetworkPolicyIngressPeer{
 						{
-							Namespaces: &v1alpha1.NamespacedPeer{
-								NamespaceSelector: &v1.LabelSelector{
-									MatchLabels: map[string]string{
-										"kubernetes.io/metadata.name": "network-policy-conformance-slytherin",
-									},
+							Namespaces: &v1.LabelSelector{
+								MatchLabels: map[string]string{
+									"kubernetes.io/metadata.name": "network-policy-conformance-slytherin",
 								},
 							},
 						},
@@ -238,13 +216,11 @@ var CoreGressRulesCombinedANB = []*v1alpha1.AdminNetworkPolicy{
 				{
 					Name:   "pass-from-slytherin-at-port-80-53-9003",
 					Action: v1alpha1.AdminNetworkPolicyRuleActionPass,
-					From: []v1alpha1.AdminNetworkPolicyPeer{
+					From: []v1alpha1.AdminNetworkPolicyIngressPeer{
 						{
-							Namespaces: &v1alpha1.NamespacedPeer{
-								NamespaceSelector: &v1.LabelSelector{
-									MatchLabels: map[string]string{
-										"kubernetes.io/metadata.name": "network-policy-conformance-slytherin",
-									},
+							Namespaces: &v1.LabelSelector{
+								MatchLabels: map[string]string{
+									"kubernetes.io/metadata.name": "network-policy-conformance-slytherin",
 								},
 							},
 						},
@@ -264,13 +240,11 @@ var CoreGressRulesCombinedANB = []*v1alpha1.AdminNetworkPolicy{
 				{
 					Name:   "allow-from-hufflepuff-at-port-80-5353-9003",
 					Action: v1alpha1.AdminNetworkPolicyRuleActionAllow,
-					From: []v1alpha1.AdminNetworkPolicyPeer{
+					From: []v1alpha1.AdminNetworkPolicyIngressPeer{
 						{
-							Namespaces: &v1alpha1.NamespacedPeer{
-								NamespaceSelector: &v1.LabelSelector{
-									MatchLabels: map[string]string{
-										"kubernetes.io/metadata.name": "network-policy-conformance-hufflepuff",
-									},
+							Namespaces: &v1.LabelSelector{
+								MatchLabels: map[string]string{
+									"kubernetes.io/metadata.name": "network-policy-conformance-hufflepuff",
 								},
 							},
 						},
@@ -290,13 +264,11 @@ var CoreGressRulesCombinedANB = []*v1alpha1.AdminNetworkPolicy{
 				{
 					Name:   "deny-from-hufflepuff-everything-else",
 					Action: v1alpha1.AdminNetworkPolicyRuleActionDeny,
-					From: []v1alpha1.AdminNetworkPolicyPeer{
+					From: []v1alpha1.AdminNetworkPolicyIngressPeer{
 						{
-							Namespaces: &v1alpha1.NamespacedPeer{
-								NamespaceSelector: &v1.LabelSelector{
-									MatchLabels: map[string]string{
-										"kubernetes.io/metadata.name": "network-policy-conformance-hufflepuff",
-									},
+							Namespaces: &v1.LabelSelector{
+								MatchLabels: map[string]string{
+									"kubernetes.io/metadata.name": "network-policy-conformance-hufflepuff",
 								},
 							},
 						},
@@ -325,13 +297,11 @@ var CoreGressRulesCombinedANB = []*v1alpha1.AdminNetworkPolicy{
 				{
 					Name:   "allow-to-ravenclaw-everything-2",
 					Action: v1alpha1.AdminNetworkPolicyRuleActionAllow,
-					To: []v1alpha1.AdminNetworkPolicyPeer{
+					To: []v1alpha1.AdminNetworkPolicyEgressPeer{
 						{
-							Namespaces: &v1alpha1.NamespacedPeer{
-								NamespaceSelector: &v1.LabelSelector{
-									MatchLabels: map[string]string{
-										"kubernetes.io/metadata.name": "network-policy-conformance-ravenclaw",
-									},
+							Namespaces: &v1.LabelSelector{
+								MatchLabels: map[string]string{
+									"kubernetes.io/metadata.name": "network-policy-conformance-ravenclaw",
 								},
 							},
 						},
@@ -340,13 +310,11 @@ var CoreGressRulesCombinedANB = []*v1alpha1.AdminNetworkPolicy{
 				{
 					Name:   "deny-to-ravenclaw-everything-2",
 					Action: v1alpha1.AdminNetworkPolicyRuleActionDeny,
-					To: []v1alpha1.AdminNetworkPolicyPeer{
+					To: []v1alpha1.AdminNetworkPolicyEgressPeer{
 						{
-							Namespaces: &v1alpha1.NamespacedPeer{
-								NamespaceSelector: &v1.LabelSelector{
-									MatchLabels: map[string]string{
-										"kubernetes.io/metadata.name": "network-policy-conformance-ravenclaw",
-									},
+							Namespaces: &v1.LabelSelector{
+								MatchLabels: map[string]string{
+									"kubernetes.io/metadata.name": "network-policy-conformance-ravenclaw",
 								},
 							},
 						},
@@ -355,13 +323,11 @@ var CoreGressRulesCombinedANB = []*v1alpha1.AdminNetworkPolicy{
 				{
 					Name:   "pass-to-ravenclaw-everything-2",
 					Action: v1alpha1.AdminNetworkPolicyRuleActionPass,
-					To: []v1alpha1.AdminNetworkPolicyPeer{
+					To: []v1alpha1.AdminNetworkPolicyEgressPeer{
 						{
-							Namespaces: &v1alpha1.NamespacedPeer{
-								NamespaceSelector: &v1.LabelSelector{
-									MatchLabels: map[string]string{
-										"kubernetes.io/metadata.name": "network-policy-conformance-ravenclaw",
-									},
+							Namespaces: &v1.LabelSelector{
+								MatchLabels: map[string]string{
+									"kubernetes.io/metadata.name": "network-policy-conformance-ravenclaw",
 								},
 							},
 						},
@@ -370,13 +336,11 @@ var CoreGressRulesCombinedANB = []*v1alpha1.AdminNetworkPolicy{
 				{
 					Name:   "deny-to-slytherin-at-ports-80-53-9003-2",
 					Action: v1alpha1.AdminNetworkPolicyRuleActionDeny,
-					To: []v1alpha1.AdminNetworkPolicyPeer{
+					To: []v1alpha1.AdminNetworkPolicyEgressPeer{
 						{
-							Namespaces: &v1alpha1.NamespacedPeer{
-								NamespaceSelector: &v1.LabelSelector{
-									MatchLabels: map[string]string{
-										"kubernetes.io/metadata.name": "network-policy-conformance-slytherin",
-									},
+							Namespaces: &v1.LabelSelector{
+								MatchLabels: map[string]string{
+									"kubernetes.io/metadata.name": "network-policy-conformance-slytherin",
 								},
 							},
 						},
@@ -396,13 +360,11 @@ var CoreGressRulesCombinedANB = []*v1alpha1.AdminNetworkPolicy{
 				{
 					Name:   "pass-to-slytherin-at-port-80-53-9003-2",
 					Action: v1alpha1.AdminNetworkPolicyRuleActionPass,
-					To: []v1alpha1.AdminNetworkPolicyPeer{
+					To: []v1alpha1.AdminNetworkPolicyEgressPeer{
 						{
-							Namespaces: &v1alpha1.NamespacedPeer{
-								NamespaceSelector: &v1.LabelSelector{
-									MatchLabels: map[string]string{
-										"kubernetes.io/metadata.name": "network-policy-conformance-slytherin",
-									},
+							Namespaces: &v1.LabelSelector{
+								MatchLabels: map[string]string{
+									"kubernetes.io/metadata.name": "network-policy-conformance-slytherin",
 								},
 							},
 						},
@@ -422,13 +384,11 @@ var CoreGressRulesCombinedANB = []*v1alpha1.AdminNetworkPolicy{
 				{
 					Name:   "allow-to-hufflepuff-at-ports-8080-5353-2",
 					Action: v1alpha1.AdminNetworkPolicyRuleActionAllow,
-					To: []v1alpha1.AdminNetworkPolicyPeer{
+					To: []v1alpha1.AdminNetworkPolicyEgressPeer{
 						{
-							Namespaces: &v1alpha1.NamespacedPeer{
-								NamespaceSelector: &v1.LabelSelector{
-									MatchLabels: map[string]string{
-										"kubernetes.io/metadata.name": "network-policy-conformance-hufflepuff",
-									},
+							Namespaces: &v1.LabelSelector{
+								MatchLabels: map[string]string{
+									"kubernetes.io/metadata.name": "network-policy-conformance-hufflepuff",
 								},
 							},
 						},
@@ -448,13 +408,11 @@ var CoreGressRulesCombinedANB = []*v1alpha1.AdminNetworkPolicy{
 				{
 					Name:   "deny-to-hufflepuff-everything-else-2",
 					Action: v1alpha1.AdminNetworkPolicyRuleActionDeny,
-					To: []v1alpha1.AdminNetworkPolicyPeer{
+					To: []v1alpha1.AdminNetworkPolicyEgressPeer{
 						{
-							Namespaces: &v1alpha1.NamespacedPeer{
-								NamespaceSelector: &v1.LabelSelector{
-									MatchLabels: map[string]string{
-										"kubernetes.io/metadata.name": "network-policy-conformance-hufflepuff",
-									},
+							Namespaces: &v1.LabelSelector{
+								MatchLabels: map[string]string{
+									"kubernetes.io/metadata.name": "network-policy-conformance-hufflepuff",
 								},
 							},
 						},
@@ -465,13 +423,11 @@ var CoreGressRulesCombinedANB = []*v1alpha1.AdminNetworkPolicy{
 				{
 					Name:   "allow-from-ravenclaw-everything-2",
 					Action: v1alpha1.AdminNetworkPolicyRuleActionAllow,
-					From: []v1alpha1.AdminNetworkPolicyPeer{
+					From: []v1alpha1.AdminNetworkPolicyIngressPeer{
 						{
-							Namespaces: &v1alpha1.NamespacedPeer{
-								NamespaceSelector: &v1.LabelSelector{
-									MatchLabels: map[string]string{
-										"kubernetes.io/metadata.name": "network-policy-conformance-ravenclaw",
-									},
+							Namespaces: &v1.LabelSelector{
+								MatchLabels: map[string]string{
+									"kubernetes.io/metadata.name": "network-policy-conformance-ravenclaw",
 								},
 							},
 						},
@@ -480,13 +436,11 @@ var CoreGressRulesCombinedANB = []*v1alpha1.AdminNetworkPolicy{
 				{
 					Name:   "deny-from-ravenclaw-everything-2",
 					Action: v1alpha1.AdminNetworkPolicyRuleActionDeny,
-					From: []v1alpha1.AdminNetworkPolicyPeer{
+					From: []v1alpha1.AdminNetworkPolicyIngressPeer{
 						{
-							Namespaces: &v1alpha1.NamespacedPeer{
-								NamespaceSelector: &v1.LabelSelector{
-									MatchLabels: map[string]string{
-										"kubernetes.io/metadata.name": "network-policy-conformance-ravenclaw",
-									},
+							Namespaces: &v1.LabelSelector{
+								MatchLabels: map[string]string{
+									"kubernetes.io/metadata.name": "network-policy-conformance-ravenclaw",
 								},
 							},
 						},
@@ -495,13 +449,11 @@ var CoreGressRulesCombinedANB = []*v1alpha1.AdminNetworkPolicy{
 				{
 					Name:   "pass-from-ravenclaw-everything-2",
 					Action: v1alpha1.AdminNetworkPolicyRuleActionPass,
-					From: []v1alpha1.AdminNetworkPolicyPeer{
+					From: []v1alpha1.AdminNetworkPolicyIngressPeer{
 						{
-							Namespaces: &v1alpha1.NamespacedPeer{
-								NamespaceSelector: &v1.LabelSelector{
-									MatchLabels: map[string]string{
-										"kubernetes.io/metadata.name": "network-policy-conformance-ravenclaw",
-									},
+							Namespaces: &v1.LabelSelector{
+								MatchLabels: map[string]string{
+									"kubernetes.io/metadata.name": "network-policy-conformance-ravenclaw",
 								},
 							},
 						},
@@ -510,13 +462,11 @@ var CoreGressRulesCombinedANB = []*v1alpha1.AdminNetworkPolicy{
 				{
 					Name:   "deny-from-slytherin-at-port-80-53-9003-2",
 					Action: v1alpha1.AdminNetworkPolicyRuleActionDeny,
-					From: []v1alpha1.AdminNetworkPolicyPeer{
+					From: []v1alpha1.AdminNetworkPolicyIngressPeer{
 						{
-							Namespaces: &v1alpha1.NamespacedPeer{
-								NamespaceSelector: &v1.LabelSelector{
-									MatchLabels: map[string]string{
-										"kubernetes.io/metadata.name": "network-policy-conformance-slytherin",
-									},
+							Namespaces: &v1.LabelSelector{
+								MatchLabels: map[string]string{
+									"kubernetes.io/metadata.name": "network-policy-conformance-slytherin",
 								},
 							},
 						},
@@ -536,13 +486,11 @@ var CoreGressRulesCombinedANB = []*v1alpha1.AdminNetworkPolicy{
 				{
 					Name:   "pass-from-slytherin-at-port-80-53-9003-2",
 					Action: v1alpha1.AdminNetworkPolicyRuleActionPass,
-					From: []v1alpha1.AdminNetworkPolicyPeer{
+					From: []v1alpha1.AdminNetworkPolicyIngressPeer{
 						{
-							Namespaces: &v1alpha1.NamespacedPeer{
-								NamespaceSelector: &v1.LabelSelector{
-									MatchLabels: map[string]string{
-										"kubernetes.io/metadata.name": "network-policy-conformance-slytherin",
-									},
+							Namespaces: &v1.LabelSelector{
+								MatchLabels: map[string]string{
+									"kubernetes.io/metadata.name": "network-policy-conformance-slytherin",
 								},
 							},
 						},
@@ -562,13 +510,11 @@ var CoreGressRulesCombinedANB = []*v1alpha1.AdminNetworkPolicy{
 				{
 					Name:   "allow-from-hufflepuff-at-port-80-5353-9003-2",
 					Action: v1alpha1.AdminNetworkPolicyRuleActionAllow,
-					From: []v1alpha1.AdminNetworkPolicyPeer{
+					From: []v1alpha1.AdminNetworkPolicyIngressPeer{
 						{
-							Namespaces: &v1alpha1.NamespacedPeer{
-								NamespaceSelector: &v1.LabelSelector{
-									MatchLabels: map[string]string{
-										"kubernetes.io/metadata.name": "network-policy-conformance-hufflepuff",
-									},
+							Namespaces: &v1.LabelSelector{
+								MatchLabels: map[string]string{
+									"kubernetes.io/metadata.name": "network-policy-conformance-hufflepuff",
 								},
 							},
 						},
@@ -588,13 +534,11 @@ var CoreGressRulesCombinedANB = []*v1alpha1.AdminNetworkPolicy{
 				{
 					Name:   "deny-from-hufflepuff-everything-else-2",
 					Action: v1alpha1.AdminNetworkPolicyRuleActionDeny,
-					From: []v1alpha1.AdminNetworkPolicyPeer{
+					From: []v1alpha1.AdminNetworkPolicyIngressPeer{
 						{
-							Namespaces: &v1alpha1.NamespacedPeer{
-								NamespaceSelector: &v1.LabelSelector{
-									MatchLabels: map[string]string{
-										"kubernetes.io/metadata.name": "network-policy-conformance-hufflepuff",
-									},
+							Namespaces: &v1.LabelSelector{
+								MatchLabels: map[string]string{
+									"kubernetes.io/metadata.name": "network-policy-conformance-hufflepuff",
 								},
 							},
 						},
@@ -624,10 +568,15 @@ var CoreGressRulesCombinedBANB *v1alpha1.BaselineAdminNetworkPolicy = &v1alpha1.
 			{
 				Name:   "allow-to-ravenclaw-everything",
 				Action: v1alpha1.BaselineAdminNetworkPolicyRuleActionAllow,
-				To: []v1alpha1.AdminNetworkPolicyPeer{
+				To: []v1alpha1.BaselineAdminNetworkPolicyEgressPeer{
 					{
-						Namespaces: &v1alpha1.NamespacedPeer{
-							SameLabels: []string{"Test"},
+						Namespaces: &v1.LabelSelector{
+							MatchExpressions: []v1.LabelSelectorRequirement{
+								{
+									Key:      "Test",
+									Operator: v1.LabelSelectorOpExists,
+								},
+							},
 						},
 					},
 				},
@@ -635,10 +584,15 @@ var CoreGressRulesCombinedBANB *v1alpha1.BaselineAdminNetworkPolicy = &v1alpha1.
 			{
 				Name:   "deny-to-ravenclaw-everything",
 				Action: v1alpha1.BaselineAdminNetworkPolicyRuleActionDeny,
-				To: []v1alpha1.AdminNetworkPolicyPeer{
+				To: []v1alpha1.BaselineAdminNetworkPolicyEgressPeer{
 					{
-						Namespaces: &v1alpha1.NamespacedPeer{
-							NotSameLabels: []string{"Test1"},
+						Namespaces: &v1.LabelSelector{
+							MatchExpressions: []v1.LabelSelectorRequirement{
+								{
+									Key:      "Test1",
+									Operator: v1.LabelSelectorOpExists,
+								},
+							},
 						},
 					},
 				},
@@ -646,15 +600,13 @@ var CoreGressRulesCombinedBANB *v1alpha1.BaselineAdminNetworkPolicy = &v1alpha1.
 			{
 				Name:   "deny-to-slytherin-at-ports-80-53-9003",
 				Action: v1alpha1.BaselineAdminNetworkPolicyRuleActionDeny,
-				To: []v1alpha1.AdminNetworkPolicyPeer{
+				To: []v1alpha1.BaselineAdminNetworkPolicyEgressPeer{
 					{
-						Namespaces: &v1alpha1.NamespacedPeer{
-							NamespaceSelector: &v1.LabelSelector{
-								MatchExpressions: []v1.LabelSelectorRequirement{
-									{
-										Key:      "kubernetes.io/metadata.name",
-										Operator: v1.LabelSelectorOpExists,
-									},
+						Namespaces: &v1.LabelSelector{
+							MatchExpressions: []v1.LabelSelectorRequirement{
+								{
+									Key:      "kubernetes.io/metadata.name",
+									Operator: v1.LabelSelectorOpExists,
 								},
 							},
 						},
@@ -675,13 +627,11 @@ var CoreGressRulesCombinedBANB *v1alpha1.BaselineAdminNetworkPolicy = &v1alpha1.
 			{
 				Name:   "allow-to-hufflepuff-at-ports-8080-5353",
 				Action: v1alpha1.BaselineAdminNetworkPolicyRuleActionAllow,
-				To: []v1alpha1.AdminNetworkPolicyPeer{
+				To: []v1alpha1.BaselineAdminNetworkPolicyEgressPeer{
 					{
-						Namespaces: &v1alpha1.NamespacedPeer{
-							NamespaceSelector: &v1.LabelSelector{
-								MatchLabels: map[string]string{
-									"kubernetes.io/metadata.name": "network-policy-conformance-hufflepuff",
-								},
+						Namespaces: &v1.LabelSelector{
+							MatchLabels: map[string]string{
+								"kubernetes.io/metadata.name": "network-policy-conformance-hufflepuff",
 							},
 						},
 					},
@@ -701,13 +651,11 @@ var CoreGressRulesCombinedBANB *v1alpha1.BaselineAdminNetworkPolicy = &v1alpha1.
 			{
 				Name:   "deny-to-hufflepuff-everything-else",
 				Action: v1alpha1.BaselineAdminNetworkPolicyRuleActionDeny,
-				To: []v1alpha1.AdminNetworkPolicyPeer{
+				To: []v1alpha1.BaselineAdminNetworkPolicyEgressPeer{
 					{
-						Namespaces: &v1alpha1.NamespacedPeer{
-							NamespaceSelector: &v1.LabelSelector{
-								MatchLabels: map[string]string{
-									"kubernetes.io/metadata.name": "network-policy-conformance-hufflepuff",
-								},
+						Namespaces: &v1.LabelSelector{
+							MatchLabels: map[string]string{
+								"kubernetes.io/metadata.name": "network-policy-conformance-hufflepuff",
 							},
 						},
 					},
@@ -718,13 +666,11 @@ var CoreGressRulesCombinedBANB *v1alpha1.BaselineAdminNetworkPolicy = &v1alpha1.
 			{
 				Name:   "allow-from-ravenclaw-everything",
 				Action: v1alpha1.BaselineAdminNetworkPolicyRuleActionAllow,
-				From: []v1alpha1.AdminNetworkPolicyPeer{
+				From: []v1alpha1.AdminNetworkPolicyIngressPeer{
 					{
-						Namespaces: &v1alpha1.NamespacedPeer{
-							NamespaceSelector: &v1.LabelSelector{
-								MatchLabels: map[string]string{
-									"kubernetes.io/metadata.name": "network-policy-conformance-ravenclaw",
-								},
+						Namespaces: &v1.LabelSelector{
+							MatchLabels: map[string]string{
+								"kubernetes.io/metadata.name": "network-policy-conformance-ravenclaw",
 							},
 						},
 					},
@@ -733,13 +679,11 @@ var CoreGressRulesCombinedBANB *v1alpha1.BaselineAdminNetworkPolicy = &v1alpha1.
 			{
 				Name:   "deny-from-slytherin-at-port-80-53-9003",
 				Action: v1alpha1.BaselineAdminNetworkPolicyRuleActionDeny,
-				From: []v1alpha1.AdminNetworkPolicyPeer{
+				From: []v1alpha1.AdminNetworkPolicyIngressPeer{
 					{
-						Namespaces: &v1alpha1.NamespacedPeer{
-							NamespaceSelector: &v1.LabelSelector{
-								MatchLabels: map[string]string{
-									"kubernetes.io/metadata.name": "network-policy-conformance-slytherin",
-								},
+						Namespaces: &v1.LabelSelector{
+							MatchLabels: map[string]string{
+								"kubernetes.io/metadata.name": "network-policy-conformance-slytherin",
 							},
 						},
 					},
@@ -759,13 +703,11 @@ var CoreGressRulesCombinedBANB *v1alpha1.BaselineAdminNetworkPolicy = &v1alpha1.
 			{
 				Name:   "allow-from-hufflepuff-at-port-80-5353-9003",
 				Action: v1alpha1.BaselineAdminNetworkPolicyRuleActionAllow,
-				From: []v1alpha1.AdminNetworkPolicyPeer{
+				From: []v1alpha1.AdminNetworkPolicyIngressPeer{
 					{
-						Namespaces: &v1alpha1.NamespacedPeer{
-							NamespaceSelector: &v1.LabelSelector{
-								MatchLabels: map[string]string{
-									"kubernetes.io/metadata.name": "network-policy-conformance-hufflepuff",
-								},
+						Namespaces: &v1.LabelSelector{
+							MatchLabels: map[string]string{
+								"kubernetes.io/metadata.name": "network-policy-conformance-hufflepuff",
 							},
 						},
 					},
@@ -785,13 +727,11 @@ var CoreGressRulesCombinedBANB *v1alpha1.BaselineAdminNetworkPolicy = &v1alpha1.
 			{
 				Name:   "deny-from-hufflepuff-everything-else",
 				Action: v1alpha1.BaselineAdminNetworkPolicyRuleActionDeny,
-				From: []v1alpha1.AdminNetworkPolicyPeer{
+				From: []v1alpha1.AdminNetworkPolicyIngressPeer{
 					{
-						Namespaces: &v1alpha1.NamespacedPeer{
-							NamespaceSelector: &v1.LabelSelector{
-								MatchLabels: map[string]string{
-									"kubernetes.io/metadata.name": "network-policy-conformance-hufflepuff",
-								},
+						Namespaces: &v1.LabelSelector{
+							MatchLabels: map[string]string{
+								"kubernetes.io/metadata.name": "network-policy-conformance-hufflepuff",
 							},
 						},
 					},
@@ -819,13 +759,11 @@ var SimpleANPs = []*v1alpha1.AdminNetworkPolicy{
 				{
 					Name:   "allow-to-ravenclaw-everything",
 					Action: v1alpha1.AdminNetworkPolicyRuleActionAllow,
-					To: []v1alpha1.AdminNetworkPolicyPeer{
+					To: []v1alpha1.AdminNetworkPolicyEgressPeer{
 						{
-							Namespaces: &v1alpha1.NamespacedPeer{
-								NamespaceSelector: &v1.LabelSelector{
-									MatchLabels: map[string]string{
-										"kubernetes.io/metadata.name": "network-policy-conformance-ravenclaw",
-									},
+							Namespaces: &v1.LabelSelector{
+								MatchLabels: map[string]string{
+									"kubernetes.io/metadata.name": "network-policy-conformance-ravenclaw",
 								},
 							},
 						},
@@ -851,13 +789,11 @@ var SimpleANPs = []*v1alpha1.AdminNetworkPolicy{
 				{
 					Name:   "allow-to-ravenclaw-everything",
 					Action: v1alpha1.AdminNetworkPolicyRuleActionDeny,
-					To: []v1alpha1.AdminNetworkPolicyPeer{
+					To: []v1alpha1.AdminNetworkPolicyEgressPeer{
 						{
-							Namespaces: &v1alpha1.NamespacedPeer{
-								NamespaceSelector: &v1.LabelSelector{
-									MatchLabels: map[string]string{
-										"kubernetes.io/metadata.name": "network-policy-conformance-ravenclaw",
-									},
+							Namespaces: &v1.LabelSelector{
+								MatchLabels: map[string]string{
+									"kubernetes.io/metadata.name": "network-policy-conformance-ravenclaw",
 								},
 							},
 						},
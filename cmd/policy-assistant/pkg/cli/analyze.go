@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/olekukonko/tablewriter"
+	"golang.org/x/exp/slices"
 	"golang.org/x/net/context"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/kubernetes"
@@ -20,8 +21,10 @@ import (
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/kube"
 	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/matcher"
 	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/utils"
@@ -29,11 +32,12 @@ import (
 
 const (
 	// ParseMode        = "parse"
-	ExplainMode = "explain"
-	// QueryTrafficMode = "query-traffic"
-	// QueryTargetMode  = "query-target"
+	ExplainMode            = "explain"
+	QueryTrafficMode       = "query-traffic"
+	QueryTargetMode        = "query-target"
 	ProbeMode              = "probe"
 	VerdictWalkthroughMode = "walkthrough"
+	LintMode               = "lint"
 )
 
 // should we remove commented out modes or implement them later?
@@ -41,10 +45,23 @@ const (
 var AllModes = []string{
 	// ParseMode,
 	ExplainMode,
-	// QueryTrafficMode,
-	// QueryTargetMode,
+	QueryTrafficMode,
+	QueryTargetMode,
 	ProbeMode,
 	VerdictWalkthroughMode,
+	LintMode,
+}
+
+const (
+	TableOutput = "table"
+	JSONOutput  = "json"
+	YAMLOutput  = "yaml"
+)
+
+var AllOutputFormats = []string{
+	TableOutput,
+	JSONOutput,
+	YAMLOutput,
 }
 
 const DefaultTimeout = 3 * time.Minute
@@ -57,8 +74,14 @@ type AnalyzeArgs struct {
 	Context            string
 	SimplifyPolicies   bool
 
+	// optional: file in the /etc/hosts format, for resolving domain names of ANP DomainNames rules
+	HostsFile string
+
 	Modes []string
 
+	// Output is the format to print the results of every mode in
+	Output string
+
 	// traffic
 	TrafficPath string
 
@@ -67,6 +90,9 @@ type AnalyzeArgs struct {
 
 	// synthetic probe
 	ProbePath string
+	// optional: print the synthetic probe as a graph in this format, instead of as tables
+	ProbeGraphFormat      string
+	ProbeAggregationLevel string
 
 	Timeout time.Duration
 
@@ -77,6 +103,10 @@ type AnalyzeArgs struct {
 	Port int
 
 	Protocol string
+
+	// Watch keeps running after the modes, and prints the flows whose verdict changes as the cluster changes
+	Watch         bool
+	WatchDebounce time.Duration
 }
 
 func SetupAnalyzeCommand() *cobra.Command {
@@ -97,17 +127,24 @@ func SetupAnalyzeCommand() *cobra.Command {
 	command.Flags().StringVar(&args.PolicyPath, "policy-path", "", "may be a file or a directory; if set, will attempt to read policies from the path")
 	command.Flags().StringVar(&args.Context, "context", "", "selects kube context to read policies from; only reads from kube if one or more namespaces or all namespaces are specified")
 	command.Flags().BoolVar(&args.SimplifyPolicies, "simplify-policies", true, "if true, reduce policies to simpler form while preserving semantics (only applies to NPv1 currently)")
+	command.Flags().StringVar(&args.HostsFile, "hosts-file", "", "path to a file in the /etc/hosts format; if set, used to resolve between IPs and domain names for ANP DomainNames rules")
 
 	command.Flags().StringSliceVar(&args.Modes, "mode", []string{ExplainMode}, "analysis modes to run; allowed values are "+strings.Join(AllModes, ","))
 
+	command.Flags().StringVarP(&args.Output, "output", "o", TableOutput, "output format; allowed values are "+strings.Join(AllOutputFormats, ","))
+
 	command.Flags().StringVar(&args.TargetPodPath, "target-pod-path", "", "path to json target pod file -- json array of dicts")
 	command.Flags().StringVar(&args.TrafficPath, "traffic-path", "", "path to json traffic file, containing of a list of traffic objects")
 	command.Flags().StringVar(&args.ProbePath, "probe-path", "", "path to json model file for synthetic probe")
+	command.Flags().StringVar(&args.ProbeGraphFormat, "probe-graph-format", "", "if set, print the synthetic probe as a graph of allowed flows, labeled with port/protocol and the kind of the deciding policies, instead of as tables; allowed values are "+strings.Join(probe.AllGraphFormats, ","))
+	command.Flags().StringVar(&args.ProbeAggregationLevel, "probe-aggregation-level", string(probe.AggregationLevelPod), "what to group the pods of the synthetic probe into, for tables and graphs; allowed values are "+strings.Join(probe.AllAggregationLevels, ","))
 	command.Flags().DurationVar(&args.Timeout, "kube-client-timeout", DefaultTimeout, "kube client timeout")
 	command.Flags().StringVar(&args.SourceWorkloadTraffic, "src-workload", "", "Source workload traffic in this form namespace/workloadType/workloadName")
 	command.Flags().StringVar(&args.DestinationWorkloadTraffic, "dst-workload", "", "Destination workload traffic Name in this form namespace/workloadType/workloadName")
 	command.Flags().IntVar(&args.Port, "port", 0, "port used for testing network policies")
 	command.Flags().StringVar(&args.Protocol, "protocol", "", "protocol used for testing network policies")
+	command.Flags().BoolVar(&args.Watch, "watch", false, "if true, after running the modes, watch the policies, pods and namespaces of the cluster, and print the flows whose verdict changes whenever a policy or a label changes")
+	command.Flags().DurationVar(&args.WatchDebounce, "watch-debounce", 2*time.Second, "with 'watch', how long to wait for more changes after one, so that a burst of changes is reported together")
 
 	return command
 }
@@ -120,19 +157,26 @@ func RunAnalyzeCommand(args *AnalyzeArgs) {
 	var kubePods []v1.Pod
 	var kubeNamespaces []v1.Namespace
 	var netpolErr, anpErr, banpErr error
+	var kubeClient *kube.Kubernetes
+	var namespaces []string
+	var includeANPS, includeBANPSs bool
+	if args.Watch && !args.AllNamespaces && len(args.Namespaces) == 0 {
+		panic(errors.Errorf("watch requires one or more namespaces or all namespaces"))
+	}
 	if args.AllNamespaces || len(args.Namespaces) > 0 {
-		kubeClient, err := kube.NewKubernetesForContext(args.Context)
+		var err error
+		kubeClient, err = kube.NewKubernetesForContext(args.Context)
 		utils.DoOrDie(err)
 
-		namespaces := args.Namespaces
+		namespaces = args.Namespaces
 		if args.AllNamespaces {
-			nsList, err := kubeClient.GetAllNamespaces()
-			utils.DoOrDie(err)
-			kubeNamespaces = nsList.Items
 			namespaces = []string{v1.NamespaceAll}
 		}
+		if modesReadPodsFromKube(args) {
+			namespaces, kubePods, kubeNamespaces = ReadPodsAndNamespacesFromKube(kubeClient, args.Namespaces, args.AllNamespaces)
+		}
 
-		includeANPS, includeBANPSs := shouldIncludeANPandBANP(kubeClient.ClientSet)
+		includeANPS, includeBANPSs = shouldIncludeANPandBANP(kubeClient.ClientSet)
 
 		ctx, cancel := context.WithTimeout(context.TODO(), args.Timeout)
 		defer cancel()
@@ -150,6 +194,9 @@ func RunAnalyzeCommand(args *AnalyzeArgs) {
 		}
 	}
 	// 2. read policies from file
+	var extraPolicies []*networkingv1.NetworkPolicy
+	var extraANPs []*v1alpha1.AdminNetworkPolicy
+	var extraBANP *v1alpha1.BaselineAdminNetworkPolicy
 	if args.PolicyPath != "" {
 		policiesFromPath, anpsFromPath, banpFromPath, err := kube.ReadNetworkPoliciesFromPath(args.PolicyPath)
 		utils.DoOrDie(err)
@@ -159,6 +206,7 @@ func RunAnalyzeCommand(args *AnalyzeArgs) {
 			logrus.Debugf("More that one banp parsed - setting banp from file")
 		}
 		kubeBANP = banpFromPath
+		extraPolicies, extraANPs, extraBANP = append(extraPolicies, policiesFromPath...), append(extraANPs, anpsFromPath...), banpFromPath
 	}
 	// 3. read example policies
 	if args.UseExamplePolicies {
@@ -169,82 +217,484 @@ func RunAnalyzeCommand(args *AnalyzeArgs) {
 			logrus.Debugf("More that onew banp parsed - setting banp from the examples")
 		}
 		kubeBANP = examples.CoreGressRulesCombinedBANB
+		extraPolicies, extraANPs, extraBANP = append(extraPolicies, netpol.AllExamples...), append(extraANPs, examples.CoreGressRulesCombinedANB...), examples.CoreGressRulesCombinedBANB
+	}
+
+	if args.Output == "" {
+		args.Output = TableOutput
+	}
+	if !slices.Contains(AllOutputFormats, args.Output) {
+		panic(errors.Errorf("unrecognized output format %s, must be one of %s", args.Output, strings.Join(AllOutputFormats, ",")))
 	}
 
 	logrus.Debugf("parsed policies:\n%s", json.MustMarshalToString(kubePolicies))
-	policies := matcher.BuildV1AndV2NetPols(args.SimplifyPolicies, kubePolicies, kubeANPs, kubeBANP)
+	// built on first use, since building panics on some invalid policies which lint mode reports on instead
+	var policies *matcher.Policy
+	buildPolicies := func() *matcher.Policy {
+		if policies == nil {
+			policies = matcher.BuildV1AndV2NetPols(args.SimplifyPolicies, kubePolicies, kubeANPs, kubeBANP)
+			if args.HostsFile != "" {
+				resolver, err := matcher.NewStaticResolverFromHostsFile(args.HostsFile)
+				utils.DoOrDie(err)
+				policies.Resolver = resolver
+			}
+		}
+		return policies
+	}
 
 	for _, mode := range args.Modes {
 		// see analyze_unimplemented.go for unimplemented modes and the "case" statements for them
 		switch mode {
 		case ExplainMode:
-			fmt.Println("explained policies:")
-			ExplainPolicies(policies)
+			printModeHeader(args.Output, "explained policies:")
+			ExplainPolicies(buildPolicies(), args.Output)
+		case QueryTargetMode:
+			printModeHeader(args.Output, "query target:")
+			QueryTargets(buildPolicies(), args.TargetPodPath, QueryTargetPodsFromKube(kubePods, kubeNamespaces), args.Output)
+		case QueryTrafficMode:
+			printModeHeader(args.Output, "query traffic:")
+			QueryTraffic(buildPolicies(), args.TrafficPath, args.Output)
 		case ProbeMode:
-			fmt.Println("probe (simulated connectivity):")
-			ProbeSyntheticConnectivity(policies, args.ProbePath, kubePods, kubeNamespaces)
+			level := probe.AggregationLevelPod
+			if args.ProbeAggregationLevel != "" {
+				level = probe.AggregationLevel(args.ProbeAggregationLevel)
+			}
+			var workloads map[string]string
+			if kubeClient != nil && level == probe.AggregationLevelWorkload {
+				workloads = ReadPodWorkloadsFromKube(kubeClient, namespaces, kubePods)
+			}
+			if args.ProbeGraphFormat != "" {
+				PrintSyntheticConnectivityGraphs(buildPolicies(), args.ProbePath, kubePods, kubeNamespaces, level, args.ProbeGraphFormat, workloads)
+				break
+			}
+			printModeHeader(args.Output, "probe (simulated connectivity):")
+			if level != probe.AggregationLevelPod {
+				ProbeAggregatedSyntheticConnectivity(buildPolicies(), args.ProbePath, kubePods, kubeNamespaces, level, workloads, args.Output)
+				break
+			}
+			ProbeSyntheticConnectivity(buildPolicies(), args.ProbePath, kubePods, kubeNamespaces, args.Output)
 		case VerdictWalkthroughMode:
-			fmt.Println("verdict walkthrough:")
-			VerdictWalkthrough(policies, args.SourceWorkloadTraffic, args.DestinationWorkloadTraffic, args.Port, args.Protocol, args.TrafficPath)
+			printModeHeader(args.Output, "verdict walkthrough:")
+			VerdictWalkthrough(buildPolicies(), args.SourceWorkloadTraffic, args.DestinationWorkloadTraffic, args.Port, args.Protocol, args.TrafficPath, args.Output)
+		case LintMode:
+			printModeHeader(args.Output, "lint:")
+			LintPolicies(kubePolicies, kubeANPs, kubeBANP, args.Output)
 		default:
 			panic(errors.Errorf("unrecognized mode %s", mode))
 		}
 	}
+
+	if args.Watch {
+		printModeHeader(args.Output, "watch:")
+		RunWatch(kubeClient, &WatchArgs{
+			Namespaces:       namespaces,
+			IncludeANPs:      includeANPS,
+			IncludeBANPs:     includeBANPSs,
+			SimplifyPolicies: args.SimplifyPolicies,
+			HostsFile:        args.HostsFile,
+			Debounce:         args.WatchDebounce,
+			Policies:         extraPolicies,
+			ANPs:             extraANPs,
+			BANP:             extraBANP,
+			Output:           args.Output,
+		})
+	}
+}
+
+// modesReadPodsFromKube returns true if any of the modes uses the pods of the cluster: query-target does, and
+// probe does unless its model comes from a file.  Other modes only read policies, so they don't need access to pods.
+func modesReadPodsFromKube(args *AnalyzeArgs) bool {
+	for _, mode := range args.Modes {
+		if mode == QueryTargetMode || (mode == ProbeMode && args.ProbePath == "") {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadPodsAndNamespacesFromKube returns the namespaces to read kube resources from, along with their pods and namespace objects
+func ReadPodsAndNamespacesFromKube(kubeClient *kube.Kubernetes, namespaces []string, allNamespaces bool) ([]string, []v1.Pod, []v1.Namespace) {
+	var kubeNamespaces []v1.Namespace
+	if allNamespaces {
+		nsList, err := kubeClient.GetAllNamespaces()
+		utils.DoOrDie(err)
+		kubeNamespaces = nsList.Items
+		namespaces = []string{v1.NamespaceAll}
+	} else {
+		for _, ns := range namespaces {
+			kubeNamespace, err := kubeClient.GetNamespace(ns)
+			utils.DoOrDie(err)
+			kubeNamespaces = append(kubeNamespaces, *kubeNamespace)
+		}
+	}
+
+	kubePods, err := kube.GetPodsInNamespaces(kubeClient, namespaces)
+	utils.DoOrDie(err)
+	return namespaces, kubePods, kubeNamespaces
+}
+
+// printModeHeader only prints for table output, so that json and yaml output can be parsed
+func printModeHeader(output string, header string) {
+	if output == TableOutput {
+		fmt.Println(header)
+	}
+}
+
+// printStructured prints obj in json or yaml.  Each call prints a separate json value or yaml document,
+// so that the results of multiple modes can be told apart.
+func printStructured(output string, obj interface{}) {
+	switch output {
+	case JSONOutput:
+		fmt.Println(json.MustMarshalToString(obj))
+	case YAMLOutput:
+		fmt.Printf("---\n%s", utils.YamlString(obj))
+	default:
+		panic(errors.Errorf("unrecognized output format %s", output))
+	}
 }
 
-func ExplainPolicies(explainedPolicies *matcher.Policy) {
+func ExplainPolicies(explainedPolicies *matcher.Policy, output string) {
+	if output != TableOutput {
+		printStructured(output, explainedPolicies)
+		return
+	}
 	fmt.Printf("%s\n", explainedPolicies.ExplainTable())
 }
 
+func LintPolicies(netpols []*networkingv1.NetworkPolicy, anps []*v1alpha1.AdminNetworkPolicy, banp *v1alpha1.BaselineAdminNetworkPolicy, output string) {
+	findings := matcher.Lint(netpols, anps, banp)
+
+	if output != TableOutput {
+		printStructured(output, findings)
+		return
+	}
+
+	if len(findings) == 0 {
+		fmt.Printf("no problems found\n\n")
+		return
+	}
+	fmt.Printf("%s\n", matcher.LintFindingsTable(findings))
+}
+
+// QueryTargetPod is a pod to find the targets of.  If NamespaceLabels is empty,
+// the namespace is assumed to only have the 'kubernetes.io/metadata.name' label.
+type QueryTargetPod struct {
+	Namespace       string
+	NamespaceLabels map[string]string
+	Labels          map[string]string
+}
+
+func QueryTargetPodsFromKube(kubePods []v1.Pod, kubeNamespaces []v1.Namespace) []*QueryTargetPod {
+	nsLabels := map[string]map[string]string{}
+	for _, ns := range kubeNamespaces {
+		nsLabels[ns.Name] = ns.Labels
+	}
+
+	pods := make([]*QueryTargetPod, len(kubePods))
+	for i, p := range kubePods {
+		pods[i] = &QueryTargetPod{
+			Namespace:       p.Namespace,
+			NamespaceLabels: nsLabels[p.Namespace],
+			Labels:          p.Labels,
+		}
+	}
+	return pods
+}
+
+func QueryTargets(explainedPolicies *matcher.Policy, podPath string, pods []*QueryTargetPod, output string) {
+	if podPath != "" {
+		podsFromFile, err := json.ParseFile[[]*QueryTargetPod](podPath)
+		utils.DoOrDie(err)
+		pods = append(pods, *podsFromFile...)
+	}
+
+	results := make([]*QueryTargetResult, len(pods))
+	for i, pod := range pods {
+		results[i] = QueryTargetHelper(explainedPolicies, pod)
+	}
+
+	if output != TableOutput {
+		printStructured(output, results)
+		return
+	}
+
+	for _, result := range results {
+		fmt.Printf("pod in ns %s with labels %+v:\n\n", result.Pod.Namespace, result.Pod.Labels)
+
+		fmt.Printf("Matching NetworkPolicy targets:\n%s\n", result.V1Targets.ExplainTable())
+		fmt.Printf("Matching AdminNetworkPolicies and BaselineAdminNetworkPolicy:\n%s\n", result.AdminPoliciesTable())
+		fmt.Printf("Combined rules:\n%s\n\n\n", result.CombinedRules.ExplainTable())
+	}
+}
+
+type QueryTargetResult struct {
+	Pod *QueryTargetPod
+	// V1Targets holds the NPv1 targets selecting the pod
+	V1Targets *matcher.Policy
+	// AdminPolicies holds the ANPs (by priority) and BANP selecting the pod, along with the directions they select it for
+	AdminPolicies []*QueryTargetAdminPolicy
+	// CombinedRules holds at most one target per direction, combining every rule which applies to the pod
+	CombinedRules *matcher.Policy
+}
+
+type QueryTargetAdminPolicy struct {
+	matcher.AdminPolicy
+	Ingress bool
+	Egress  bool
+}
+
+func (r *QueryTargetResult) AdminPoliciesTable() string {
+	tableString := &strings.Builder{}
+	table := tablewriter.NewWriter(tableString)
+	table.SetAutoWrapText(false)
+	table.SetRowLine(true)
+	table.SetHeader([]string{"Type", "Priority", "Name", "Ingress", "Egress"})
+
+	for _, p := range r.AdminPolicies {
+		priority := ""
+		if p.Kind == matcher.AdminNetworkPolicy {
+			priority = fmt.Sprintf("%d", p.Priority)
+		}
+		table.Append([]string{string(p.Kind), priority, p.Name, fmt.Sprintf("%t", p.Ingress), fmt.Sprintf("%t", p.Egress)})
+	}
+
+	table.Render()
+	return tableString.String()
+}
+
+func QueryTargetHelper(policies *matcher.Policy, pod *QueryTargetPod) *QueryTargetResult {
+	nsLabels := pod.NamespaceLabels
+	if len(nsLabels) == 0 {
+		nsLabels = map[string]string{v1.LabelMetadataName: pod.Namespace}
+	}
+	podInfo := &matcher.InternalPeer{
+		Namespace:       pod.Namespace,
+		NamespaceLabels: nsLabels,
+		PodLabels:       pod.Labels,
+	}
+	ingressTargets := policies.TargetsApplyingToPod(true, podInfo)
+	egressTargets := policies.TargetsApplyingToPod(false, podInfo)
+
+	adminPolicies := map[matcher.AdminPolicy]*QueryTargetAdminPolicy{}
+	var sortedAdminPolicies []matcher.AdminPolicy
+	addAdminPolicies := func(targets []*matcher.Target, isIngress bool) []*matcher.Target {
+		var v1Targets []*matcher.Target
+		for _, target := range targets {
+			if _, ok := target.SubjectMatcher.(*matcher.SubjectV1); ok {
+				v1Targets = append(v1Targets, target)
+				continue
+			}
+			for _, p := range target.AdminPolicies() {
+				if _, ok := adminPolicies[p]; !ok {
+					adminPolicies[p] = &QueryTargetAdminPolicy{AdminPolicy: p}
+					sortedAdminPolicies = append(sortedAdminPolicies, p)
+				}
+				if isIngress {
+					adminPolicies[p].Ingress = true
+				} else {
+					adminPolicies[p].Egress = true
+				}
+			}
+		}
+		return v1Targets
+	}
+	v1IngressTargets := addAdminPolicies(ingressTargets, true)
+	v1EgressTargets := addAdminPolicies(egressTargets, false)
+
+	matcher.SortAdminPolicies(sortedAdminPolicies)
+	result := &QueryTargetResult{
+		Pod:       pod,
+		V1Targets: matcher.NewPolicyWithTargets(v1IngressTargets, v1EgressTargets),
+	}
+	for _, p := range sortedAdminPolicies {
+		result.AdminPolicies = append(result.AdminPolicies, adminPolicies[p])
+	}
+
+	podSelector := metav1.LabelSelector{MatchLabels: pod.Labels}
+	var combinedIngresses []*matcher.Target
+	if combined := matcher.CombineTargetsIgnoringPrimaryKey(pod.Namespace, podSelector, ingressTargets); combined != nil {
+		combinedIngresses = []*matcher.Target{combined}
+	}
+	var combinedEgresses []*matcher.Target
+	if combined := matcher.CombineTargetsIgnoringPrimaryKey(pod.Namespace, podSelector, egressTargets); combined != nil {
+		combinedEgresses = []*matcher.Target{combined}
+	}
+	result.CombinedRules = matcher.NewPolicyWithTargets(combinedIngresses, combinedEgresses)
+
+	return result
+}
+
+// QueryTrafficResult is the verdict for a single entry of a traffic file
+type QueryTrafficResult struct {
+	Traffic *matcher.Traffic
+	Result  *matcher.AllowedResult
+}
+
+func QueryTraffic(explainedPolicies *matcher.Policy, trafficPath string, output string) {
+	if trafficPath == "" {
+		logrus.Fatalf("%+v", errors.Errorf("path to traffic file required for query-traffic mode"))
+	}
+	allTraffics, err := json.ParseFile[[]*matcher.Traffic](trafficPath)
+	utils.DoOrDie(err)
+
+	results := make([]*QueryTrafficResult, len(*allTraffics))
+	for i, traffic := range *allTraffics {
+		results[i] = &QueryTrafficResult{Traffic: traffic, Result: explainedPolicies.IsTrafficAllowed(traffic)}
+	}
+
+	if output != TableOutput {
+		printStructured(output, results)
+		return
+	}
+
+	for _, r := range results {
+		fmt.Printf("Traffic:\n%s\n", r.Traffic.Table())
+		fmt.Printf("Is traffic allowed?\n%s\n\n\n", r.Result.Table())
+	}
+}
+
 type SyntheticProbeConnectivityConfig struct {
 	Resources *probe.Resources
 	Probes    []*generator.PortProtocol
 }
 
-func ProbeSyntheticConnectivity(explainedPolicies *matcher.Policy, modelPath string, kubePods []v1.Pod, kubeNamespaces []v1.Namespace) {
-	if modelPath != "" {
-		config, err := json.ParseFile[SyntheticProbeConnectivityConfig](modelPath)
+// SyntheticProbeResult is the simulated connectivity for one of the probes of a synthetic probe, between classes of
+// pods which policies can't tell apart.  Consumers read it once per pair of classes, or expand it into a Table.
+type SyntheticProbeResult struct {
+	Description string
+	Classes     *probe.ClassTable
+}
+
+// ExpandedSyntheticProbeResult is a synthetic probe with a cell for every pair of pods
+type ExpandedSyntheticProbeResult struct {
+	Description string
+	Table       *probe.Table
+}
+
+func ProbeSyntheticConnectivity(explainedPolicies *matcher.Policy, modelPath string, kubePods []v1.Pod, kubeNamespaces []v1.Namespace, output string) {
+	var results []*ExpandedSyntheticProbeResult
+	for _, result := range SimulateSyntheticConnectivity(explainedPolicies, modelPath, kubePods, kubeNamespaces) {
+		results = append(results, &ExpandedSyntheticProbeResult{Description: result.Description, Table: result.Classes.Table()})
+	}
+
+	if output != TableOutput {
+		printStructured(output, results)
+		return
+	}
+
+	for _, result := range results {
+		logrus.Info(result.Description)
+		fmt.Printf("Ingress:\n%s\n", result.Table.RenderIngress())
+		fmt.Printf("Egress:\n%s\n", result.Table.RenderEgress())
+		fmt.Printf("Combined:\n%s\n\n\n", result.Table.RenderTable())
+	}
+}
+
+// AggregatedSyntheticProbeResult is a synthetic probe whose pods are grouped into namespaces or workloads
+type AggregatedSyntheticProbeResult struct {
+	Description string
+	Table       *probe.AggregatedTable
+}
+
+// ProbeAggregatedSyntheticConnectivity prints each synthetic probe with its cells collapsed by namespace or by
+// workload, saying whether each is all-allowed, all-blocked, all-invalid or mixed
+func ProbeAggregatedSyntheticConnectivity(explainedPolicies *matcher.Policy, modelPath string, kubePods []v1.Pod, kubeNamespaces []v1.Namespace, level probe.AggregationLevel, workloads map[string]string, output string) {
+	var results []*AggregatedSyntheticProbeResult
+	for _, result := range SimulateSyntheticConnectivity(explainedPolicies, modelPath, kubePods, kubeNamespaces) {
+		table, err := probe.NewAggregatedTable(result.Classes, level, workloads)
 		utils.DoOrDie(err)
+		results = append(results, &AggregatedSyntheticProbeResult{Description: result.Description, Table: table})
+	}
 
-		jobBuilder := &probe.JobBuilder{TimeoutSeconds: 10}
+	if output != TableOutput {
+		printStructured(output, results)
+		return
+	}
 
-		if len(config.Probes) == 0 {
-			gen := generator.ProbeAllAvailable
-			simRunner := probe.NewSimulatedRunner(explainedPolicies, jobBuilder)
+	for _, result := range results {
+		logrus.Info(result.Description)
+		fmt.Printf("Combined, by %s:\n%s\n\n\n", result.Table.Level, result.Table.RenderTable())
+	}
+}
 
-			probeResult := simRunner.RunProbeForConfig(gen, config.Resources)
+// PrintSyntheticConnectivityGraphs prints each synthetic probe as a graph, which stays readable for more
+// pods than tables do
+func PrintSyntheticConnectivityGraphs(explainedPolicies *matcher.Policy, modelPath string, kubePods []v1.Pod, kubeNamespaces []v1.Namespace, level probe.AggregationLevel, format string, workloads map[string]string) {
+	for _, result := range SimulateSyntheticConnectivity(explainedPolicies, modelPath, kubePods, kubeNamespaces) {
+		logrus.Info(result.Description)
+		graph, err := probe.NewReachabilityGraph(result.Classes, level, workloads)
+		utils.DoOrDie(err)
+		rendered, err := graph.Render(format)
+		utils.DoOrDie(err)
+		fmt.Println(rendered)
+	}
+}
 
-			logrus.Info("probing all available ports")
-			fmt.Printf("Ingress:\n%s\n", probeResult.RenderIngress())
-			fmt.Printf("Egress:\n%s\n", probeResult.RenderEgress())
-			fmt.Printf("Combined:\n%s\n\n\n", probeResult.RenderTable())
+// ReadPodWorkloadsFromKube maps pods, by PodString, to the workloads owning them
+func ReadPodWorkloadsFromKube(kubeClient *kube.Kubernetes, namespaces []string, kubePods []v1.Pod) map[string]string {
+	var replicaSets []appsv1.ReplicaSet
+	for _, ns := range namespaces {
+		nsReplicaSets, err := kubeClient.GetReplicaSetsInNamespace(ns)
+		utils.DoOrDie(err)
+		replicaSets = append(replicaSets, nsReplicaSets...)
+	}
+	return kube.PodWorkloads(kubePods, replicaSets)
+}
 
-			return
+func SimulateSyntheticConnectivity(explainedPolicies *matcher.Policy, modelPath string, kubePods []v1.Pod, kubeNamespaces []v1.Namespace) []*SyntheticProbeResult {
+	resources, probes := SyntheticProbeModel(modelPath, kubePods, kubeNamespaces)
+
+	var results []*SyntheticProbeResult
+	for _, p := range probes {
+		engine := probe.NewEquivalenceClassEngine(explainedPolicies, &probe.JobBuilder{TimeoutSeconds: 10})
+		classTable := engine.RunProbeForConfig(p.Config, resources)
+		logrus.Debugf("simulating %s between %d classes of %d pods", p.Description, len(classTable.Classes.Classes), len(resources.Pods))
+		results = append(results, &SyntheticProbeResult{
+			Description: p.Description,
+			Classes:     classTable,
+		})
+	}
+	return results
+}
+
+// SyntheticProbe is one of the probes to simulate on the resources of a synthetic probe model
+type SyntheticProbe struct {
+	Description string
+	Config      *generator.ProbeConfig
+}
+
+// SyntheticProbeModel returns the resources and probes to simulate: from the model file if modelPath is set,
+// otherwise all available ports of the pods read from the cluster
+func SyntheticProbeModel(modelPath string, kubePods []v1.Pod, kubeNamespaces []v1.Namespace) (*probe.Resources, []*SyntheticProbe) {
+	if modelPath != "" {
+		config, err := json.ParseFile[SyntheticProbeConnectivityConfig](modelPath)
+		utils.DoOrDie(err)
+
+		if len(config.Probes) == 0 {
+			return config.Resources, []*SyntheticProbe{{Description: "probing all available ports", Config: generator.ProbeAllAvailable}}
 		}
 
-		// run probes
+		var probes []*SyntheticProbe
 		for _, probeConfig := range config.Probes {
-			gen := generator.NewProbeConfig(probeConfig.Port, probeConfig.Protocol, generator.ProbeModeServiceName)
-			simRunner := probe.NewSimulatedRunner(explainedPolicies, jobBuilder)
-			probeResult := simRunner.RunProbeForConfig(gen, config.Resources)
-
-			logrus.Infof("probe on port %s, protocol %s", probeConfig.Port.String(), probeConfig.Protocol)
-			fmt.Printf("Ingress:\n%s\n", probeResult.RenderIngress())
-			fmt.Printf("Egress:\n%s\n", probeResult.RenderEgress())
-			fmt.Printf("Combined:\n%s\n\n\n", probeResult.RenderTable())
+			probes = append(probes, &SyntheticProbe{
+				Description: fmt.Sprintf("probe on port %s, protocol %s", probeConfig.Port.String(), probeConfig.Protocol),
+				Config:      generator.NewProbeConfig(probeConfig.Port, probeConfig.Protocol, generator.ProbeModeServiceName),
+			})
 		}
-
-		return
+		return config.Resources, probes
 	}
 
+	return SyntheticProbeResourcesFromKube(kubePods, kubeNamespaces, logrus.Warnf), []*SyntheticProbe{{Description: "probing all available ports of pods from the cluster", Config: generator.ProbeAllAvailable}}
+}
+
+// SyntheticProbeResourcesFromKube models the pods which serve on a port; logSkipped reports the rest
+func SyntheticProbeResourcesFromKube(kubePods []v1.Pod, kubeNamespaces []v1.Namespace, logSkipped func(format string, args ...interface{})) *probe.Resources {
 	resources := &probe.Resources{
 		Namespaces: map[string]map[string]string{},
 		Pods:       []*probe.Pod{},
 	}
 
-	nsMap := map[string]v1.Namespace{}
 	for _, ns := range kubeNamespaces {
-		nsMap[ns.Name] = ns
 		resources.Namespaces[ns.Name] = ns.Labels
 	}
 
@@ -252,7 +702,7 @@ func ProbeSyntheticConnectivity(explainedPolicies *matcher.Policy, modelPath str
 		var containers []*probe.Container
 		for _, cont := range pod.Spec.Containers {
 			if len(cont.Ports) == 0 {
-				logrus.Warnf("skipping container %s/%s/%s, no ports available", pod.Namespace, pod.Name, cont.Name)
+				logSkipped("skipping container %s/%s/%s, no ports available", pod.Namespace, pod.Name, cont.Name)
 				continue
 			}
 			port := cont.Ports[0]
@@ -264,7 +714,7 @@ func ProbeSyntheticConnectivity(explainedPolicies *matcher.Policy, modelPath str
 			})
 		}
 		if len(containers) == 0 {
-			logrus.Warnf("skipping pod %s/%s, no containers available", pod.Namespace, pod.Name)
+			logSkipped("skipping pod %s/%s, no containers available", pod.Namespace, pod.Name)
 			continue
 		}
 		resources.Pods = append(resources.Pods, &probe.Pod{
@@ -276,11 +726,7 @@ func ProbeSyntheticConnectivity(explainedPolicies *matcher.Policy, modelPath str
 		})
 	}
 
-	simRunner := probe.NewSimulatedRunner(explainedPolicies, &probe.JobBuilder{TimeoutSeconds: 10})
-	simulatedProbe := simRunner.RunProbeForConfig(generator.ProbeAllAvailable, resources)
-	fmt.Printf("Ingress:\n%s\n", simulatedProbe.RenderIngress())
-	fmt.Printf("Egress:\n%s\n", simulatedProbe.RenderEgress())
-	fmt.Printf("Combined:\n%s\n\n\n", simulatedProbe.RenderTable())
+	return resources
 }
 
 func shouldIncludeANPandBANP(client *kubernetes.Clientset) (bool, bool) {
@@ -308,7 +754,7 @@ func shouldIncludeANPandBANP(client *kubernetes.Clientset) (bool, bool) {
 	return includeANP, includeBANP
 }
 
-func VerdictWalkthrough(policies *matcher.Policy, sourceWorkloadTraffic string, destinationWorkloadTraffic string, port int, protocol string, trafficPath string) {
+func VerdictWalkthrough(policies *matcher.Policy, sourceWorkloadTraffic string, destinationWorkloadTraffic string, port int, protocol string, trafficPath string, output string) {
 	var sourceWorkloadInfo matcher.TrafficPeer
 	var destinationWorkloadInfo matcher.TrafficPeer
 	var allTraffic []*matcher.Traffic
@@ -330,7 +776,11 @@ func VerdictWalkthrough(policies *matcher.Policy, sourceWorkloadTraffic string,
 			destinationInternal := traffic.Destination.Internal
 
 			podA = matcher.CreateTrafficPeer(traffic.Source.IP, nil)
+			podA.NodeLabels = traffic.Source.NodeLabels
+			podA.DomainName = traffic.Source.DomainName
 			podB = matcher.CreateTrafficPeer(traffic.Destination.IP, nil)
+			podB.NodeLabels = traffic.Destination.NodeLabels
+			podB.DomainName = traffic.Destination.DomainName
 
 			// Update podA and podB if internal information is available
 			if sourceInternal != nil {
@@ -340,6 +790,7 @@ func VerdictWalkthrough(policies *matcher.Policy, sourceWorkloadTraffic string,
 					Namespace:       sourceInternal.Namespace,
 					Workload:        sourceInternal.Workload,
 				})
+				podA.NodeLabels = traffic.Source.NodeLabels
 			}
 
 			if destinationInternal != nil {
@@ -349,6 +800,7 @@ func VerdictWalkthrough(policies *matcher.Policy, sourceWorkloadTraffic string,
 					Namespace:       destinationInternal.Namespace,
 					Workload:        destinationInternal.Workload,
 				})
+				podB.NodeLabels = traffic.Destination.NodeLabels
 			}
 
 			// Special case handling for workload-specific traffic (internal vs. external)
@@ -387,7 +839,8 @@ func VerdictWalkthrough(policies *matcher.Policy, sourceWorkloadTraffic string,
 				Namespace:       sourceWorkloadInfo.Internal.Namespace,
 				Workload:        sourceWorkloadInfo.Internal.Workload,
 			},
-			IP: sourceWorkloadInfo.Internal.Pods[0].IP,
+			IP:         sourceWorkloadInfo.Internal.Pods[0].IP,
+			NodeLabels: sourceWorkloadInfo.Internal.Pods[0].NodeLabels,
 		}
 		podB := &matcher.TrafficPeer{
 			Internal: &matcher.InternalPeer{
@@ -396,7 +849,8 @@ func VerdictWalkthrough(policies *matcher.Policy, sourceWorkloadTraffic string,
 				Namespace:       destinationWorkloadInfo.Internal.Namespace,
 				Workload:        destinationWorkloadInfo.Internal.Workload,
 			},
-			IP: destinationWorkloadInfo.Internal.Pods[0].IP,
+			IP:         destinationWorkloadInfo.Internal.Pods[0].IP,
+			NodeLabels: destinationWorkloadInfo.Internal.Pods[0].NodeLabels,
 		}
 		allTraffic = []*matcher.Traffic{
 			{
@@ -408,6 +862,16 @@ func VerdictWalkthrough(policies *matcher.Policy, sourceWorkloadTraffic string,
 		}
 	}
 
+	results := make([]*QueryTrafficResult, len(allTraffic))
+	for i, traffic := range allTraffic {
+		results[i] = &QueryTrafficResult{Traffic: traffic, Result: policies.IsTrafficAllowed(traffic)}
+	}
+
+	if output != TableOutput {
+		printStructured(output, results)
+		return
+	}
+
 	tableString := &strings.Builder{}
 	table := tablewriter.NewWriter(tableString)
 	table.SetAutoWrapText(false)
@@ -415,8 +879,8 @@ func VerdictWalkthrough(policies *matcher.Policy, sourceWorkloadTraffic string,
 	table.SetAutoMergeCells(true)
 
 	table.SetHeader([]string{"Traffic", "Verdict", "Ingress Walkthrough", "Egress Walkthrough"})
-	for _, traffic := range allTraffic {
-		trafficResult := policies.IsTrafficAllowed(traffic)
+	for _, r := range results {
+		traffic, trafficResult := r.Traffic, r.Result
 		ingressFlow := trafficResult.Ingress.Flow()
 		egressFlow := trafficResult.Egress.Flow()
 		if ingressFlow == "" {
@@ -1,14 +1,43 @@
 package cli
 
 import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/network-policy-api/apis/v1alpha1"
+	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/connectivity"
+	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/connectivity/probe"
+	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/generator"
+	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/kube"
+	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/utils"
 )
 
 type CompareArgs struct {
 	Noisy                     bool
 	NetpolCreationWaitSeconds int
 	Contexts                  []string
+	PolicyPath                string
+	PodCreationTimeoutSeconds int
+	ProbeMode                 string
+	JobTimeoutSeconds         int
+	BatchJobs                 bool
+
+	// what to probe on
+	ProbeAllAvailable bool
+	Ports             []string
+	Protocols         []string
+
+	// server setup
+	ServerProtocols  []string
+	ServerPorts      []int
+	ServerNamespaces []string
+	ServerPods       []string
+	ImageRegistry    string
 }
 
 func SetupCompareCommand() *cobra.Command {
@@ -17,8 +46,11 @@ func SetupCompareCommand() *cobra.Command {
 	command := &cobra.Command{
 		Use:   "compare",
 		Short: "compare network policy",
-		Long:  "Compare network policies between multiple clusters",
+		Long:  "Compare network policies between multiple clusters: deploys the same pods and policies to each kube context, probes, and prints where the clusters disagree",
 		Args:  cobra.ExactArgs(0),
+		PreRunE: func(cmd *cobra.Command, as []string) error {
+			return ValidateCompareArgs(args)
+		},
 		Run: func(cmd *cobra.Command, as []string) {
 			RunCompareCommand(args)
 		},
@@ -26,103 +58,103 @@ func SetupCompareCommand() *cobra.Command {
 
 	command.Flags().BoolVar(&args.Noisy, "noisy", false, "if true, print all results")
 	command.Flags().IntVar(&args.NetpolCreationWaitSeconds, "netpol-creation-wait-seconds", 5, "number of seconds to wait after creating a network policy before running probes, to give the CNI time to update the cluster state")
-	command.Flags().StringSliceVar(&args.Contexts, "context", []string{}, "kubernetes context to use; if empty, uses default context")
+	command.Flags().StringSliceVar(&args.Contexts, "context", []string{}, "kubernetes contexts to compare; must be passed at least twice")
+	command.Flags().StringVar(&args.PolicyPath, "policy-path", "", "may be a file or a directory; if set, will create the policies from the path in each context")
+	command.Flags().IntVar(&args.PodCreationTimeoutSeconds, "pod-creation-timeout-seconds", 60, "number of seconds to wait for pods to create, be running and have IP addresses")
+	command.Flags().StringVar(&args.ProbeMode, "probe-mode", generator.ProbeModeServiceName, "probe mode to use, must be one of "+strings.Join(generator.AllProbeModes, ", "))
+	command.Flags().IntVar(&args.JobTimeoutSeconds, "job-timeout-seconds", 10, "number of seconds to pass on to 'agnhost connect --timeout=%ds' flag")
+	command.Flags().BoolVar(&args.BatchJobs, "batch-jobs", false, "if true, run jobs in batches to avoid saturating the Kube APIServer with too many exec requests")
+
+	command.Flags().BoolVar(&args.ProbeAllAvailable, "all-available", true, "if true, probe all available ports and protocols on each pod")
+	command.Flags().StringSliceVar(&args.Ports, "port", []string{"80"}, "ports to run probes on; may be named port or numbered port")
+	command.Flags().StringSliceVar(&args.Protocols, "protocol", []string{"tcp"}, "protocols to run probes on")
+
+	command.Flags().StringSliceVarP(&args.ServerNamespaces, "server-namespace", "n", []string{"x", "y", "z"}, "namespaces to create/use pods in")
+	command.Flags().StringSliceVar(&args.ServerPods, "server-pod", []string{"a", "b", "c"}, "pods to create in namespaces")
+	command.Flags().IntSliceVar(&args.ServerPorts, "server-port", []int{80, 81}, "ports to run server on")
+	command.Flags().StringSliceVar(&args.ServerProtocols, "server-protocol", []string{"TCP", "UDP", "SCTP"}, "protocols to run server on")
+	command.Flags().StringVar(&args.ImageRegistry, "image-registry", "registry.k8s.io", "Image registry for agnhost")
 
 	return command
 }
 
+// ValidateCompareArgs checks the flags before anything is set up in the clusters, so that misuse is
+// reported along with the usage
+func ValidateCompareArgs(args *CompareArgs) error {
+	if len(args.Contexts) < 2 {
+		return errors.Errorf("need at least 2 contexts to compare, found %d: pass '--context' once per context", len(args.Contexts))
+	}
+	if len(args.ServerNamespaces) == 0 || len(args.ServerPods) == 0 {
+		return errors.Errorf("found 0 namespaces or pods, must have at least 1 of each")
+	}
+	return nil
+}
+
 func RunCompareCommand(args *CompareArgs) {
-	panic("TODO")
-	/*
-		namespaces := []string{"x", "y", "z"}
-		pods := []string{"a", "b", "c"}
-
-		protocol := v1.ProtocolTCP
-		probePort := intstr.FromInt(80)
-
-		serverPort := 80
-
-		kubeClients := map[string]*kube.Kubernetes{}
-		if len(args.Contexts) == 0 {
-			kubernetes, err := kube.NewKubernetesForContext("")
-			utils.DoOrDie(err)
-			kubeClients["default-context"] = kubernetes
-		} else {
-			for _, context := range args.Contexts {
-				kubernetes, err := kube.NewKubernetesForContext(context)
-				utils.DoOrDie(err)
-				kubeClients[context] = kubernetes
-			}
-		}
+	utils.DoOrDie(ValidateCompareArgs(args))
 
-		kubeResources := map[string]*connectivitykube.Resources{}
-		var syntheticResources *synthetic.Resources
-		var zcIP string
-		for context, kubeClient := range kubeClients {
-			kubernetesResources, synth, err := connectivity.SetupClusterTODODelete(kubeClient, namespaces, pods, serverPort, protocol)
-			utils.DoOrDie(err)
-			// TODO this is a huge hack -- ips are going to be different from cluster to cluster, which means
-			//   that policies involving ips need to be different from cluster to cluster.  But here we're just
-			//   taking the first one and using it everywhere.
-			if syntheticResources == nil {
-				syntheticResources = synth
-
-				zcPod, err := kubeClient.GetPod("z", "c")
-				utils.DoOrDie(err)
-				if zcPod.Status.PodIP == "" {
-					panic(errors.Errorf("no ip found for pod z/c"))
-				}
-				zcIP = zcPod.Status.PodIP
-			}
+	serverProtocols := parseProtocols(args.ServerProtocols)
 
-			kubeResources[context] = kubernetesResources
-		}
+	kubeClients := map[string]kube.IKubernetes{}
+	kubeResources := map[string]*probe.Resources{}
+	for _, kubeContext := range args.Contexts {
+		kubernetes, err := kube.NewKubernetesForContext(kubeContext)
+		utils.DoOrDie(err)
+		kubeClients[kubeContext] = kubernetes
 
-		fragGenerator := generator.NewDefaultFragmentGenerator(true, namespaces, zcIP)
-		kubePolicySlices := packIntoSlices(fragGenerator.FragmentPolicies())
+		logrus.Infof("setting up resources in context %s", kubeContext)
+		resources, err := probe.NewDefaultResources(kubernetes, args.ServerNamespaces, args.ServerPods, args.ServerPorts, serverProtocols, nil, args.PodCreationTimeoutSeconds, args.BatchJobs, args.ImageRegistry)
+		utils.DoOrDie(err)
+		kubeResources[kubeContext] = resources
+	}
 
-		fmt.Printf("testing %d policies\n\n", len(kubePolicySlices))
+	var policies []*networkingv1.NetworkPolicy
+	var anps []*v1alpha1.AdminNetworkPolicy
+	var banp *v1alpha1.BaselineAdminNetworkPolicy
+	if args.PolicyPath != "" {
+		var err error
+		policies, anps, banp, err = kube.ReadNetworkPoliciesFromPath(args.PolicyPath)
+		utils.DoOrDie(err)
+	}
 
-		contexts := args.Contexts
-		sort.Slice(contexts, func(i, j int) bool {
-			return contexts[i] < contexts[j]
-		})
-		tester := connectivity.NewMultipleContextTester()
-		printer := &connectivity.MultipleContextTestCasePrinter{
-			Noisy:    args.Noisy,
-			Contexts: contexts,
-		}
+	mode, err := generator.ParseProbeMode(args.ProbeMode)
+	utils.DoOrDie(err)
 
-		for i, kubePolicy := range kubePolicySlices {
-			logrus.Infof("starting policy #%d", i)
-			testCase := &connectivity.MultipleContextTestCase{
-				KubePolicies:              kubePolicy,
-				NetpolCreationWaitSeconds: args.NetpolCreationWaitSeconds,
-				Port:                      probePort,
-				Protocol:                  protocol,
-				KubeClients:               kubeClients,
-				KubeResources:             kubeResources,
-				SyntheticResources:        syntheticResources,
-				NamespacesToClean:         namespaces,
-				Policy:                    matcher.BuildNetworkPolicies(kubePolicy),
-			}
-			result := tester.TestNetworkPolicy(testCase)
-			if len(result.Errors) > 0 {
-				panic(result.Errors[0])
+	var probeConfigs []*generator.ProbeConfig
+	if args.ProbeAllAvailable {
+		probeConfigs = append(probeConfigs, generator.NewAllAvailable(mode))
+	} else {
+		for _, port := range args.Ports {
+			for _, protocol := range parseProtocols(args.Protocols) {
+				probeConfigs = append(probeConfigs, generator.NewProbeConfig(intstr.Parse(port), protocol, mode))
 			}
+		}
+	}
 
-			printer.PrintTestCaseResult(result)
-			logrus.Infof("finished policy #%d", i)
+	tester := connectivity.NewMultipleContextTester(kubeClients, kubeResources, &connectivity.MultipleContextTesterConfig{
+		PerturbationWaitSeconds: args.NetpolCreationWaitSeconds,
+		JobTimeoutSeconds:       args.JobTimeoutSeconds,
+		BatchJobs:               args.BatchJobs,
+	})
+	printer := &connectivity.MultipleContextTestCasePrinter{Noisy: args.Noisy}
+
+	for _, probeConfig := range probeConfigs {
+		testCase := &connectivity.MultipleContextTestCase{
+			Description: describeProbeConfig(probeConfig),
+			Policies:    policies,
+			ANPs:        anps,
+			BANP:        banp,
+			ProbeConfig: probeConfig,
 		}
+		printer.PrintTestCaseResult(tester.TestNetworkPolicy(testCase))
+	}
 
-		printer.PrintFinish()
-	*/
+	printer.PrintSummary()
 }
 
-func packIntoSlices(netpols []*networkingv1.NetworkPolicy) [][]*networkingv1.NetworkPolicy {
-	var sos [][]*networkingv1.NetworkPolicy
-	for _, np := range netpols {
-		sos = append(sos, []*networkingv1.NetworkPolicy{np})
+func describeProbeConfig(probeConfig *generator.ProbeConfig) string {
+	if probeConfig.AllAvailable {
+		return fmt.Sprintf("all available ports and protocols (%s)", probeConfig.Mode)
 	}
-	return sos
+	return fmt.Sprintf("port %s on protocol %s (%s)", probeConfig.PortProtocol.Port.String(), probeConfig.PortProtocol.Protocol, probeConfig.Mode)
 }
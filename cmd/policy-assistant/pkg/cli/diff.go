@@ -0,0 +1,140 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"golang.org/x/exp/slices"
+	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/connectivity/probe"
+	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/kube"
+	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/matcher"
+	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/utils"
+)
+
+type DiffArgs struct {
+	BeforePath       string
+	AfterPath        string
+	SimplifyPolicies bool
+	HostsFile        string
+
+	// model: either a synthetic probe file, or the pods of the cluster
+	ProbePath     string
+	AllNamespaces bool
+	Namespaces    []string
+	Context       string
+
+	Output string
+}
+
+func SetupDiffCommand() *cobra.Command {
+	args := &DiffArgs{}
+
+	command := &cobra.Command{
+		Use:   "diff",
+		Short: "semantic diff between two sets of policies",
+		Long:  "Evaluates two sets of policies against the same pods and ports, and lists every flow whose verdict changed, along with the old and new explanations",
+		Args:  cobra.ExactArgs(0),
+		Run: func(cmd *cobra.Command, as []string) {
+			RunDiffCommand(args)
+		},
+	}
+
+	command.Flags().StringVar(&args.BeforePath, "before", "", "may be a file or a directory; policies before the change")
+	utils.DoOrDie(command.MarkFlagRequired("before"))
+	command.Flags().StringVar(&args.AfterPath, "after", "", "may be a file or a directory; policies after the change")
+	utils.DoOrDie(command.MarkFlagRequired("after"))
+	command.Flags().BoolVar(&args.SimplifyPolicies, "simplify-policies", true, "if true, reduce policies to simpler form while preserving semantics (only applies to NPv1 currently)")
+	command.Flags().StringVar(&args.HostsFile, "hosts-file", "", "path to a file in the /etc/hosts format; if set, used to resolve between IPs and domain names for ANP DomainNames rules")
+
+	command.Flags().StringVar(&args.ProbePath, "probe-path", "", "path to json model file for synthetic probe; if not set, the pods of the cluster are used")
+	command.Flags().BoolVarP(&args.AllNamespaces, "all-namespaces", "A", false, "reads pods from all namespaces; same as kubectl's '--all-namespaces'/'-A' flag")
+	command.Flags().StringSliceVarP(&args.Namespaces, "namespace", "n", []string{}, "namespaces to read pods from; similar to kubectl's '--namespace'/'-n' flag, except that multiple namespaces may be passed in")
+	command.Flags().StringVar(&args.Context, "context", "", "selects kube context to read pods from")
+
+	command.Flags().StringVarP(&args.Output, "output", "o", TableOutput, "output format; allowed values are "+strings.Join(AllOutputFormats, ","))
+
+	return command
+}
+
+func RunDiffCommand(args *DiffArgs) {
+	if !slices.Contains(AllOutputFormats, args.Output) {
+		panic(errors.Errorf("unrecognized output format %s, must be one of %s", args.Output, strings.Join(AllOutputFormats, ",")))
+	}
+	if args.ProbePath == "" && !args.AllNamespaces && len(args.Namespaces) == 0 {
+		panic(errors.Errorf("must set --probe-path, or one or more namespaces or all namespaces to read pods from"))
+	}
+
+	var kubePods []v1.Pod
+	var kubeNamespaces []v1.Namespace
+	if args.ProbePath == "" {
+		kubeClient, err := kube.NewKubernetesForContext(args.Context)
+		utils.DoOrDie(err)
+		_, kubePods, kubeNamespaces = ReadPodsAndNamespacesFromKube(kubeClient, args.Namespaces, args.AllNamespaces)
+	}
+
+	before := buildPoliciesFromPath(args.BeforePath, args.SimplifyPolicies, args.HostsFile)
+	after := buildPoliciesFromPath(args.AfterPath, args.SimplifyPolicies, args.HostsFile)
+
+	resources, probes := SyntheticProbeModel(args.ProbePath, kubePods, kubeNamespaces)
+	jobBuilder := &probe.JobBuilder{TimeoutSeconds: 10}
+	var jobs []*probe.Job
+	for _, p := range probes {
+		jobs = append(jobs, jobBuilder.GetJobsForProbeConfig(resources, p.Config).Valid...)
+	}
+
+	PrintVerdictChanges(probe.DiffVerdicts(before, after, jobs), args.Output)
+}
+
+func buildPoliciesFromPath(path string, simplify bool, hostsFile string) *matcher.Policy {
+	netpols, anps, banp, err := kube.ReadNetworkPoliciesFromPath(path)
+	utils.DoOrDie(err)
+	policies := matcher.BuildV1AndV2NetPols(simplify, netpols, anps, banp)
+	if hostsFile != "" {
+		resolver, err := matcher.NewStaticResolverFromHostsFile(hostsFile)
+		utils.DoOrDie(err)
+		policies.Resolver = resolver
+	}
+	return policies
+}
+
+func PrintVerdictChanges(changes []*probe.VerdictChange, output string) {
+	if output != TableOutput {
+		if changes == nil {
+			changes = []*probe.VerdictChange{}
+		}
+		printStructured(output, changes)
+		return
+	}
+
+	if len(changes) == 0 {
+		fmt.Println("no verdicts changed")
+		return
+	}
+
+	describe := func(result *matcher.AllowedResult) string {
+		ingressFlow, egressFlow := result.Ingress.Flow(), result.Egress.Flow()
+		if ingressFlow == "" {
+			ingressFlow = "no policies targeting ingress"
+		}
+		if egressFlow == "" {
+			egressFlow = "no policies targeting egress"
+		}
+		return fmt.Sprintf("%s\ningress: %s\negress: %s", result.Verdict(), ingressFlow, egressFlow)
+	}
+
+	tableString := &strings.Builder{}
+	table := tablewriter.NewWriter(tableString)
+	table.SetAutoWrapText(false)
+	table.SetRowLine(true)
+	table.SetHeader([]string{"From", "To", "Port/Protocol", "Before", "After"})
+	for _, c := range changes {
+		table.Append([]string{c.From, c.To, c.PortProtocol, describe(c.Before), describe(c.After)})
+	}
+	table.Render()
+
+	fmt.Printf("%d flows changed verdict:\n%s\n", len(changes), tableString.String())
+}
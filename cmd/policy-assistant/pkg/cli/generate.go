@@ -1,16 +1,22 @@
 package cli
 
 import (
+	"context"
 	"fmt"
+	"os"
 	"strings"
+	"time"
 
 	"github.com/mattfenwick/collections/pkg/json"
+	"github.com/mattfenwick/collections/pkg/slice"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"golang.org/x/exp/maps"
 	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/connectivity"
 	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/connectivity/probe"
 	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/generator"
 	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/kube"
+	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/telemetry"
 	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/utils"
 )
 
@@ -20,7 +26,9 @@ var (
 		generator.TagUpstreamE2E,
 		generator.TagExample,
 		generator.TagEndPort,
-		generator.TagNamespacesByDefaultLabel}
+		generator.TagNamespacesByDefaultLabel,
+		// most CNIs don't support admin policies yet
+		generator.TagANP}
 )
 
 type GenerateArgs struct {
@@ -30,6 +38,10 @@ type GenerateArgs struct {
 	PerturbationWaitSeconds   int
 	PodCreationTimeoutSeconds int
 	Retries                   int
+	RepeatProbes              bool
+	ConvergenceTimeoutSeconds int
+	ConvergenceResultsFile    string
+	CNI                       string
 	Context                   string
 	ServerPorts               []int
 	ServerProtocols           []string
@@ -41,10 +53,22 @@ type GenerateArgs struct {
 	Exclude                   []string
 	DestinationType           string
 	Mock                      bool
+	MockCNIBugs               []string
 	DryRun                    bool
 	JobTimeoutSeconds         int
 	JunitResultsFile          string
+	HTMLReportFile            string
 	ImageRegistry             string
+	FuzzCases                 int
+	FuzzSeed                  int64
+	FuzzShrink                bool
+	FuzzFailuresFile          string
+	TestCasePath              string
+	ExportTestCasesFile       string
+	RecordDirectory           string
+	ReplayDirectory           string
+	MetricsAddr               string
+	Tracing                   bool
 	//BatchJobs                 bool
 }
 
@@ -57,6 +81,9 @@ func SetupGenerateCommand() *cobra.Command {
 		Long:  "generate network policies, create and probe against kubernetes, and compare to expected results",
 		Args:  cobra.ExactArgs(0),
 		Run: func(cmd *cobra.Command, as []string) {
+			if !cmd.Flags().Changed("fuzz-seed") {
+				args.FuzzSeed = time.Now().UnixNano()
+			}
 			RunGenerateCommand(args)
 		},
 	}
@@ -68,6 +95,10 @@ func SetupGenerateCommand() *cobra.Command {
 
 	//command.Flags().BoolVar(&args.BatchJobs, "batch-jobs", false, "if true, run jobs in batches to avoid saturating the Kube APIServer with too many exec requests")
 	command.Flags().IntVar(&args.Retries, "retries", 1, "number of kube probe retries to allow, if probe fails")
+	command.Flags().BoolVar(&args.RepeatProbes, "repeat-probes", false, "run every kube probe retry, even after a probe passes, so that flaky results can be told apart from consistently wrong ones")
+	command.Flags().IntVar(&args.ConvergenceTimeoutSeconds, "convergence-timeout-seconds", 0, "if positive, measure how long the CNI takes to enforce each step: instead of waiting 'perturbation-wait-seconds' and retrying, probe repeatedly until kube matches the simulation or this many seconds pass")
+	command.Flags().StringVar(&args.ConvergenceResultsFile, "convergence-results-file", "", "output the convergence latency percentiles, by tag, as json to the specified file")
+	command.Flags().StringVar(&args.CNI, "cni", "", "name of the CNI under test, to label the convergence latencies")
 	command.Flags().BoolVar(&args.AllowDNS, "allow-dns", true, "if using egress, allow tcp and udp over port 53 for DNS resolution")
 	command.Flags().BoolVar(&args.Noisy, "noisy", false, "if true, print all results")
 	command.Flags().BoolVar(&args.IgnoreLoopback, "ignore-loopback", false, "if true, ignore loopback for truthtable correctness verification")
@@ -80,14 +111,29 @@ func SetupGenerateCommand() *cobra.Command {
 	command.Flags().IntVar(&args.JobTimeoutSeconds, "job-timeout-seconds", 10, "number of seconds to pass on to 'agnhost connect --timeout=%ds' flag")
 
 	command.Flags().StringSliceVar(&args.Include, "include", []string{}, "include tests with any of these tags; if empty, all tests will be included.  Valid tags:\n"+strings.Join(generator.TagSlice, "\n"))
-	command.Flags().StringSliceVar(&args.Exclude, "exclude", DefaultExcludeTags, "exclude tests with any of these tags.  See 'include' field for valid tags")
+	command.Flags().StringSliceVar(&args.Exclude, "exclude", DefaultExcludeTags, "exclude tests with any of these tags, unless the tag (or one of its subordinate tags) is also included.  See 'include' field for valid tags")
 
-	command.Flags().BoolVar(&args.Mock, "mock", false, "if true, use a mock kube runner (i.e. don't actually run tests against kubernetes; instead, product fake results")
+	command.Flags().BoolVar(&args.Mock, "mock", false, "if true, use a mock kube runner (i.e. don't actually run tests against kubernetes; instead, enforce policies the same way as the simulator)")
+	command.Flags().StringSliceVar(&args.MockCNIBugs, "mock-cni-bugs", []string{}, fmt.Sprintf("with 'mock', CNI bugs to inject into policy enforcement; valid values: %+v", slice.Sort(maps.Keys(probe.MockCNIBugs))))
 	command.Flags().BoolVar(&args.DryRun, "dry-run", false, "if true, don't actually do anything: just print out what would be done")
+	command.Flags().StringVar(&args.TestCasePath, "test-case-path", "", "if specified, run the test cases from this YAML/JSON file, or from the .yaml, .yml and .json files in this directory, instead of the built-in test cases")
+	command.Flags().StringVar(&args.ExportTestCasesFile, "export-test-cases-file", "", "write the test cases to run to the specified file, as YAML (or JSON if it ends with .json); use with 'dry-run' to export the built-in test cases")
+
+	command.Flags().StringVar(&args.RecordDirectory, "record", "", "save every kube probe result, along with its test case, step and the policies in force, to the specified directory")
+	command.Flags().StringVar(&args.ReplayDirectory, "replay", "", "replay the kube probe results saved with 'record' from the specified directory, instead of running against kubernetes; the same test cases must be selected as when recording")
+
+	command.Flags().StringVar(&args.MetricsAddr, "metrics-addr", "", "if specified, serve prometheus metrics of probes, steps and test cases on this address (i.e. ':9090') at /metrics")
+	command.Flags().BoolVar(&args.Tracing, "tracing", false, "if true, export an opentelemetry span per test case, step and kube probe, over OTLP/HTTP to the endpoint configured by the OTEL_EXPORTER_OTLP_ENDPOINT environment variable")
 
 	command.Flags().StringVar(&args.JunitResultsFile, "junit-results-file", "", "output junit results to the specified file")
+	command.Flags().StringVar(&args.HTMLReportFile, "html-report-file", "", "output a self-contained html report, with the truth tables and policies of every step, to the specified file")
 	command.Flags().StringVar(&args.ImageRegistry, "image-registry", "registry.k8s.io", "Image registry for agnhost")
 
+	command.Flags().IntVar(&args.FuzzCases, "fuzz-cases", 0, "if positive, run this many randomly generated NPv1/ANP/BANP test cases instead of the curated test cases selected by 'include' and 'exclude'")
+	command.Flags().Int64Var(&args.FuzzSeed, "fuzz-seed", 0, "seed of the first random test case; case i uses seed 'fuzz-seed + i'.  If not specified, a seed is picked from the current time.  Use with '--fuzz-cases 1' to replay a failing case")
+	command.Flags().BoolVar(&args.FuzzShrink, "fuzz-shrink", true, "if true, shrink each failing random test case to a minimal failing policy set, by re-running smaller variants of it")
+	command.Flags().StringVar(&args.FuzzFailuresFile, "fuzz-failures-file", "", "output the seeds and minimal test cases of failing random test cases to the specified file")
+
 	return command
 }
 
@@ -98,11 +144,22 @@ func RunGenerateCommand(args *GenerateArgs) {
 
 	utils.DoOrDie(generator.ValidateTags(append(args.Include, args.Exclude...)))
 
+	telemetry.ServeMetrics(args.MetricsAddr)
+	if args.Tracing {
+		shutdownTracing, err := telemetry.SetUpTracing(context.Background())
+		utils.DoOrDie(err)
+		defer shutdownTracing()
+	}
+
 	externalIPs := []string{} // "http://www.google.com"} // TODO make these be IPs?  or not?
 
 	var kubernetes kube.IKubernetes
-	if args.Mock {
+	if args.ReplayDirectory != "" {
 		kubernetes = kube.NewMockKubernetes(1.0)
+	} else if args.Mock {
+		mockKubernetes, err := probe.NewMockCNIKubernetes(args.MockCNIBugs)
+		utils.DoOrDie(err)
+		kubernetes = mockKubernetes
 	} else {
 		kubeClient, err := kube.NewKubernetesForContext(args.Context)
 		utils.DoOrDie(err)
@@ -115,32 +172,48 @@ func RunGenerateCommand(args *GenerateArgs) {
 	serverProtocols := parseProtocols(args.ServerProtocols)
 
 	batchJobs := false // args.BatchJobs
-	resources, err := probe.NewDefaultResources(kubernetes, args.ServerNamespaces, args.ServerPods, args.ServerPorts, serverProtocols, externalIPs, args.PodCreationTimeoutSeconds, batchJobs, args.ImageRegistry)
-	utils.DoOrDie(err)
+	resources := setupResources(kubernetes, args.RecordDirectory, args.ReplayDirectory, func() (*probe.Resources, error) {
+		return probe.NewDefaultResources(kubernetes, args.ServerNamespaces, args.ServerPods, args.ServerPorts, serverProtocols, externalIPs, args.PodCreationTimeoutSeconds, batchJobs, args.ImageRegistry)
+	})
 
 	interpreterConfig := &connectivity.InterpreterConfig{
 		ResetClusterBeforeTestCase:       true,
 		KubeProbeRetries:                 args.Retries,
-		PerturbationWaitSeconds:          args.PerturbationWaitSeconds,
+		RepeatKubeProbes:                 args.RepeatProbes,
+		ConvergenceTimeoutSeconds:        args.ConvergenceTimeoutSeconds,
+		PerturbationWaitSeconds:          perturbationWaitSeconds(args.PerturbationWaitSeconds, args.ReplayDirectory),
 		VerifyClusterStateBeforeTestCase: true,
 		BatchJobs:                        batchJobs,
 		IgnoreLoopback:                   args.IgnoreLoopback,
 		JobTimeoutSeconds:                args.JobTimeoutSeconds,
 		FailFast:                         args.FailFast,
+		RecordDirectory:                  args.RecordDirectory,
+		ReplayDirectory:                  args.ReplayDirectory,
 	}
 	interpreter := connectivity.NewInterpreter(kubernetes, resources, interpreterConfig)
 	printer := &connectivity.Printer{
-		Noisy:            args.Noisy,
-		IgnoreLoopback:   args.IgnoreLoopback,
-		JunitResultsFile: args.JunitResultsFile,
+		Noisy:                  args.Noisy,
+		IgnoreLoopback:         args.IgnoreLoopback,
+		JunitResultsFile:       args.JunitResultsFile,
+		HTMLReportFile:         args.HTMLReportFile,
+		CNI:                    args.CNI,
+		ConvergenceResultsFile: args.ConvergenceResultsFile,
 	}
 
 	zcPod, err := resources.GetPod("z", "c")
 	utils.DoOrDie(err)
 
-	testCaseGenerator := generator.NewTestCaseGenerator(args.AllowDNS, zcPod.IP, args.ServerNamespaces, args.Include, args.Exclude)
-
-	testCases := testCaseGenerator.GenerateTestCases()
+	var testCases []*generator.TestCase
+	if args.FuzzCases > 0 {
+		fmt.Printf("fuzzing %d cases starting from seed %d\n", args.FuzzCases, args.FuzzSeed)
+		testCases = generator.NewFuzzer(args.AllowDNS, args.ServerNamespaces, args.ServerPods).FuzzTestCases(args.FuzzSeed, args.FuzzCases)
+	} else if args.TestCasePath != "" {
+		testCases, err = generator.ReadTestCasesFromPath(args.TestCasePath)
+		utils.DoOrDie(err)
+	} else {
+		testCaseGenerator := generator.NewTestCaseGenerator(args.AllowDNS, zcPod.IP, args.ServerNamespaces, args.Include, generator.RemoveIncludedTags(args.Exclude, args.Include))
+		testCases = testCaseGenerator.GenerateTestCases()
+	}
 	fmt.Printf("test cases to run by tag:\n")
 	for tag, count := range generator.CountTestCasesByTag(testCases) {
 		fmt.Printf("- %s: %d\n", tag, count)
@@ -150,6 +223,11 @@ func RunGenerateCommand(args *GenerateArgs) {
 		fmt.Printf("test #%d: %s\n - tags: %+v\n", i+1, testCase.Description, strings.Join(testCase.Tags.Keys(), ", "))
 	}
 
+	if args.ExportTestCasesFile != "" {
+		utils.DoOrDie(generator.WriteTestCasesToFile(args.ExportTestCasesFile, testCases))
+		fmt.Printf("wrote %d test cases to %s\n", len(testCases), args.ExportTestCasesFile)
+	}
+
 	if args.DryRun {
 		return
 	}
@@ -164,6 +242,7 @@ func RunGenerateCommand(args *GenerateArgs) {
 		}
 	}
 
+	var fuzzFailures []*FuzzFailure
 	for i, testCase := range testCases {
 		fmt.Printf("starting test case #%d\n", i+1)
 
@@ -173,7 +252,12 @@ func RunGenerateCommand(args *GenerateArgs) {
 		printer.PrintTestCaseResult(result)
 		fmt.Printf("finished policy #%d\n", i+1)
 
-		if args.FailFast && !result.Passed(interpreter.Config.IgnoreLoopback) {
+		passed := result.Passed(interpreter.Config.IgnoreLoopback)
+		if args.FuzzCases > 0 && !passed {
+			fuzzFailures = append(fuzzFailures, shrinkFuzzFailure(args, interpreter, args.FuzzSeed+int64(i), result))
+		}
+
+		if args.FailFast && !passed {
 			logrus.Warn("failing fast due to failure")
 			break
 		}
@@ -181,6 +265,10 @@ func RunGenerateCommand(args *GenerateArgs) {
 
 	printer.PrintSummary()
 
+	if args.FuzzCases > 0 {
+		printFuzzFailures(args, fuzzFailures)
+	}
+
 	if args.CleanupNamespaces {
 		for _, ns := range args.ServerNamespaces {
 			logrus.Infof("cleaning up namespace %s", ns)
@@ -191,3 +279,47 @@ func RunGenerateCommand(args *GenerateArgs) {
 		}
 	}
 }
+
+// FuzzFailure records a failing random test case: the seed replays it, and Minimal is the smallest
+// failing variant found by shrinking it
+type FuzzFailure struct {
+	Seed    int64
+	Minimal *generator.TestCase
+}
+
+func shrinkFuzzFailure(args *GenerateArgs, interpreter *connectivity.Interpreter, seed int64, result *connectivity.Result) *FuzzFailure {
+	logrus.Warnf("random test case with seed %d failed", seed)
+	if !args.FuzzShrink {
+		return &FuzzFailure{Seed: seed, Minimal: result.TestCase}
+	}
+
+	minimalResult := result
+	minimal := generator.Shrink(result.TestCase, func(testCase *generator.TestCase) bool {
+		candidateResult := interpreter.ExecuteTestCase(testCase)
+		if candidateResult.Err != nil {
+			// a candidate which can't be run doesn't reproduce the failure: keep shrinking with the others
+			logrus.Warnf("unable to run shrink candidate for seed %d: %+v", seed, candidateResult.Err)
+			return false
+		}
+		if candidateResult.Passed(interpreter.Config.IgnoreLoopback) {
+			return false
+		}
+		minimalResult = candidateResult
+		return true
+	})
+
+	fmt.Printf("minimal failing test case for seed %d:\n", seed)
+	(&connectivity.Printer{Noisy: args.Noisy, IgnoreLoopback: args.IgnoreLoopback}).PrintTestCaseResult(minimalResult)
+	return &FuzzFailure{Seed: seed, Minimal: minimal}
+}
+
+func printFuzzFailures(args *GenerateArgs, failures []*FuzzFailure) {
+	fmt.Printf("%d of %d random test cases failed\n", len(failures), args.FuzzCases)
+	for _, failure := range failures {
+		fmt.Printf("- seed %d: replay with '--fuzz-cases 1 --fuzz-seed %d'\n", failure.Seed, failure.Seed)
+	}
+
+	if args.FuzzFailuresFile != "" {
+		utils.DoOrDie(os.WriteFile(args.FuzzFailuresFile, []byte(json.MustMarshalToString(failures)), 0644))
+	}
+}
@@ -36,6 +36,9 @@ type ProbeArgs struct {
 	ServerNamespaces []string
 	ServerPods       []string
 	ImageRegistry    string
+
+	RecordDirectory string
+	ReplayDirectory string
 }
 
 func SetupProbeCommand() *cobra.Command {
@@ -69,6 +72,8 @@ func SetupProbeCommand() *cobra.Command {
 	command.Flags().IntVar(&args.PodCreationTimeoutSeconds, "pod-creation-timeout-seconds", 60, "number of seconds to wait for pods to create, be running and have IP addresses")
 	command.Flags().StringVar(&args.PolicyPath, "policy-path", "", "path to yaml network policy to create in kube; if empty, will not create any policies")
 	command.Flags().StringVar(&args.ImageRegistry, "image-registry", "registry.k8s.io", "Image registry for agnhost")
+	command.Flags().StringVar(&args.RecordDirectory, "record", "", "save every kube probe result, along with the policies in force, to the specified directory")
+	command.Flags().StringVar(&args.ReplayDirectory, "replay", "", "replay the kube probe results saved with 'record' from the specified directory, instead of running against kubernetes")
 
 	return command
 }
@@ -79,23 +84,32 @@ func RunProbeCommand(args *ProbeArgs) {
 		panic(errors.Errorf("found 0 namespaces or pods, must have at least 1 of each"))
 	}
 
-	kubernetes, err := kube.NewKubernetesForContext(args.KubeContext)
-	utils.DoOrDie(err)
+	var kubernetes kube.IKubernetes
+	if args.ReplayDirectory != "" {
+		kubernetes = kube.NewMockKubernetes(1.0)
+	} else {
+		kubeClient, err := kube.NewKubernetesForContext(args.KubeContext)
+		utils.DoOrDie(err)
+		kubernetes = kubeClient
+	}
 
 	protocols := parseProtocols(args.Protocols)
 	serverProtocols := parseProtocols(args.ServerProtocols)
 
-	resources, err := probe.NewDefaultResources(kubernetes, args.ServerNamespaces, args.ServerPods, args.ServerPorts, serverProtocols, externalIPs, args.PodCreationTimeoutSeconds, false, args.ImageRegistry)
-	utils.DoOrDie(err)
+	resources := setupResources(kubernetes, args.RecordDirectory, args.ReplayDirectory, func() (*probe.Resources, error) {
+		return probe.NewDefaultResources(kubernetes, args.ServerNamespaces, args.ServerPods, args.ServerPorts, serverProtocols, externalIPs, args.PodCreationTimeoutSeconds, false, args.ImageRegistry)
+	})
 
 	interpreterConfig := &connectivity.InterpreterConfig{
 		ResetClusterBeforeTestCase:       false,
 		KubeProbeRetries:                 0,
-		PerturbationWaitSeconds:          args.PerturbationWaitSeconds,
+		PerturbationWaitSeconds:          perturbationWaitSeconds(args.PerturbationWaitSeconds, args.ReplayDirectory),
 		VerifyClusterStateBeforeTestCase: false,
 		BatchJobs:                        false,
 		IgnoreLoopback:                   args.IgnoreLoopback,
 		JobTimeoutSeconds:                args.JobTimeoutSeconds,
+		RecordDirectory:                  args.RecordDirectory,
+		ReplayDirectory:                  args.ReplayDirectory,
 	}
 	interpreter := connectivity.NewInterpreter(kubernetes, resources, interpreterConfig)
 
@@ -130,6 +144,33 @@ func RunProbeCommand(args *ProbeArgs) {
 	}
 }
 
+// setupResources reads the resources from the recording when replaying, instead of creating them in kubernetes,
+// and saves them when recording
+func setupResources(kubernetes kube.IKubernetes, recordDirectory string, replayDirectory string, newResources func() (*probe.Resources, error)) *probe.Resources {
+	if recordDirectory != "" && replayDirectory != "" {
+		utils.DoOrDie(errors.Errorf("unable to record and replay at the same time"))
+	}
+	if replayDirectory != "" {
+		resources, err := probe.NewReplayResources(kubernetes, replayDirectory)
+		utils.DoOrDie(err)
+		return resources
+	}
+	resources, err := newResources()
+	utils.DoOrDie(err)
+	if recordDirectory != "" {
+		utils.DoOrDie(probe.RecordResources(recordDirectory, resources))
+	}
+	return resources
+}
+
+// perturbationWaitSeconds skips waiting for the CNI when replaying, since there's no cluster to wait for
+func perturbationWaitSeconds(seconds int, replayDirectory string) int {
+	if replayDirectory != "" {
+		return 0
+	}
+	return seconds
+}
+
 func parseProtocols(strs []string) []v1.Protocol {
 	var protocols []v1.Protocol
 	for _, protocol := range strs {
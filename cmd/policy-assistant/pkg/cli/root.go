@@ -34,7 +34,8 @@ func SetupRootCommand() *cobra.Command {
 	command.PersistentFlags().StringVarP(&flags.Verbosity, "verbosity", "v", "info", "log level; one of [info, debug, trace, warn, error, fatal, panic]")
 
 	command.AddCommand(SetupAnalyzeCommand())
-	//command.AddCommand(SetupCompareCommand())
+	command.AddCommand(SetupCompareCommand())
+	command.AddCommand(SetupDiffCommand())
 	command.AddCommand(SetupGenerateCommand())
 	command.AddCommand(SetupProbeCommand())
 	command.AddCommand(SetupVersionCommand())
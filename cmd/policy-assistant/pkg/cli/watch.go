@@ -0,0 +1,293 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	networkingv1 "k8s.io/api/networking/v1"
+	"sigs.k8s.io/network-policy-api/apis/v1alpha1"
+	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/connectivity/probe"
+	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/kube"
+	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/matcher"
+	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/utils"
+)
+
+// WatchArgs configures RunWatch.  Policies, ANPs and BANP are enforced along with the policies of the cluster.
+type WatchArgs struct {
+	Namespaces       []string
+	IncludeANPs      bool
+	IncludeBANPs     bool
+	SimplifyPolicies bool
+	HostsFile        string
+	Debounce         time.Duration
+
+	Policies []*networkingv1.NetworkPolicy
+	ANPs     []*v1alpha1.AdminNetworkPolicy
+	BANP     *v1alpha1.BaselineAdminNetworkPolicy
+
+	Output string
+}
+
+// WatchUpdate is printed whenever changes to the cluster change the verdict of some flows
+type WatchUpdate struct {
+	Time    time.Time
+	Events  []*kube.WatchEvent
+	Changes []*probe.VerdictChange
+}
+
+// watchQueue collects events from informer goroutines, so that bursts of them are handled together
+type watchQueue struct {
+	lock   sync.Mutex
+	events []*kube.WatchEvent
+	ready  chan struct{}
+}
+
+func (q *watchQueue) push(event *kube.WatchEvent) {
+	q.lock.Lock()
+	q.events = append(q.events, event)
+	q.lock.Unlock()
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+}
+
+func (q *watchQueue) pop() []*kube.WatchEvent {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	events := q.events
+	q.events = nil
+	return events
+}
+
+// RunWatch keeps informer caches of the policies, pods and namespaces of the cluster, and prints the flows
+// whose verdict changes whenever a policy or a label changes, until interrupted.  Only the flows which a change
+// can affect are re-evaluated: those from or to relabeled pods and namespaces, and those to or from the pods
+// selected by the targets of changed policies.
+func RunWatch(kubeClient *kube.Kubernetes, args *WatchArgs) {
+	informers, err := kube.NewInformers(kubeClient, args.Namespaces, args.IncludeANPs, args.IncludeBANPs, 0)
+	utils.DoOrDie(err)
+	queue := &watchQueue{ready: make(chan struct{}, 1)}
+	informers.AddEventHandler(queue.push)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	utils.DoOrDie(informers.Start(ctx.Done()))
+	// the caches now hold everything which was initially listed, so the baseline covers those events
+	queue.pop()
+
+	tracker := probe.NewVerdictTracker()
+	snapshot, err := informers.Snapshot()
+	utils.DoOrDie(err)
+	policies, err := newWatchPolicies(args, snapshot)
+	utils.DoOrDie(err)
+	update := tracker.Update(policies.set.Policy, SyntheticProbeResourcesFromKube(snapshot.Pods, snapshot.Namespaces, logrus.Debugf), nil)
+	logrus.Infof("watching %d flows between %d pods; interrupt to stop", update.Evaluated, len(snapshot.Pods))
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-queue.ready:
+		}
+		// wait for the rest of a burst -- i.e. a policy applied along with pod label changes
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(args.Debounce):
+		}
+
+		events := queue.pop()
+		if len(events) == 0 {
+			continue
+		}
+		snapshot, err = informers.Snapshot()
+		if err != nil {
+			logrus.Errorf("unable to read informer caches: %+v", err)
+			continue
+		}
+
+		changed := policies.update(events, snapshot)
+		relabeled := staleJobs(events)
+		isStale := func(job *probe.Job) bool {
+			return relabeled(job) || changed.Affects(job.Traffic())
+		}
+
+		update = tracker.Update(policies.set.Policy, SyntheticProbeResourcesFromKube(snapshot.Pods, snapshot.Namespaces, logrus.Debugf), isStale)
+		logrus.Infof("%s: re-evaluated %d flows, %d new, %d removed, %d changed verdict",
+			describeWatchEvents(events), update.Evaluated, update.Added, update.Removed, len(update.Changes))
+		if len(update.Changes) > 0 {
+			printWatchUpdate(&WatchUpdate{Time: time.Now(), Events: events, Changes: update.Changes}, args.Output)
+		}
+	}
+}
+
+// watchPolicies are the policies of the cluster along with the extra policies.  They're updated one policy at a
+// time as the cluster changes.
+type watchPolicies struct {
+	args *WatchArgs
+	set  *matcher.PolicySet
+}
+
+func newWatchPolicies(args *WatchArgs, snapshot *kube.ClusterSnapshot) (*watchPolicies, error) {
+	policies := &watchPolicies{args: args, set: matcher.NewPolicySet(args.SimplifyPolicies)}
+	err := recoverBuildPanic(func() {
+		for i, netpol := range args.Policies {
+			policies.set.SetNetworkPolicy(fmt.Sprintf("extra/%s/%d", kube.WatchEventNetworkPolicy, i), netpol)
+		}
+		for i, anp := range args.ANPs {
+			policies.set.SetANP(fmt.Sprintf("extra/%s/%d", kube.WatchEventAdminNetworkPolicy, i), anp)
+		}
+		if args.BANP != nil {
+			policies.set.SetBANP(fmt.Sprintf("extra/%s", kube.WatchEventBaselineAdminNetworkPolicy), args.BANP)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	if args.HostsFile != "" {
+		resolver, err := matcher.NewStaticResolverFromHostsFile(args.HostsFile)
+		if err != nil {
+			return nil, err
+		}
+		policies.set.Policy.Resolver = resolver
+	}
+
+	var events []*kube.WatchEvent
+	for _, netpol := range snapshot.Policies {
+		events = append(events, &kube.WatchEvent{Kind: kube.WatchEventNetworkPolicy, Namespace: netpol.Namespace, Name: netpol.Name})
+	}
+	for _, anp := range snapshot.ANPs {
+		events = append(events, &kube.WatchEvent{Kind: kube.WatchEventAdminNetworkPolicy, Name: anp.Name})
+	}
+	if snapshot.BANP != nil {
+		events = append(events, &kube.WatchEvent{Kind: kube.WatchEventBaselineAdminNetworkPolicy, Name: snapshot.BANP.Name})
+	}
+	policies.update(events, snapshot)
+	return policies, nil
+}
+
+// update sets or removes the policies of the events, as they are in the snapshot, and returns the subjects of the
+// targets it rebuilt.  Building panics on some invalid policies, which mustn't stop the watch: those keep their
+// previous version.  Policies which fail are retried once the others are set, in case they conflicted with the
+// previous version of another, i.e. when ANPs swap priorities.
+func (p *watchPolicies) update(events []*kube.WatchEvent, snapshot *kube.ClusterSnapshot) *matcher.ChangedSubjects {
+	changed := &matcher.ChangedSubjects{}
+	seen := map[string]bool{}
+	var failed []*kube.WatchEvent
+	for _, event := range events {
+		key := watchPolicyKey(event)
+		if !event.IsPolicy() || seen[key] {
+			continue
+		}
+		seen[key] = true
+		if err := p.apply(event, snapshot, changed); err != nil {
+			failed = append(failed, event)
+		}
+	}
+	for _, event := range failed {
+		if err := p.apply(event, snapshot, changed); err != nil {
+			logrus.Errorf("unable to build %s, ignoring changes to it: %+v", watchPolicyKey(event), err)
+		}
+	}
+	return changed
+}
+
+func watchPolicyKey(event *kube.WatchEvent) string {
+	return fmt.Sprintf("%s/%s/%s", event.Kind, event.Namespace, event.Name)
+}
+
+// apply sets or removes the policy of an event, adding the subjects of the targets it rebuilt to changed
+func (p *watchPolicies) apply(event *kube.WatchEvent, snapshot *kube.ClusterSnapshot, changed *matcher.ChangedSubjects) error {
+	key := watchPolicyKey(event)
+	return recoverBuildPanic(func() {
+		switch event.Kind {
+		case kube.WatchEventNetworkPolicy:
+			changed.Append(p.set.SetNetworkPolicy(key, findWatchedPolicy(snapshot.Policies, event)))
+		case kube.WatchEventAdminNetworkPolicy:
+			changed.Append(p.set.SetANP(key, findWatchedPolicy(snapshot.ANPs, event)))
+		case kube.WatchEventBaselineAdminNetworkPolicy:
+			// the extra BANP replaces that of the cluster
+			if p.args.BANP == nil {
+				changed.Append(p.set.SetBANP(key, findWatchedPolicy([]*v1alpha1.BaselineAdminNetworkPolicy{snapshot.BANP}, event)))
+			}
+		}
+	})
+}
+
+// findWatchedPolicy finds the policy of an event, or returns nil if it no longer exists
+func findWatchedPolicy[P interface {
+	comparable
+	GetNamespace() string
+	GetName() string
+}](policies []P, event *kube.WatchEvent) P {
+	var none P
+	for _, policy := range policies {
+		if policy != none && policy.GetNamespace() == event.Namespace && policy.GetName() == event.Name {
+			return policy
+		}
+	}
+	return none
+}
+
+func recoverBuildPanic(build func()) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.Errorf("%+v", r)
+		}
+	}()
+	build()
+	return nil
+}
+
+// staleJobs finds the flows from or to the pods and namespaces whose labels changed
+func staleJobs(events []*kube.WatchEvent) func(job *probe.Job) bool {
+	pods, namespaces := map[string]bool{}, map[string]bool{}
+	for _, event := range events {
+		switch event.Kind {
+		case kube.WatchEventPod:
+			pods[probe.NewPodString(event.Namespace, event.Name).String()] = true
+		case kube.WatchEventNamespace:
+			namespaces[event.Name] = true
+		}
+	}
+	return func(job *probe.Job) bool {
+		return pods[job.FromKey] || pods[job.ToKey] || namespaces[job.FromNamespace] || namespaces[job.ToNamespace]
+	}
+}
+
+func describeWatchEvents(events []*kube.WatchEvent) string {
+	var descriptions []string
+	for _, event := range events {
+		name := event.Name
+		if event.Namespace != "" {
+			name = event.Namespace + "/" + name
+		}
+		action := "changed"
+		if event.Deleted {
+			action = "deleted"
+		}
+		descriptions = append(descriptions, fmt.Sprintf("%s %s %s", event.Kind, name, action))
+	}
+	if len(descriptions) > 5 {
+		descriptions = append(descriptions[:5], fmt.Sprintf("and %d more", len(descriptions)-5))
+	}
+	return strings.Join(descriptions, ", ")
+}
+
+func printWatchUpdate(update *WatchUpdate, output string) {
+	if output != TableOutput {
+		printStructured(output, update)
+		return
+	}
+	fmt.Printf("%s: %s\n", update.Time.Format(time.RFC3339), describeWatchEvents(update.Events))
+	PrintVerdictChanges(update.Changes, output)
+}
@@ -133,11 +133,32 @@ func (c *ComparisonTable) RenderSuccessTable() string {
 	})
 }
 
+// Classify compares a from/to pair over every try of a step: it's the same if kube agreed with the simulation
+// on every try, different if kube disagreed on every try, and flaky otherwise.  Flaky results are usually caused by
+// a CNI which is slow to converge, rather than by a policy bug.
+func Classify(tries []*ComparisonTable, from string, to string) Comparison {
+	same, different := 0, 0
+	for _, try := range tries {
+		if try.Get(from, to).IsSuccess() {
+			same++
+		} else {
+			different++
+		}
+	}
+	if different == 0 {
+		return SameComparison
+	} else if same == 0 {
+		return DifferentComparison
+	}
+	return FlakyComparison
+}
+
 type Comparison string
 
 const (
 	SameComparison      Comparison = "same"
 	DifferentComparison Comparison = "different"
+	FlakyComparison     Comparison = "flaky"
 	IgnoredComparison   Comparison = "ignored"
 )
 
@@ -147,6 +168,8 @@ func (c Comparison) ShortString() string {
 		return "."
 	case DifferentComparison:
 		return "X"
+	case FlakyComparison:
+		return "~"
 	case IgnoredComparison:
 		return "?"
 	default:
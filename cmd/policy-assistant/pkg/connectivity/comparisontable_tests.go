@@ -0,0 +1,81 @@
+package connectivity
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/connectivity/probe"
+)
+
+func RunComparisonTableTests() {
+	Describe("Classify", func() {
+		pods := []string{"x/a", "x/b"}
+
+		It("classifies pairs as same, different or flaky over every try", func() {
+			step := NewStepResult(newTestTable(pods, "x/a->x/b", "x/b->x/a"), nil, nil, nil, nil)
+			step.RepeatedKubeProbes = true
+			step.AddKubeProbe(newTestTable(pods))
+			step.AddKubeProbe(newTestTable(pods, "x/b->x/a"))
+
+			Expect(step.Classify("x/a", "x/a", false)).To(Equal(SameComparison))
+			Expect(step.Classify("x/a", "x/b", false)).To(Equal(DifferentComparison))
+			Expect(step.Classify("x/b", "x/a", false)).To(Equal(FlakyComparison))
+			Expect(step.Classify("x/a", "x/a", true)).To(Equal(IgnoredComparison))
+			Expect(step.ValueCounts(false)).To(Equal(map[Comparison]int{SameComparison: 2, DifferentComparison: 1, FlakyComparison: 1}))
+			Expect(step.Passed(false)).To(BeFalse())
+			Expect(step.Flaky(false)).To(BeTrue())
+		})
+
+		It("passes steps whose only discrepancies are flaky", func() {
+			step := NewStepResult(newTestTable(pods, "x/a->x/b"), nil, nil, nil, nil)
+			step.RepeatedKubeProbes = true
+			step.AddKubeProbe(newTestTable(pods))
+			step.AddKubeProbe(newTestTable(pods, "x/a->x/b"))
+
+			Expect(step.ValueCounts(false)).To(Equal(map[Comparison]int{SameComparison: 3, FlakyComparison: 1}))
+			Expect(step.Passed(false)).To(BeTrue())
+
+			result := &Result{Steps: []*StepResult{step}}
+			Expect(result.Passed(false)).To(BeTrue())
+			Expect(result.Flaky(false)).To(BeTrue())
+		})
+
+		It("judges steps by their last try unless the kube probes were repeated", func() {
+			step := NewStepResult(newTestTable(pods, "x/a->x/b"), nil, nil, nil, nil)
+			step.AddKubeProbe(newTestTable(pods))
+			step.AddKubeProbe(newTestTable(pods, "x/a->x/b"))
+
+			Expect(step.Classify("x/a", "x/b", false)).To(Equal(SameComparison))
+			Expect(step.ValueCounts(false)).To(Equal(map[Comparison]int{SameComparison: 4}))
+			Expect(step.Passed(false)).To(BeTrue())
+			Expect(step.Flaky(false)).To(BeFalse())
+
+			step.AddKubeProbe(newTestTable(pods))
+			Expect(step.Classify("x/a", "x/b", false)).To(Equal(DifferentComparison))
+			Expect(step.Passed(false)).To(BeFalse())
+			Expect(step.Flaky(false)).To(BeFalse())
+		})
+	})
+}
+
+// newTestTable builds a table of TCP/80 probes between the pods, allowing all but the blocked "from->to" pairs
+func newTestTable(pods []string, blocked ...string) *probe.Table {
+	table := probe.NewTable(pods)
+	for _, fr := range pods {
+		for _, to := range pods {
+			c := probe.ConnectivityAllowed
+			for _, b := range blocked {
+				if b == fr+"->"+to {
+					c = probe.ConnectivityBlocked
+				}
+			}
+			Expect(table.Get(fr, to).AddJobResult(&probe.JobResult{
+				Job:      &probe.Job{FromKey: fr, ToKey: to, ResolvedPort: 80, Protocol: v1.ProtocolTCP},
+				Ingress:  &c,
+				Egress:   &c,
+				Combined: c,
+			})).To(Succeed())
+		}
+	}
+	return table
+}
@@ -0,0 +1,157 @@
+package connectivity
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mattfenwick/collections/pkg/slice"
+	"github.com/olekukonko/tablewriter"
+	"github.com/pkg/errors"
+	"golang.org/x/exp/maps"
+)
+
+const convergenceAllTag = "all"
+
+// Convergence is how long kube took to enforce the policies of a step, after its actions were performed
+type Convergence struct {
+	Converged bool
+	// Latency is the time from the end of the step's actions to the end of the first probe which matched
+	// the simulation -- or to the end of the last probe, if kube didn't converge
+	Latency time.Duration
+	Tries   int
+}
+
+// ConvergenceRow aggregates the convergence latencies of the steps of every test case with a tag
+type ConvergenceRow struct {
+	Tag       string
+	IsPrimary bool
+	Converged int
+	TimedOut  int
+	P50       time.Duration
+	P90       time.Duration
+	P99       time.Duration
+	Max       time.Duration
+}
+
+// ConvergenceSummary is the convergence latencies of a run, against a single CNI
+type ConvergenceSummary struct {
+	CNI  string
+	Rows []*ConvergenceRow
+}
+
+// NewConvergenceSummaryFromResults aggregates the convergence of every step by tag.  Returns nil if
+// convergence wasn't measured.
+func NewConvergenceSummaryFromResults(cni string, results []*Result) *ConvergenceSummary {
+	latencies := map[string][]time.Duration{}
+	timeouts := map[string]int{}
+	primaries := map[string]map[string]bool{}
+	measured := false
+	for _, result := range results {
+		tags := []string{convergenceAllTag}
+		for primary, subs := range result.TestCase.Tags.GroupTags() {
+			tags = append(tags, primary)
+			tags = append(tags, subs...)
+			if _, ok := primaries[primary]; !ok {
+				primaries[primary] = map[string]bool{}
+			}
+			for _, sub := range subs {
+				primaries[primary][sub] = true
+			}
+		}
+		for _, step := range result.Steps {
+			if step.Convergence == nil {
+				continue
+			}
+			measured = true
+			for _, tag := range tags {
+				if step.Convergence.Converged {
+					latencies[tag] = append(latencies[tag], step.Convergence.Latency)
+				} else {
+					timeouts[tag]++
+				}
+			}
+		}
+	}
+	if !measured {
+		return nil
+	}
+
+	newRow := func(tag string, isPrimary bool) *ConvergenceRow {
+		sorted := append([]time.Duration{}, latencies[tag]...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		return &ConvergenceRow{
+			Tag:       tag,
+			IsPrimary: isPrimary,
+			Converged: len(sorted),
+			TimedOut:  timeouts[tag],
+			P50:       latencyPercentile(sorted, 50),
+			P90:       latencyPercentile(sorted, 90),
+			P99:       latencyPercentile(sorted, 99),
+			Max:       latencyPercentile(sorted, 100),
+		}
+	}
+	summary := &ConvergenceSummary{CNI: cni, Rows: []*ConvergenceRow{newRow(convergenceAllTag, true)}}
+	for _, primary := range slice.Sort(maps.Keys(primaries)) {
+		summary.Rows = append(summary.Rows, newRow(primary, true))
+		for _, sub := range slice.Sort(maps.Keys(primaries[primary])) {
+			summary.Rows = append(summary.Rows, newRow(sub, false))
+		}
+	}
+	return summary
+}
+
+// latencyPercentile uses the nearest-rank method on sorted latencies; returns 0 if there are none
+func latencyPercentile(sorted []time.Duration, percentile float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(percentile / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func (c *ConvergenceSummary) RenderTable() string {
+	str := &strings.Builder{}
+	cni := c.CNI
+	if cni == "" {
+		cni = "unnamed CNI"
+	}
+	str.WriteString(fmt.Sprintf("Policy enforcement convergence latency for %s:\n", cni))
+	table := tablewriter.NewWriter(str)
+	table.SetAutoWrapText(false)
+	table.SetHeader([]string{"Tag", "Converged", "Timed out", "p50", "p90", "p99", "Max"})
+	for _, row := range c.Rows {
+		name := row.Tag
+		if !row.IsPrimary {
+			name = " - " + name
+		}
+		latencies := []string{"-", "-", "-", "-"}
+		if row.Converged > 0 {
+			for i, latency := range []time.Duration{row.P50, row.P90, row.P99, row.Max} {
+				latencies[i] = latency.Round(time.Millisecond).String()
+			}
+		}
+		table.Append(append([]string{name, intToString(row.Converged), intToString(row.TimedOut)}, latencies...))
+	}
+	table.Render()
+	return str.String()
+}
+
+// WriteConvergenceSummary writes the summary as JSON, so that the latencies of several CNIs can be compared
+func WriteConvergenceSummary(filename string, summary *ConvergenceSummary) error {
+	if filename == "" || summary == nil {
+		return nil
+	}
+	bytes, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return errors.Wrapf(err, "unable to marshal convergence summary")
+	}
+	return errors.Wrapf(os.WriteFile(filename, bytes, 0644), "unable to write file %s", filename)
+}
@@ -0,0 +1,46 @@
+package connectivity
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/generator"
+)
+
+func RunConvergenceTests() {
+	Describe("ConvergenceSummary", func() {
+		newResult := func(tag string, convergences ...*Convergence) *Result {
+			result := &Result{TestCase: generator.NewTestCase("test", generator.NewStringSet(tag))}
+			for _, convergence := range convergences {
+				result.Steps = append(result.Steps, &StepResult{Convergence: convergence})
+			}
+			return result
+		}
+		converged := func(seconds int) *Convergence {
+			return &Convergence{Converged: true, Latency: time.Duration(seconds) * time.Second, Tries: 1}
+		}
+
+		It("is nil if convergence wasn't measured", func() {
+			Expect(NewConvergenceSummaryFromResults("cni", []*Result{newResult(generator.TagIngress, nil)})).To(BeNil())
+		})
+
+		It("aggregates percentiles by tag", func() {
+			var latencies []*Convergence
+			for i := 1; i <= 10; i++ {
+				latencies = append(latencies, converged(i))
+			}
+			summary := NewConvergenceSummaryFromResults("cni", []*Result{
+				newResult(generator.TagIngress, latencies...),
+				newResult(generator.TagEgress, converged(20), &Convergence{Converged: false, Latency: time.Minute}),
+			})
+			Expect(summary.CNI).To(Equal("cni"))
+			Expect(summary.Rows).To(Equal([]*ConvergenceRow{
+				{Tag: "all", IsPrimary: true, Converged: 11, TimedOut: 1, P50: 6 * time.Second, P90: 10 * time.Second, P99: 20 * time.Second, Max: 20 * time.Second},
+				{Tag: generator.TagDirection, IsPrimary: true, Converged: 11, TimedOut: 1, P50: 6 * time.Second, P90: 10 * time.Second, P99: 20 * time.Second, Max: 20 * time.Second},
+				{Tag: generator.TagEgress, Converged: 1, TimedOut: 1, P50: 20 * time.Second, P90: 20 * time.Second, P99: 20 * time.Second, Max: 20 * time.Second},
+				{Tag: generator.TagIngress, Converged: 10, P50: 5 * time.Second, P90: 9 * time.Second, P99: 10 * time.Second, Max: 10 * time.Second},
+			}))
+		})
+	})
+}
@@ -0,0 +1,287 @@
+package connectivity
+
+import (
+	"fmt"
+	"html/template"
+	"os"
+	"strings"
+
+	"github.com/mattfenwick/collections/pkg/slice"
+	"github.com/pkg/errors"
+	"golang.org/x/exp/maps"
+	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/connectivity/probe"
+	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/generator"
+	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/utils"
+)
+
+// htmlReport is the data rendered by htmlReportTemplate: everything is precomputed, so that the template
+// doesn't need to know about truth tables and comparisons
+type htmlReport struct {
+	Passed    int
+	Failed    int
+	Flaky     int
+	PassRates []*htmlPassRateTable
+	TestCases []*htmlTestCase
+}
+
+type htmlPassRateTable struct {
+	Name string
+	Rows []*htmlPassRateRow
+}
+
+type htmlPassRateRow struct {
+	Name      string
+	IsPrimary bool
+	Pass      int
+	Fail      int
+}
+
+func (r *htmlPassRateRow) Percentage() string {
+	return fmt.Sprintf("%.0f%%", percentage(r.Pass, r.Pass+r.Fail))
+}
+
+type htmlTestCase struct {
+	Number      int
+	Description string
+	Tags        string
+	Passed      bool
+	Err         string
+	Steps       []*htmlStep
+}
+
+type htmlStep struct {
+	Number   int
+	Probe    string
+	Passed   bool
+	Tries    int
+	Wrong    int
+	Flaky    int
+	Policies []string
+	Tables   []*htmlTruthTable
+}
+
+type htmlTruthTable struct {
+	Caption string
+	Tos     []string
+	Rows    []*htmlTruthTableRow
+}
+
+type htmlTruthTableRow struct {
+	From  string
+	Cells [][]*htmlTruthTableEntry
+}
+
+// htmlTruthTableEntry is the result for one port/protocol of a from/to pair
+type htmlTruthTableEntry struct {
+	PortProtocol string
+	Value        string
+	Wrong        bool
+}
+
+// PrintHTMLReport writes a self-contained HTML report of the results, with the same summary as PrintSummary
+func PrintHTMLReport(filename string, results []*Result, ignoreLoopback bool) error {
+	if filename == "" {
+		return nil
+	}
+
+	f, err := os.Create(filename)
+	if err != nil {
+		return errors.Wrapf(err, "unable to create file %s for html report", filename)
+	}
+	defer f.Close()
+
+	return errors.Wrapf(htmlReportTemplate.Execute(f, newHTMLReport(results, ignoreLoopback)), "unable to render html report")
+}
+
+func newHTMLReport(results []*Result, ignoreLoopback bool) *htmlReport {
+	summary := NewSummaryTableFromResults(ignoreLoopback, results)
+	protocols := &htmlPassRateTable{Name: "Probes by protocol"}
+	for _, protocol := range []v1.Protocol{v1.ProtocolTCP, v1.ProtocolUDP, v1.ProtocolSCTP} {
+		counts := summary.ProtocolCounts[protocol]
+		protocols.Rows = append(protocols.Rows, &htmlPassRateRow{Name: string(protocol), IsPrimary: true, Pass: counts[SameComparison], Fail: counts[DifferentComparison]})
+	}
+	report := &htmlReport{
+		Passed: summary.Passed,
+		Failed: summary.Failed,
+		Flaky:  summary.Flaky,
+		PassRates: []*htmlPassRateTable{
+			{Name: "Tag", Rows: newHTMLPassRateRows(summary.TagPrimaryCounts, summary.TagCounts)},
+			{Name: "Feature", Rows: newHTMLPassRateRows(summary.FeaturePrimaryCounts, summary.FeatureCounts)},
+			protocols,
+		},
+	}
+
+	for i, result := range results {
+		testCase := &htmlTestCase{
+			Number:      i + 1,
+			Description: result.TestCase.Description,
+			Tags:        strings.Join(result.TestCase.Tags.Keys(), ", "),
+			Passed:      result.Err == nil && result.Passed(ignoreLoopback),
+		}
+		if result.Err != nil {
+			testCase.Err = fmt.Sprintf("%+v", result.Err)
+		}
+		for j, step := range result.Steps {
+			testCase.Steps = append(testCase.Steps, newHTMLStep(j+1, result.TestCase.Steps[j].Probe, step, ignoreLoopback))
+		}
+		report.TestCases = append(report.TestCases, testCase)
+	}
+	return report
+}
+
+func newHTMLPassRateRows(primaryCounts map[string]map[bool]int, counts map[string]map[string]map[bool]int) []*htmlPassRateRow {
+	var rows []*htmlPassRateRow
+	for _, primary := range slice.Sort(maps.Keys(counts)) {
+		rows = append(rows, &htmlPassRateRow{Name: primary, IsPrimary: true, Pass: primaryCounts[primary][true], Fail: primaryCounts[primary][false]})
+		for _, sub := range slice.Sort(maps.Keys(counts[primary])) {
+			rows = append(rows, &htmlPassRateRow{Name: sub, Pass: counts[primary][sub][true], Fail: counts[primary][sub][false]})
+		}
+	}
+	return rows
+}
+
+func newHTMLStep(number int, probeConfig *generator.ProbeConfig, step *StepResult, ignoreLoopback bool) *htmlStep {
+	probeDescription := "all available ports/protocols"
+	if probeConfig.PortProtocol != nil {
+		probeDescription = fmt.Sprintf("port %s, protocol %s", probeConfig.PortProtocol.Port.String(), probeConfig.PortProtocol.Protocol)
+	}
+
+	var policies []string
+	for _, policy := range step.KubePolicies {
+		policies = append(policies, PrintNetworkPolicy(policy))
+	}
+	for _, anp := range step.ANPs {
+		policies = append(policies, utils.YamlString(anp))
+	}
+	if step.BANP != nil {
+		policies = append(policies, utils.YamlString(step.BANP))
+	}
+
+	comparison := step.LastComparison()
+	isWrong := func(from string, to string, portProtocol string) bool {
+		if ignoreLoopback && from == to {
+			return false
+		}
+		item := comparison.Get(from, to)
+		kube, simulated := item.Kube.JobResults[portProtocol], item.Simulated.JobResults[portProtocol]
+		return kube == nil || simulated == nil || kube.Combined != simulated.Combined
+	}
+	simulated, kube := step.SimulatedProbe, step.LastKubeProbe()
+	counts := step.ValueCounts(ignoreLoopback)
+	return &htmlStep{
+		Number:   number,
+		Probe:    probeDescription,
+		Passed:   step.Passed(ignoreLoopback),
+		Tries:    len(step.KubeProbes),
+		Wrong:    counts[DifferentComparison],
+		Flaky:    counts[FlakyComparison],
+		Policies: policies,
+		Tables: []*htmlTruthTable{
+			newHTMLTruthTable("Expected ingress", simulated, htmlIngress, isWrong),
+			newHTMLTruthTable("Expected egress", simulated, htmlEgress, isWrong),
+			newHTMLTruthTable("Expected combined", simulated, htmlCombined, isWrong),
+			newHTMLTruthTable(fmt.Sprintf("Actual combined (try %d)", len(step.KubeProbes)), kube, htmlCombined, isWrong),
+		},
+	}
+}
+
+func htmlIngress(result *probe.JobResult) string {
+	return htmlDirection(result.Ingress)
+}
+
+func htmlEgress(result *probe.JobResult) string {
+	return htmlDirection(result.Egress)
+}
+
+func htmlCombined(result *probe.JobResult) string {
+	return result.Combined.ShortString()
+}
+
+// htmlDirection renders an ingress or egress result, which is only known for simulated probes
+func htmlDirection(connectivity *probe.Connectivity) string {
+	if connectivity == nil {
+		return probe.ConnectivityUnknown.ShortString()
+	}
+	return connectivity.ShortString()
+}
+
+func newHTMLTruthTable(caption string, table *probe.Table, render func(*probe.JobResult) string, isWrong func(string, string, string) bool) *htmlTruthTable {
+	truthTable := &htmlTruthTable{Caption: caption, Tos: table.Wrapped.Tos}
+	for _, from := range table.Wrapped.Froms {
+		row := &htmlTruthTableRow{From: from}
+		for _, to := range table.Wrapped.Tos {
+			jobResults := table.Get(from, to).JobResults
+			var cell []*htmlTruthTableEntry
+			for _, portProtocol := range slice.Sort(maps.Keys(jobResults)) {
+				cell = append(cell, &htmlTruthTableEntry{
+					PortProtocol: portProtocol,
+					Value:        render(jobResults[portProtocol]),
+					Wrong:        isWrong(from, to, portProtocol),
+				})
+			}
+			row.Cells = append(row.Cells, cell)
+		}
+		truthTable.Rows = append(truthTable.Rows, row)
+	}
+	return truthTable
+}
+
+var htmlReportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>policy-assistant report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin: 0.5em 1em 1em 0; }
+th, td { border: 1px solid #ccc; padding: 2px 6px; text-align: left; vertical-align: top; }
+td.primary { font-weight: bold; }
+td.sub { padding-left: 2em; }
+.truthtables { display: flex; flex-wrap: wrap; }
+.truthtables td { font-family: monospace; white-space: nowrap; }
+.wrong { background: #f8c4c4; font-weight: bold; }
+.pass { color: #1a7f37; }
+.fail { color: #cf222e; }
+details { margin: 0.3em 0; }
+details details { margin-left: 1.5em; }
+summary { cursor: pointer; }
+pre { background: #f6f8fa; padding: 0.5em; overflow-x: auto; }
+</style>
+</head>
+<body>
+<h1>policy-assistant report</h1>
+<p><span class="pass">{{.Passed}} passed</span>, <span class="fail">{{.Failed}} failed</span>, {{.Flaky}} passed with flaky results</p>
+
+<h2>Summary</h2>
+<div class="truthtables">
+{{range .PassRates}}<table>
+<tr><th>{{.Name}}</th><th>Passed</th><th>Failed</th><th>Pass rate</th></tr>
+{{range .Rows}}<tr><td class="{{if .IsPrimary}}primary{{else}}sub{{end}}">{{.Name}}</td><td>{{.Pass}}</td><td>{{.Fail}}</td><td class="{{if .Fail}}fail{{else}}pass{{end}}">{{.Percentage}}</td></tr>
+{{end}}</table>
+{{end}}</div>
+
+<h2>Test cases</h2>
+{{range .TestCases}}<details>
+<summary><span class="{{if .Passed}}pass{{else}}fail{{end}}">{{if .Passed}}passed{{else}}failed{{end}}</span> #{{.Number}}: {{.Description}}</summary>
+<p>tags: {{.Tags}}</p>
+{{if .Err}}<pre class="fail">{{.Err}}</pre>{{end}}
+{{range .Steps}}<details{{if not .Passed}} open{{end}}>
+<summary><span class="{{if .Passed}}pass{{else}}fail{{end}}">step {{.Number}}</span> on {{.Probe}}: {{.Wrong}} wrong, {{.Flaky}} flaky over {{.Tries}} tries</summary>
+<div class="truthtables">
+{{range .Tables}}<table>
+<caption>{{.Caption}}</caption>
+<tr><th></th>{{range .Tos}}<th>{{.}}</th>{{end}}</tr>
+{{range .Rows}}<tr><th>{{.From}}</th>{{range .Cells}}<td>{{range .}}<div{{if .Wrong}} class="wrong"{{end}} title="{{.PortProtocol}}">{{.PortProtocol}}: {{.Value}}</div>{{end}}</td>{{end}}</tr>
+{{end}}</table>
+{{end}}</div>
+<details><summary>policies</summary>
+{{range .Policies}}<pre>{{.}}</pre>
+{{else}}<p>no policies</p>
+{{end}}</details>
+</details>
+{{end}}</details>
+{{end}}
+</body>
+</html>
+`))
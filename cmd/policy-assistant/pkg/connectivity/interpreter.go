@@ -1,16 +1,21 @@
 package connectivity
 
 import (
+	"context"
 	"fmt"
 	"time"
 
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 	networkingv1 "k8s.io/api/networking/v1"
+	"sigs.k8s.io/network-policy-api/apis/v1alpha1"
 	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/connectivity/probe"
 	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/generator"
 	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/kube"
 	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/matcher"
+	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/telemetry"
 )
 
 const (
@@ -18,29 +23,51 @@ const (
 
 	// 9 = 3 namespaces x 3 pods
 	defaultBatchWorkersCount = 9
+
+	// convergencePollInterval is the pause between probes while waiting for a step to converge
+	convergencePollInterval = 500 * time.Millisecond
 )
 
 type InterpreterConfig struct {
-	ResetClusterBeforeTestCase       bool
-	KubeProbeRetries                 int
+	ResetClusterBeforeTestCase bool
+	KubeProbeRetries           int
+	// RepeatKubeProbes, if set, runs every retry even once a kube probe passes, so that flaky results can be
+	// told apart from consistently wrong ones
+	RepeatKubeProbes                 bool
 	PerturbationWaitSeconds          int
 	VerifyClusterStateBeforeTestCase bool
 	BatchJobs                        bool
 	IgnoreLoopback                   bool
 	JobTimeoutSeconds                int
 	FailFast                         bool
+	// RecordDirectory, if set, is where every round of kube probe results is saved
+	RecordDirectory string
+	// ReplayDirectory, if set, is where kube probe results are replayed from, instead of probing the cluster
+	ReplayDirectory string
+	// ConvergenceTimeoutSeconds, if positive, replaces the perturbation wait and the retries: after its actions,
+	// every step is probed repeatedly until kube matches the simulation or the timeout expires, and the time
+	// that took is recorded
+	ConvergenceTimeoutSeconds int
 }
 
 func (i *InterpreterConfig) PerturbationWaitDuration() time.Duration {
 	return time.Duration(i.PerturbationWaitSeconds) * time.Second
 }
 
+func (i *InterpreterConfig) ConvergenceTimeoutDuration() time.Duration {
+	return time.Duration(i.ConvergenceTimeoutSeconds) * time.Second
+}
+
 type Interpreter struct {
 	kubernetes kube.IKubernetes
 	resources  *probe.Resources
 	kubeRunner *probe.Runner
 	jobBuilder *probe.JobBuilder
 	Config     *InterpreterConfig
+	// testCaseCount identifies test cases in recordings, by the order in which they were executed
+	testCaseCount int
+	// createdAdminPolicies outlives test cases, so that resets clean up the admin network policies they left behind
+	createdAdminPolicies *CreatedAdminNetworkPolicies
 }
 
 func NewInterpreter(kubernetes kube.IKubernetes, resources *probe.Resources, config *InterpreterConfig) *Interpreter {
@@ -53,6 +80,11 @@ func NewInterpreter(kubernetes kube.IKubernetes, resources *probe.Resources, con
 	} else {
 		kubeRunner = probe.NewKubeRunner(kubernetes, defaultWorkersCount, jobBuilder)
 	}
+	if config.ReplayDirectory != "" {
+		kubeRunner = &probe.Runner{JobRunner: probe.NewReplayJobRunner(config.ReplayDirectory), JobBuilder: jobBuilder}
+	} else if config.RecordDirectory != "" {
+		kubeRunner.JobRunner = probe.NewRecordingJobRunner(kubeRunner.JobRunner, config.RecordDirectory)
+	}
 
 	return &Interpreter{
 		kubernetes: kubernetes,
@@ -60,10 +92,36 @@ func NewInterpreter(kubernetes kube.IKubernetes, resources *probe.Resources, con
 		kubeRunner: kubeRunner,
 		jobBuilder: jobBuilder,
 		Config:     config,
+
+		createdAdminPolicies: NewCreatedAdminNetworkPolicies(),
 	}
 }
 
 func (t *Interpreter) ExecuteTestCase(testCase *generator.TestCase) *Result {
+	t.testCaseCount++
+	ctx, span := telemetry.StartSpan(context.Background(), "test case",
+		attribute.Int("test_case.index", t.testCaseCount),
+		attribute.String("test_case.description", testCase.Description),
+		attribute.StringSlice("test_case.tags", testCase.Tags.Keys()))
+	defer span.End()
+
+	result := t.executeTestCase(ctx, testCase)
+
+	outcome := telemetry.TestCaseResultPassed
+	if result.Err != nil {
+		outcome = telemetry.TestCaseResultError
+		span.RecordError(result.Err)
+		span.SetStatus(codes.Error, "test case errored")
+	} else if !result.Passed(t.Config.IgnoreLoopback) {
+		outcome = telemetry.TestCaseResultFailed
+		span.SetStatus(codes.Error, "test case failed")
+	}
+	span.SetAttributes(attribute.String("test_case.result", outcome))
+	telemetry.ObserveTestCase(testCase.Tags.Keys(), outcome)
+	return result
+}
+
+func (t *Interpreter) executeTestCase(ctx context.Context, testCase *generator.TestCase) *Result {
 	result := &Result{InitialResources: t.resources, TestCase: testCase}
 	var err error
 
@@ -72,6 +130,7 @@ func (t *Interpreter) ExecuteTestCase(testCase *generator.TestCase) *Result {
 		Kubernetes: t.kubernetes,
 		Resources:  t.resources,
 		Policies:   []*networkingv1.NetworkPolicy{},
+		Created:    t.createdAdminPolicies,
 	}
 
 	if t.Config.ResetClusterBeforeTestCase {
@@ -95,6 +154,8 @@ func (t *Interpreter) ExecuteTestCase(testCase *generator.TestCase) *Result {
 	// perform perturbations one at a time, and run a probe after each change
 	for stepIndex, step := range testCase.Steps {
 		// TODO grab actual netpols from kube and record in results, for extra debugging/sanity checks
+		stepStart := time.Now()
+		stepCtx, stepSpan := telemetry.StartSpan(ctx, "step", attribute.Int("step.index", stepIndex+1), attribute.Int("step.actions", len(step.Actions)))
 
 		for actionIndex, action := range step.Actions {
 			if action.CreatePolicy != nil {
@@ -103,6 +164,18 @@ func (t *Interpreter) ExecuteTestCase(testCase *generator.TestCase) *Result {
 				err = testCaseState.UpdatePolicy(action.UpdatePolicy.Policy)
 			} else if action.DeletePolicy != nil {
 				err = testCaseState.DeletePolicy(action.DeletePolicy.Namespace, action.DeletePolicy.Name)
+			} else if action.CreateAdminNetworkPolicy != nil {
+				err = testCaseState.CreateAdminNetworkPolicy(action.CreateAdminNetworkPolicy.Policy)
+			} else if action.UpdateAdminNetworkPolicy != nil {
+				err = testCaseState.UpdateAdminNetworkPolicy(action.UpdateAdminNetworkPolicy.Policy)
+			} else if action.DeleteAdminNetworkPolicy != nil {
+				err = testCaseState.DeleteAdminNetworkPolicy(action.DeleteAdminNetworkPolicy.Name)
+			} else if action.CreateBaselineAdminNetworkPolicy != nil {
+				err = testCaseState.CreateBaselineAdminNetworkPolicy(action.CreateBaselineAdminNetworkPolicy.Policy)
+			} else if action.UpdateBaselineAdminNetworkPolicy != nil {
+				err = testCaseState.UpdateBaselineAdminNetworkPolicy(action.UpdateBaselineAdminNetworkPolicy.Policy)
+			} else if action.DeleteBaselineAdminNetworkPolicy != nil {
+				err = testCaseState.DeleteBaselineAdminNetworkPolicy(action.DeleteBaselineAdminNetworkPolicy.Name)
 			} else if action.CreateNamespace != nil {
 				err = testCaseState.CreateNamespace(action.CreateNamespace.Namespace, action.CreateNamespace.Labels)
 			} else if action.SetNamespaceLabels != nil {
@@ -122,16 +195,32 @@ func (t *Interpreter) ExecuteTestCase(testCase *generator.TestCase) *Result {
 				err = errors.Errorf("invalid Action at step %d, action %d", stepIndex, actionIndex)
 			}
 			if err != nil {
+				stepSpan.RecordError(err)
+				stepSpan.SetStatus(codes.Error, "action failed")
+				stepSpan.End()
 				result.Err = err
 				return result
 			}
 		}
 
-		logrus.Infof("step %d: waiting %d seconds for perturbation to take effect", stepIndex+1, t.Config.PerturbationWaitSeconds)
-		time.Sleep(t.Config.PerturbationWaitDuration())
+		actionsDone := time.Now()
+		if t.Config.ConvergenceTimeoutSeconds <= 0 {
+			logrus.Infof("step %d: waiting %d seconds for perturbation to take effect", stepIndex+1, t.Config.PerturbationWaitSeconds)
+			time.Sleep(t.Config.PerturbationWaitDuration())
+		}
 
-		stepResult := t.runProbe(testCaseState, step.Probe)
+		stepResult, err := t.runProbe(stepCtx, testCaseState, testCase.Description, stepIndex, step.Probe, actionsDone)
+		if err != nil {
+			stepSpan.RecordError(err)
+			stepSpan.SetStatus(codes.Error, "probe failed")
+			stepSpan.End()
+			result.Err = err
+			return result
+		}
 		result.Steps = append(result.Steps, stepResult)
+		telemetry.ObserveStep(time.Since(stepStart))
+		stepSpan.SetAttributes(attribute.Bool("step.passed", stepResult.Passed(t.Config.IgnoreLoopback)), attribute.Int("step.tries", len(stepResult.KubeProbes)))
+		stepSpan.End()
 
 		if t.Config.FailFast && !stepResult.Passed(t.Config.IgnoreLoopback) {
 			break
@@ -141,8 +230,8 @@ func (t *Interpreter) ExecuteTestCase(testCase *generator.TestCase) *Result {
 	return result
 }
 
-func (t *Interpreter) runProbe(testCaseState *TestCaseState, probeConfig *generator.ProbeConfig) *StepResult {
-	parsedPolicy := matcher.BuildNetworkPolicies(true, testCaseState.Policies)
+func (t *Interpreter) runProbe(ctx context.Context, testCaseState *TestCaseState, testCaseDescription string, stepIndex int, probeConfig *generator.ProbeConfig, actionsDone time.Time) (*StepResult, error) {
+	parsedPolicy := matcher.BuildV1AndV2NetPols(true, testCaseState.Policies, testCaseState.ANPs, testCaseState.BANP)
 
 	logrus.Infof("running probe %+v", probeConfig)
 	logrus.Debugf("with resources:\n%s", testCaseState.Resources.RenderTable())
@@ -152,16 +241,78 @@ func (t *Interpreter) runProbe(testCaseState *TestCaseState, probeConfig *genera
 	stepResult := NewStepResult(
 		simRunner.RunProbeForConfig(probeConfig, testCaseState.Resources),
 		parsedPolicy,
-		append([]*networkingv1.NetworkPolicy{}, testCaseState.Policies...)) // this looks weird, but just making a new copy to avoid accidentally mutating it elsewhere
+		// this looks weird, but just making new copies to avoid accidentally mutating them elsewhere
+		append([]*networkingv1.NetworkPolicy{}, testCaseState.Policies...),
+		append([]*v1alpha1.AdminNetworkPolicy{}, testCaseState.ANPs...),
+		testCaseState.BANP)
 
+	if t.Config.ConvergenceTimeoutSeconds > 0 {
+		err := t.runKubeProbesUntilConverged(ctx, testCaseState, testCaseDescription, stepIndex, probeConfig, stepResult, actionsDone)
+		return stepResult, err
+	}
+
+	stepResult.RepeatedKubeProbes = t.Config.RepeatKubeProbes
 	for i := 0; i <= t.Config.KubeProbeRetries; i++ {
-		logrus.Infof("running kube probe on try %d", i+1)
-		stepResult.AddKubeProbe(t.kubeRunner.RunProbeForConfig(probeConfig, testCaseState.Resources))
+		err := t.runKubeProbe(ctx, testCaseState, testCaseDescription, stepIndex, i, probeConfig, stepResult)
+		if err != nil {
+			return nil, err
+		}
 		// no differences between synthetic and kube probes?  then we can stop
-		if stepResult.Passed(t.Config.IgnoreLoopback) {
+		if !t.Config.RepeatKubeProbes && stepResult.Passed(t.Config.IgnoreLoopback) {
 			break
 		}
 	}
 
-	return stepResult
+	return stepResult, nil
+}
+
+// runKubeProbesUntilConverged probes until the kube results match the simulated results, or until the
+// convergence timeout expires.  Since each probe takes a while, the measured latency is an upper bound:
+// kube converged at some point during the last probe.
+func (t *Interpreter) runKubeProbesUntilConverged(ctx context.Context, testCaseState *TestCaseState, testCaseDescription string, stepIndex int, probeConfig *generator.ProbeConfig, stepResult *StepResult, actionsDone time.Time) error {
+	deadline := actionsDone.Add(t.Config.ConvergenceTimeoutDuration())
+	for i := 0; ; i++ {
+		err := t.runKubeProbe(ctx, testCaseState, testCaseDescription, stepIndex, i, probeConfig, stepResult)
+		if err != nil {
+			return err
+		}
+		latency := time.Since(actionsDone)
+		if stepResult.LastComparison().ValueCounts(t.Config.IgnoreLoopback)[DifferentComparison] == 0 {
+			logrus.Infof("step %d: converged after %s", stepIndex+1, latency)
+			stepResult.Convergence = &Convergence{Converged: true, Latency: latency, Tries: i + 1}
+			return nil
+		}
+		if time.Now().After(deadline) {
+			logrus.Warnf("step %d: did not converge within %d seconds", stepIndex+1, t.Config.ConvergenceTimeoutSeconds)
+			stepResult.Convergence = &Convergence{Converged: false, Latency: latency, Tries: i + 1}
+			return nil
+		}
+		time.Sleep(convergencePollInterval)
+	}
+}
+
+func (t *Interpreter) runKubeProbe(ctx context.Context, testCaseState *TestCaseState, testCaseDescription string, stepIndex int, try int, probeConfig *generator.ProbeConfig, stepResult *StepResult) error {
+	logrus.Infof("running kube probe on try %d", try+1)
+	_, span := telemetry.StartSpan(ctx, "kube probe", attribute.Int("probe.try", try+1))
+	defer span.End()
+	if roundAware, ok := t.kubeRunner.JobRunner.(probe.RoundAwareJobRunner); ok {
+		err := roundAware.StartRound(&probe.Round{
+			RoundKey: probe.RoundKey{
+				TestCaseIndex: t.testCaseCount,
+				TestCase:      testCaseDescription,
+				Step:          stepIndex + 1,
+				Try:           try + 1,
+			},
+			Policies: stepResult.KubePolicies,
+			ANPs:     stepResult.ANPs,
+			BANP:     stepResult.BANP,
+		})
+		if err != nil {
+			span.RecordError(err)
+			return errors.Wrapf(err, "unable to start round of kube probes for step %d try %d", stepIndex+1, try+1)
+		}
+	}
+	stepResult.AddKubeProbe(t.kubeRunner.RunProbeForConfig(probeConfig, testCaseState.Resources))
+	span.SetAttributes(attribute.Int("probe.wrong", stepResult.LastComparison().ValueCounts(t.Config.IgnoreLoopback)[DifferentComparison]))
+	return nil
 }
@@ -10,7 +10,9 @@ import (
 
 type JUnitTestResult struct {
 	Passed bool
-	Name   string
+	// Flaky is set for tests which passed, but which had flaky results
+	Flaky bool
+	Name  string
 }
 
 func PrintJUnitResults(filename string, results []*Result, ignoreLoopback bool) error {
@@ -22,6 +24,7 @@ func PrintJUnitResults(filename string, results []*Result, ignoreLoopback bool)
 	for _, result := range results {
 		junitResults = append(junitResults, &JUnitTestResult{
 			Passed: result.Passed(ignoreLoopback),
+			Flaky:  result.Flaky(ignoreLoopback),
 			Name:   result.TestCase.Description,
 		})
 	}
@@ -39,8 +42,11 @@ func PrintJUnitResults(filename string, results []*Result, ignoreLoopback bool)
 	return enc.Encode(junitTestSuite)
 }
 
+// ResultsToJUnit converts results to a test suite.  JUnit has no notion of flaky tests, so flaky tests which
+// passed are listed as "flaky" properties of the test suite.
 func ResultsToJUnit(results []*JUnitTestResult) junit.JUnitTestSuite {
 	var testCases []junit.JUnitTestCase
+	var properties []junit.JUnitProperty
 	failed := 0
 
 	for _, result := range results {
@@ -50,12 +56,15 @@ func ResultsToJUnit(results []*JUnitTestResult) junit.JUnitTestSuite {
 		if !result.Passed {
 			testCase.Failure = &junit.JUnitFailure{}
 			failed++
+		} else if result.Flaky {
+			properties = append(properties, junit.JUnitProperty{Name: "flaky", Value: result.Name})
 		}
 		testCases = append(testCases, testCase)
 	}
 	return junit.JUnitTestSuite{
-		Name:      "policy-assistant",
-		Failures:  failed,
-		TestCases: testCases,
+		Name:       "policy-assistant",
+		Failures:   failed,
+		Properties: properties,
+		TestCases:  testCases,
 	}
 }
@@ -1,248 +1,302 @@
 package connectivity
 
-/*
-TODO
 import (
+	"context"
 	"fmt"
-	connectivitykube sigs.k8s.io/network-policy-api/policy-assistant/pkg/connectivity/kube"
-	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/connectivity/synthetic"
-	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/explainer"
-	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/kube"
-	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/matcher"
-	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/utils"
+	"strings"
+	"time"
+
+	"github.com/mattfenwick/collections/pkg/slice"
 	"github.com/olekukonko/tablewriter"
+	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
-	v1 "k8s.io/api/core/v1"
+	"golang.org/x/exp/maps"
 	networkingv1 "k8s.io/api/networking/v1"
-	"k8s.io/apimachinery/pkg/util/intstr"
-	"os"
-	"sigs.k8s.io/yaml"
-	"strings"
-	"time"
+	"sigs.k8s.io/network-policy-api/apis/v1alpha1"
+	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/connectivity/probe"
+	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/generator"
+	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/kube"
 )
 
+// MultipleContextTestCase is a set of policies which gets deployed to every kube context,
+// followed by the same probe in each context.
 type MultipleContextTestCase struct {
-	KubePolicies              []*networkingv1.NetworkPolicy
-	NetpolCreationWaitSeconds int
-	Port                      intstr.IntOrString
-	Protocol                  v1.Protocol
-	KubeClients               map[string]*kube.Kubernetes
-	KubeResources             map[string]*connectivitykube.Resources
-	SyntheticResources        *synthetic.Resources
-	NamespacesToClean         []string
-	Policy                    *matcher.Policy
+	Description string
+	Policies    []*networkingv1.NetworkPolicy
+	ANPs        []*v1alpha1.AdminNetworkPolicy
+	BANP        *v1alpha1.BaselineAdminNetworkPolicy
+	ProbeConfig *generator.ProbeConfig
 }
 
 type MultipleContextTestCaseResult struct {
-	TestCase        *MultipleContextTestCase
-	SyntheticResult *synthetic.Result
-	KubeResults     map[string]*connectivitykube.Results
-	Errors          []error
+	TestCase   *MultipleContextTestCase
+	Contexts   []string
+	KubeProbes map[string]*probe.Table
+	Errors     map[string]error
+}
+
+// ContextDifference is a single from/to/port/protocol probe on which the contexts disagree
+type ContextDifference struct {
+	From         string
+	To           string
+	PortProtocol string
+	Results      map[string]probe.Connectivity
 }
 
-type MultipleContextTester struct{}
+// Differences compares the probes of every context cell by cell, and returns the cells where
+// at least two contexts disagree.  Contexts which failed are skipped.
+func (r *MultipleContextTestCaseResult) Differences() []*ContextDifference {
+	contexts := r.succeededContexts()
+	if len(contexts) == 0 {
+		return nil
+	}
 
-func NewMultipleContextTester() *MultipleContextTester {
-	return &MultipleContextTester{}
+	var differences []*ContextDifference
+	first := r.KubeProbes[contexts[0]]
+	for _, key := range first.Wrapped.Keys() {
+		portProtocols := map[string]bool{}
+		for _, contextName := range contexts {
+			for k := range r.KubeProbes[contextName].Get(key.From, key.To).JobResults {
+				portProtocols[k] = true
+			}
+		}
+
+		for _, portProtocol := range slice.Sort(maps.Keys(portProtocols)) {
+			results := map[string]probe.Connectivity{}
+			for _, contextName := range contexts {
+				jr, ok := r.KubeProbes[contextName].Get(key.From, key.To).JobResults[portProtocol]
+				if ok {
+					results[contextName] = jr.Combined
+				} else {
+					results[contextName] = probe.ConnectivityUnknown
+				}
+			}
+			if !allEqual(contexts, results) {
+				differences = append(differences, &ContextDifference{
+					From:         key.From,
+					To:           key.To,
+					PortProtocol: portProtocol,
+					Results:      results,
+				})
+			}
+		}
+	}
+	return differences
+}
+
+// RenderDifferenceTable renders a from/to grid: '.' where all contexts agree, 'X' where they don't
+func (r *MultipleContextTestCaseResult) RenderDifferenceTable() string {
+	contexts := r.succeededContexts()
+	if len(contexts) == 0 {
+		return ""
+	}
+
+	different := map[string]bool{}
+	for _, d := range r.Differences() {
+		different[d.From+"|"+d.To] = true
+	}
+	return r.KubeProbes[contexts[0]].Wrapped.Table("", false, func(fr, to string, i interface{}) string {
+		if different[fr+"|"+to] {
+			return DifferentComparison.ShortString()
+		}
+		return SameComparison.ShortString()
+	})
+}
+
+func (r *MultipleContextTestCaseResult) succeededContexts() []string {
+	return slice.Filter(func(c string) bool {
+		_, ok := r.KubeProbes[c]
+		return ok
+	}, r.Contexts)
+}
+
+func allEqual(contexts []string, results map[string]probe.Connectivity) bool {
+	for _, c := range contexts[1:] {
+		if results[c] != results[contexts[0]] {
+			return false
+		}
+	}
+	return true
+}
+
+type MultipleContextTesterConfig struct {
+	PerturbationWaitSeconds int
+	JobTimeoutSeconds       int
+	BatchJobs               bool
+}
+
+// MultipleContextTester runs the same test cases against several clusters (e.g. one per CNI),
+// so that their results can be compared against each other.
+type MultipleContextTester struct {
+	Contexts   []string
+	kubernetes map[string]kube.IKubernetes
+	resources  map[string]*probe.Resources
+	runners    map[string]*probe.Runner
+	Config     *MultipleContextTesterConfig
+}
+
+func NewMultipleContextTester(kubernetes map[string]kube.IKubernetes, resources map[string]*probe.Resources, config *MultipleContextTesterConfig) *MultipleContextTester {
+	jobBuilder := &probe.JobBuilder{TimeoutSeconds: config.JobTimeoutSeconds}
+	runners := map[string]*probe.Runner{}
+	for contextName, kubeClient := range kubernetes {
+		if config.BatchJobs {
+			runners[contextName] = probe.NewKubeBatchRunner(kubeClient, defaultBatchWorkersCount, jobBuilder)
+		} else {
+			runners[contextName] = probe.NewKubeRunner(kubeClient, defaultWorkersCount, jobBuilder)
+		}
+	}
+
+	return &MultipleContextTester{
+		Contexts:   slice.Sort(maps.Keys(kubernetes)),
+		kubernetes: kubernetes,
+		resources:  resources,
+		runners:    runners,
+		Config:     config,
+	}
 }
 
 type workerResult struct {
 	ContextName string
-	Results     *connectivitykube.Results
+	Table       *probe.Table
 	Err         error
 }
 
 func (t *MultipleContextTester) TestNetworkPolicy(testCase *MultipleContextTestCase) *MultipleContextTestCaseResult {
 	result := &MultipleContextTestCaseResult{
-		TestCase: testCase,
-		SyntheticResult: synthetic.RunSyntheticProbe(&synthetic.Request{
-			Protocol:  testCase.Protocol,
-			Port:      testCase.Port,
-			Resources: testCase.SyntheticResources,
-			Policies:  testCase.Policy,
-		}),
-		KubeResults: map[string]*connectivitykube.Results{},
-	}
-
-	resultChan := make(chan *workerResult, len(testCase.KubeClients))
-	for contextName := range testCase.KubeClients {
-		go func(context string) {
-			results, err := t.testWorker(testCase, context)
-			resultChan <- &workerResult{
-				ContextName: context,
-				Results:     results,
-				Err:         err,
-			}
+		TestCase:   testCase,
+		Contexts:   t.Contexts,
+		KubeProbes: map[string]*probe.Table{},
+		Errors:     map[string]error{},
+	}
+
+	resultChan := make(chan *workerResult, len(t.Contexts))
+	for _, contextName := range t.Contexts {
+		go func(contextName string) {
+			table, err := t.testWorker(testCase, contextName)
+			resultChan <- &workerResult{ContextName: contextName, Table: table, Err: err}
 		}(contextName)
 	}
 
-	for i := 0; i < len(testCase.KubeClients); i++ {
-		results := <-resultChan
-		if results.Err == nil {
-			result.KubeResults[results.ContextName] = results.Results
+	for range t.Contexts {
+		r := <-resultChan
+		if r.Err == nil {
+			result.KubeProbes[r.ContextName] = r.Table
 		} else {
-			result.Errors = append(result.Errors, results.Err)
+			result.Errors[r.ContextName] = r.Err
 		}
 	}
 
 	return result
 }
 
-func (t *MultipleContextTester) testWorker(testCase *MultipleContextTestCase, contextName string) (*connectivitykube.Results, error) {
-	kubeClient := testCase.KubeClients[contextName]
-	utils.DoOrDie(kubeClient.DeleteAllNetworkPoliciesInNamespaces(testCase.NamespacesToClean))
+func (t *MultipleContextTester) testWorker(testCase *MultipleContextTestCase, contextName string) (*probe.Table, error) {
+	kubeClient := t.kubernetes[contextName]
+	resources := t.resources[contextName]
 
-	for _, kubePolicy := range testCase.KubePolicies {
-		_, err := kubeClient.CreateNetworkPolicy(kubePolicy)
-		if err != nil {
-			return nil, err
+	if err := kube.DeleteAllNetworkPoliciesInNamespaces(kubeClient, resources.NamespacesSlice()); err != nil {
+		return nil, err
+	}
+	defer cleanUpPolicies(kubeClient, resources, testCase, contextName)
+
+	for _, policy := range testCase.Policies {
+		if _, err := kubeClient.CreateNetworkPolicy(policy); err != nil {
+			return nil, errors.Wrapf(err, "unable to create network policy %s/%s", policy.Namespace, policy.Name)
+		}
+	}
+	for _, anp := range testCase.ANPs {
+		if _, err := kubeClient.CreateAdminNetworkPolicy(context.TODO(), anp); err != nil {
+			return nil, errors.Wrapf(err, "unable to create admin network policy %s", anp.Name)
+		}
+	}
+	if testCase.BANP != nil {
+		if _, err := kubeClient.CreateBaselineAdminNetworkPolicy(context.TODO(), testCase.BANP); err != nil {
+			return nil, errors.Wrapf(err, "unable to create baseline admin network policy %s", testCase.BANP.Name)
 		}
 	}
 
-	logrus.Infof("waiting %d seconds for network policy to create and become active", testCase.NetpolCreationWaitSeconds)
-	time.Sleep(time.Duration(testCase.NetpolCreationWaitSeconds) * time.Second)
+	logrus.Infof("context %s: waiting %d seconds for policies to take effect", contextName, t.Config.PerturbationWaitSeconds)
+	time.Sleep(time.Duration(t.Config.PerturbationWaitSeconds) * time.Second)
 
-	logrus.Infof("running probe on context %s", contextName)
+	logrus.Infof("context %s: running probe %+v", contextName, testCase.ProbeConfig)
+	return t.runners[contextName].RunProbeForConfig(testCase.ProbeConfig, resources), nil
+}
 
-	return connectivitykube.RunKubeProbe(kubeClient, &connectivitykube.Request{
-		Resources:       testCase.KubeResources[contextName],
-		Port:            testCase.Port,
-		Protocol:        testCase.Protocol,
-		NumberOfWorkers: 5,
-	}), nil
+func cleanUpPolicies(kubeClient kube.IKubernetes, resources *probe.Resources, testCase *MultipleContextTestCase, contextName string) {
+	if err := kube.DeleteAllNetworkPoliciesInNamespaces(kubeClient, resources.NamespacesSlice()); err != nil {
+		logrus.Errorf("context %s: unable to delete network policies: %+v", contextName, err)
+	}
+	for _, anp := range testCase.ANPs {
+		if err := kubeClient.DeleteAdminNetworkPolicy(context.TODO(), anp.Name); err != nil {
+			logrus.Errorf("context %s: unable to delete admin network policy %s: %+v", contextName, anp.Name, err)
+		}
+	}
+	if testCase.BANP != nil {
+		if err := kubeClient.DeleteBaselineAdminNetworkPolicy(context.TODO(), testCase.BANP.Name); err != nil {
+			logrus.Errorf("context %s: unable to delete baseline admin network policy %s: %+v", contextName, testCase.BANP.Name, err)
+		}
+	}
 }
 
 type MultipleContextTestCasePrinter struct {
-	Noisy            bool
-	IgnoreLoopback   bool
-	Contexts         []string
-	DifferenceCounts [][]int
+	Noisy   bool
+	Results []*MultipleContextTestCaseResult
 }
 
 func (t *MultipleContextTestCasePrinter) PrintTestCaseResult(result *MultipleContextTestCaseResult) {
-	policy := result.TestCase.Policy
-
-	policyBytes, err := yaml.Marshal(result.TestCase.KubePolicies)
-	utils.DoOrDie(err)
-	fmt.Printf("Network policy:\n\n%s\n", policyBytes)
+	t.Results = append(t.Results, result)
 
-	fmt.Printf("Policy explained:\n%s\n", explainer.TableExplainer(policy))
+	fmt.Printf("test case: %s\n\n", result.TestCase.Description)
 
-	//fmt.Println("expected results:")
-	//fmt.Println("Expected ingress:")
-	//result.SyntheticResult.Ingress.Table().Render()
-	//
-	//fmt.Println("Expected egress:")
-	//result.SyntheticResult.Egress.Table().Render()
-
-	fmt.Println("Expected connectivity:")
-	fmt.Println(result.SyntheticResult.Combined.Table())
-
-	foundDiscrepancy := false
-
-	var falseCounts []int
-	for _, contextName := range t.Contexts {
-		kubeResults := result.KubeResults[contextName]
-		comparison := result.SyntheticResult.Combined.Compare(kubeResults.TruthTable())
-		counts := comparison.ValueCounts(t.IgnoreLoopback)
-		if counts.False > 0 {
-			fmt.Printf("results for context %s:\n", contextName)
-			fmt.Printf("found %d true, %d false, %d no value, %d ignored from %d total\n", counts.True, counts.False, counts.NoValue, counts.Ignored, counts.Total)
-			foundDiscrepancy = true
+	for _, contextName := range result.Contexts {
+		if err, ok := result.Errors[contextName]; ok {
+			fmt.Printf("context %s failed: %+v\n\n", contextName, err)
+		} else if t.Noisy {
+			fmt.Printf("results for context %s:\n%s\n", contextName, result.KubeProbes[contextName].RenderTable())
 		}
-		falseCounts = append(falseCounts, counts.False)
 	}
-	t.DifferenceCounts = append(t.DifferenceCounts, falseCounts)
 
-	if foundDiscrepancy {
-		for _, contextName := range t.Contexts {
-			fmt.Printf("results for context %s:\n", contextName)
-			kubeResults := result.KubeResults[contextName]
-			fmt.Println(kubeResults.TruthTable().Table())
-		}
-	} else {
-		fmt.Println("no differences found for policy")
+	differences := result.Differences()
+	if len(differences) == 0 {
+		fmt.Printf("no differences found between contexts %s\n\n", strings.Join(result.succeededContexts(), ", "))
+		return
 	}
+
+	fmt.Printf("differences between contexts:\n%s\n", result.RenderDifferenceTable())
+	fmt.Printf("%s\n", renderDifferences(result.succeededContexts(), differences))
 }
 
-func (t *MultipleContextTestCasePrinter) PrintFinish() {
-	csv := []string{strings.Join(t.Contexts, ",")}
-	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader(t.Contexts)
-	for _, falseCounts := range t.DifferenceCounts {
-		var row []string
-		for _, falses := range falseCounts {
-			row = append(row, fmt.Sprintf("%d", falses))
+func renderDifferences(contexts []string, differences []*ContextDifference) string {
+	tableString := &strings.Builder{}
+	table := tablewriter.NewWriter(tableString)
+	table.SetAutoWrapText(false)
+	table.SetHeader(append([]string{"From", "To", "Port/Protocol"}, contexts...))
+	for _, d := range differences {
+		row := []string{d.From, d.To, d.PortProtocol}
+		for _, contextName := range contexts {
+			row = append(row, string(d.Results[contextName]))
 		}
-		csv = append(csv, strings.Join(row, ","))
 		table.Append(row)
 	}
 	table.Render()
+	return tableString.String()
+}
+
+func (t *MultipleContextTestCasePrinter) PrintSummary() {
+	if len(t.Results) == 0 {
+		return
+	}
 
-	fmt.Printf("\n\n%s\n\n", strings.Join(csv, "\n"))
-}
-*/
-
-//func SetupClusterTODODelete(kubernetes *kube.Kubernetes, namespaces []string, pods []string, port int, protocol v1.Protocol) (*connectivitykube.Resources, *synthetic.Resources, error) {
-//	kubeResources := connectivitykube.NewDefaultResources(namespaces, pods, []int{port}, []v1.Protocol{protocol})
-//
-//	err := kubeResources.CreateResourcesInKube(kubernetes)
-//	if err != nil {
-//		return nil, nil, err
-//	}
-//
-//	err = waitForPodsReadyTODODelete(kubernetes, namespaces, pods, 60)
-//	if err != nil {
-//		return nil, nil, err
-//	}
-//
-//	podList, err := kubernetes.GetPodsInNamespaces(namespaces)
-//	if err != nil {
-//		return nil, nil, err
-//	}
-//	var syntheticPods []*synthetic.Pod
-//	for _, pod := range podList {
-//		ip := pod.Status.PodIP
-//		if ip == "" {
-//			return nil, nil, errors.Errorf("no ip found for pod %s/%s", pod.Namespace, pod.Name)
-//		}
-//		syntheticPods = append(syntheticPods, &synthetic.Pod{
-//			Namespace: pod.Namespace,
-//			Name:      pod.Name,
-//			Labels:    pod.Labels,
-//			IP:        ip,
-//		})
-//		log.Infof("ip for pod %s/%s: %s", pod.Namespace, pod.Name, ip)
-//	}
-//
-//	resources, err := synthetic.NewResources(kubeResources.Namespaces, syntheticPods)
-//	if err != nil {
-//		return nil, nil, err
-//	}
-//
-//	return kubeResources, resources, nil
-//}
-
-//func waitForPodsReadyTODODelete(kubernetes *kube.Kubernetes, namespaces []string, pods []string, timeoutSeconds int) error {
-//	sleep := 5
-//	for i := 0; i < timeoutSeconds; i += sleep {
-//		podList, err := kubernetes.GetPodsInNamespaces(namespaces)
-//		if err != nil {
-//			return err
-//		}
-//
-//		ready := 0
-//		for _, pod := range podList {
-//			if pod.Status.Phase == "Running" && pod.Status.PodIP != "" {
-//				ready++
-//			}
-//		}
-//		if ready == len(namespaces)*len(pods) {
-//			return nil
-//		}
-//
-//		log.Infof("waiting for pods to be running and have IP addresses")
-//		time.Sleep(time.Duration(sleep) * time.Second)
-//	}
-//	return errors.Errorf("pods not ready")
-//}
+	tableString := &strings.Builder{}
+	table := tablewriter.NewWriter(tableString)
+	table.SetAutoWrapText(false)
+	table.SetHeader([]string{"Test case", "Differences", "Failed contexts"})
+	for _, result := range t.Results {
+		table.Append([]string{
+			result.TestCase.Description,
+			fmt.Sprintf("%d", len(result.Differences())),
+			strings.Join(slice.Sort(maps.Keys(result.Errors)), "\n"),
+		})
+	}
+	table.Render()
+	fmt.Printf("summary:\n%s\n", tableString.String())
+}
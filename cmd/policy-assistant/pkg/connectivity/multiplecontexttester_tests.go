@@ -0,0 +1,54 @@
+package connectivity
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/connectivity/probe"
+)
+
+func RunMultipleContextTesterTests() {
+	Describe("MultipleContextTestCaseResult", func() {
+		pods := []string{"x/a", "x/b"}
+
+		It("finds no differences when contexts agree", func() {
+			result := &MultipleContextTestCaseResult{
+				Contexts:   []string{"calico", "cilium"},
+				KubeProbes: map[string]*probe.Table{"calico": newTestTable(pods, "x/a->x/b"), "cilium": newTestTable(pods, "x/a->x/b")},
+			}
+			Expect(result.Differences()).To(BeEmpty())
+		})
+
+		It("finds the cells where contexts disagree", func() {
+			result := &MultipleContextTestCaseResult{
+				Contexts: []string{"calico", "cilium", "kindnet"},
+				KubeProbes: map[string]*probe.Table{
+					"calico":  newTestTable(pods, "x/a->x/b"),
+					"cilium":  newTestTable(pods, "x/a->x/b", "x/b->x/a"),
+					"kindnet": newTestTable(pods, "x/a->x/b"),
+				},
+			}
+			Expect(result.Differences()).To(Equal([]*ContextDifference{
+				{
+					From:         "x/b",
+					To:           "x/a",
+					PortProtocol: "TCP/80",
+					Results: map[string]probe.Connectivity{
+						"calico":  probe.ConnectivityAllowed,
+						"cilium":  probe.ConnectivityBlocked,
+						"kindnet": probe.ConnectivityAllowed,
+					},
+				},
+			}))
+		})
+
+		It("skips contexts which failed", func() {
+			result := &MultipleContextTestCaseResult{
+				Contexts:   []string{"calico", "cilium"},
+				KubeProbes: map[string]*probe.Table{"calico": newTestTable(pods, "x/a->x/b")},
+				Errors:     map[string]error{"cilium": errors.Errorf("unable to create pods")},
+			}
+			Expect(result.Differences()).To(BeEmpty())
+		})
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"math"
 	"strings"
+	"time"
 
 	"github.com/mattfenwick/collections/pkg/slice"
 	"github.com/sirupsen/logrus"
@@ -23,7 +24,11 @@ type Printer struct {
 	Noisy            bool
 	IgnoreLoopback   bool
 	JunitResultsFile string
-	Results          []*Result
+	HTMLReportFile   string
+	// CNI names the CNI under test in the convergence latencies
+	CNI                    string
+	ConvergenceResultsFile string
+	Results                []*Result
 }
 
 func (t *Printer) PrintSummary() {
@@ -37,9 +42,20 @@ func (t *Printer) PrintSummary() {
 	fmt.Printf("Feature results:\n%s\n\n", t.printMarkdownFeatureTable(summary.FeaturePrimaryCounts, summary.FeatureCounts))
 	fmt.Printf("Tag results:\n%s\n", t.printMarkdownFeatureTable(summary.TagPrimaryCounts, summary.TagCounts))
 
+	convergence := NewConvergenceSummaryFromResults(t.CNI, t.Results)
+	if convergence != nil {
+		fmt.Println(convergence.RenderTable())
+	}
+	if err := WriteConvergenceSummary(t.ConvergenceResultsFile, convergence); err != nil {
+		logrus.Errorf("unable to write convergence results: %+v", err)
+	}
+
 	if err := PrintJUnitResults(t.JunitResultsFile, t.Results, t.IgnoreLoopback); err != nil {
 		logrus.Errorf("unable to dump JUnit test results: %+v", err)
 	}
+	if err := PrintHTMLReport(t.HTMLReportFile, t.Results, t.IgnoreLoopback); err != nil {
+		logrus.Errorf("unable to write HTML report: %+v", err)
+	}
 }
 
 const (
@@ -105,7 +121,7 @@ func (t *Printer) printTestSummary(rows [][]string) {
 	table := tablewriter.NewWriter(tableString)
 	table.SetRowLine(true)
 
-	table.SetHeader([]string{"Test", "Result", "Step/Try", "Wrong", "Right", "Ignored", "TCP", "SCTP", "UDP"})
+	table.SetHeader([]string{"Test", "Result", "Step/Try", "Wrong", "Flaky", "Right", "Ignored", "TCP", "SCTP", "UDP"})
 
 	table.AppendBulk(rows)
 
@@ -233,19 +249,34 @@ func (t *Printer) PrintStep(i int, step *generator.TestStep, stepResult *StepRes
 	} else {
 		fmt.Println("no network policies")
 	}
+	for _, anp := range stepResult.ANPs {
+		fmt.Printf("Admin network policy:\n\n%s\n", utils.YamlString(anp))
+	}
+	if stepResult.BANP != nil {
+		fmt.Printf("Baseline admin network policy:\n\n%s\n", utils.YamlString(stepResult.BANP))
+	}
 
 	if len(stepResult.KubeProbes) == 0 {
 		panic(errors.Errorf("found 0 KubeResults for step, expected 1 or more"))
 	}
 
 	comparison := stepResult.LastComparison()
-	counts := comparison.ValueCounts(t.IgnoreLoopback)
+	counts := stepResult.ValueCounts(t.IgnoreLoopback)
 	if counts[DifferentComparison] > 0 {
 		fmt.Printf("Discrepancy found:")
+	} else if counts[FlakyComparison] > 0 {
+		fmt.Printf("Flaky results found:")
+	}
+	fmt.Printf("%d wrong, %d flaky, %d ignored, %d correct over %d tries\n", counts[DifferentComparison], counts[FlakyComparison], counts[IgnoredComparison], counts[SameComparison], len(stepResult.KubeProbes))
+	if convergence := stepResult.Convergence; convergence != nil {
+		if convergence.Converged {
+			fmt.Printf("converged after %s\n", convergence.Latency.Round(time.Millisecond))
+		} else {
+			fmt.Printf("did not converge after %s\n", convergence.Latency.Round(time.Millisecond))
+		}
 	}
-	fmt.Printf("%d wrong, %d ignored, %d correct\n", counts[DifferentComparison], counts[IgnoredComparison], counts[SameComparison])
 
-	if counts[DifferentComparison] > 0 || t.Noisy {
+	if counts[DifferentComparison] > 0 || counts[FlakyComparison] > 0 || t.Noisy {
 		fmt.Printf("Expected ingress:\n%s\n", stepResult.SimulatedProbe.RenderIngress())
 
 		fmt.Printf("Expected egress:\n%s\n", stepResult.SimulatedProbe.RenderEgress())
@@ -257,6 +288,10 @@ func (t *Printer) PrintStep(i int, step *generator.TestStep, stepResult *StepRes
 		}
 
 		fmt.Printf("\nActual vs expected (last round):\n%s\n", comparison.RenderSuccessTable())
+
+		if len(stepResult.KubeProbes) > 1 {
+			fmt.Printf("\nActual vs expected (all rounds; ~ is flaky):\n%s\n", stepResult.RenderClassificationTable(t.IgnoreLoopback))
+		}
 	} else {
 		fmt.Printf("%s\n", stepResult.LastKubeProbe().RenderTable())
 	}
@@ -1,9 +1,13 @@
 package connectivity
 
 import (
+	"os"
+	"path/filepath"
+
 	junit "github.com/jstemmer/go-junit-report/formatter"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/generator"
 )
 
 func RunPrinterTests() {
@@ -40,6 +44,23 @@ func RunPrinterTests() {
 							{Name: "test4 with\nnewlines", Failure: &junit.JUnitFailure{}},
 						},
 					},
+				}, {
+					desc: "1 flaky",
+					results: []*JUnitTestResult{
+						{Name: "test1", Passed: true},
+						{Name: "test2", Passed: true, Flaky: true},
+						{Name: "test3", Passed: false, Flaky: true},
+					},
+					junit: junit.JUnitTestSuite{
+						Failures:   1,
+						Name:       "policy-assistant",
+						Properties: []junit.JUnitProperty{{Name: "flaky", Value: "test2"}},
+						TestCases: []junit.JUnitTestCase{
+							{Name: "test1", Failure: nil},
+							{Name: "test2", Failure: nil},
+							{Name: "test3", Failure: &junit.JUnitFailure{}},
+						},
+					},
 				},
 			}
 			for _, testCase := range testCases {
@@ -48,4 +69,34 @@ func RunPrinterTests() {
 			}
 		})
 	})
+
+	Describe("HTML report", func() {
+		pods := []string{"x/a", "x/b"}
+
+		It("should highlight the wrong cells", func() {
+			step := NewStepResult(newTestTable(pods, "x/a->x/b"), nil, nil, nil, nil)
+			step.AddKubeProbe(newTestTable(pods, "x/b->x/a"))
+			results := []*Result{{
+				TestCase: generator.NewSingleStepTestCase("deny from a to b", generator.NewStringSet(generator.TagIngress), generator.NewAllAvailable(generator.ProbeModeServiceName)),
+				Steps:    []*StepResult{step},
+			}}
+
+			report := newHTMLReport(results, false)
+			Expect(report.Passed).To(Equal(0))
+			Expect(report.Failed).To(Equal(1))
+			Expect(report.TestCases[0].Steps[0].Wrong).To(Equal(2))
+			for _, table := range report.TestCases[0].Steps[0].Tables {
+				Expect(table.Rows[0].Cells[0][0].Wrong).To(BeFalse())
+				Expect(table.Rows[0].Cells[1][0].Wrong).To(BeTrue())
+				Expect(table.Rows[1].Cells[0][0].Wrong).To(BeTrue())
+			}
+
+			path := filepath.Join(GinkgoT().TempDir(), "report.html")
+			Expect(PrintHTMLReport(path, results, false)).To(Succeed())
+			html, err := os.ReadFile(path)
+			Expect(err).To(Succeed())
+			Expect(string(html)).To(ContainSubstring("deny from a to b"))
+			Expect(string(html)).To(ContainSubstring(`class="wrong"`))
+		})
+	})
 }
@@ -0,0 +1,263 @@
+package probe
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/mattfenwick/collections/pkg/slice"
+	"github.com/olekukonko/tablewriter"
+	"github.com/pkg/errors"
+	"golang.org/x/exp/maps"
+)
+
+// AggregationLevel is what pods are grouped into, to make results for many pods readable
+type AggregationLevel string
+
+const (
+	AggregationLevelPod       AggregationLevel = "pod"
+	AggregationLevelWorkload  AggregationLevel = "workload"
+	AggregationLevelNamespace AggregationLevel = "namespace"
+)
+
+var AllAggregationLevels = []string{string(AggregationLevelPod), string(AggregationLevelWorkload), string(AggregationLevelNamespace)}
+
+// Grouper returns the group of a pod, by PodString.  At the workload level, workloads maps pods to their
+// workloads, as from kube.PodWorkloads; pods which aren't in it are their own workload.
+func (level AggregationLevel) Grouper(workloads map[string]string) (func(pod string) string, error) {
+	switch level {
+	case AggregationLevelPod:
+		return func(pod string) string { return pod }, nil
+	case AggregationLevelNamespace:
+		return func(pod string) string { return PodString(pod).Namespace() }, nil
+	case AggregationLevelWorkload:
+		return func(pod string) string {
+			if workload, ok := workloads[pod]; ok {
+				return workload
+			}
+			podString := PodString(pod)
+			return fmt.Sprintf("%s/pod/%s", podString.Namespace(), podString.PodName())
+		}, nil
+	default:
+		return nil, errors.Errorf("invalid aggregation level %s; valid levels are %+v", level, AllAggregationLevels)
+	}
+}
+
+// ClassResults are the results of a probe between classes of pods, such that the results from any pod of one class to
+// any other pod of another are the same.  Consumers which group pods read them once per pair of classes, rather
+// than once per pair of pods: a Table has a class per pod, but a ClassTable may have far fewer classes than pods.
+type ClassResults interface {
+	// PodClasses returns the pods of each class, by PodString
+	PodClasses() [][]string
+	// ClassPairResults returns the results from the pods of one class to the other pods of another, by index
+	// in PodClasses, keyed by port/protocol
+	ClassPairResults(from int, to int) map[string]*JobResult
+}
+
+// classPods returns the pods of every class
+func classPods(results ClassResults) []string {
+	var pods []string
+	for _, class := range results.PodClasses() {
+		pods = append(pods, class...)
+	}
+	return pods
+}
+
+// forEachGroupPair calls f with the results of each pair of classes, once for every pair of groups their pods are in.
+// pairs is how many pairs of distinct pods there are from the pods of the first class in fromGroup to the pods of
+// the second class in toGroup; f isn't called when there are none, such as for a class of one pod to itself.
+func forEachGroupPair(results ClassResults, groupOf func(pod string) string, f func(fromGroup string, toGroup string, pairs int, jobResults map[string]*JobResult)) {
+	classes := results.PodClasses()
+	// class index -> group -> number of pods of the class in the group
+	groupCounts := make([]map[string]int, len(classes))
+	for i, pods := range classes {
+		groupCounts[i] = map[string]int{}
+		for _, pod := range pods {
+			groupCounts[i][groupOf(pod)]++
+		}
+	}
+
+	for from := range classes {
+		for to := range classes {
+			jobResults := results.ClassPairResults(from, to)
+			if len(jobResults) == 0 {
+				continue
+			}
+			for fromGroup, fromCount := range groupCounts[from] {
+				for toGroup, toCount := range groupCounts[to] {
+					pairs := fromCount * toCount
+					if from == to && fromGroup == toGroup {
+						// a pod's traffic to itself isn't counted
+						pairs -= fromCount
+					}
+					if pairs > 0 {
+						f(fromGroup, toGroup, pairs, jobResults)
+					}
+				}
+			}
+		}
+	}
+}
+
+type AggregatedVerdict string
+
+const (
+	AggregatedAllAllowed AggregatedVerdict = "all-allowed"
+	AggregatedAllBlocked AggregatedVerdict = "all-blocked"
+	AggregatedMixed      AggregatedVerdict = "mixed"
+	// AggregatedAllInvalid is for cells whose probes are all neither allowed nor blocked, i.e. because none of the
+	// destination pods has the probed named port
+	AggregatedAllInvalid AggregatedVerdict = "all-invalid"
+	// AggregatedEmpty is for groups with a single pod, whose traffic to itself isn't counted
+	AggregatedEmpty AggregatedVerdict = "empty"
+)
+
+// AggregatedCounts counts the combined results of probes.  Other is for results which are neither
+// allowed nor blocked, i.e. invalid named ports.
+type AggregatedCounts struct {
+	Allowed int
+	Blocked int
+	Other   int
+}
+
+// add counts a result for count probes
+func (c *AggregatedCounts) add(connectivity Connectivity, count int) {
+	switch connectivity {
+	case ConnectivityAllowed:
+		c.Allowed += count
+	case ConnectivityBlocked:
+		c.Blocked += count
+	default:
+		c.Other += count
+	}
+}
+
+func (c *AggregatedCounts) Total() int {
+	return c.Allowed + c.Blocked + c.Other
+}
+
+func (c *AggregatedCounts) Verdict() AggregatedVerdict {
+	switch c.Total() {
+	case 0:
+		return AggregatedEmpty
+	case c.Allowed:
+		return AggregatedAllAllowed
+	case c.Blocked:
+		return AggregatedAllBlocked
+	case c.Other:
+		return AggregatedAllInvalid
+	default:
+		return AggregatedMixed
+	}
+}
+
+func (c *AggregatedCounts) String() string {
+	switch c.Verdict() {
+	case AggregatedEmpty:
+		return "-"
+	case AggregatedAllAllowed:
+		return fmt.Sprintf("allowed (%d)", c.Total())
+	case AggregatedAllBlocked:
+		return fmt.Sprintf("blocked (%d)", c.Total())
+	case AggregatedAllInvalid:
+		return fmt.Sprintf("invalid (%d)", c.Total())
+	default:
+		return fmt.Sprintf("mixed: %d/%d allowed", c.Allowed, c.Total())
+	}
+}
+
+// AggregatedCell is the results of the probes from the pods of one group to the pods of another, in total
+// and by port/protocol.  As for simulated probes, a pod's traffic to itself isn't counted.
+type AggregatedCell struct {
+	From          string
+	To            string
+	Verdict       AggregatedVerdict
+	Counts        *AggregatedCounts
+	PortProtocols map[string]*AggregatedCounts
+}
+
+// AggregatedTable collapses the cells of a Table by namespace or by workload
+type AggregatedTable struct {
+	Level   AggregationLevel
+	Wrapped *TruthTable
+}
+
+// NewAggregatedTable collapses the results of a probe, reading them once per pair of classes
+func NewAggregatedTable(results ClassResults, level AggregationLevel, workloads map[string]string) (*AggregatedTable, error) {
+	groupOf, err := level.Grouper(workloads)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := map[string]bool{}
+	for _, pod := range classPods(results) {
+		groups[groupOf(pod)] = true
+	}
+	aggregated := &AggregatedTable{
+		Level: level,
+		Wrapped: NewTruthTableFromItems(slice.Sort(maps.Keys(groups)), func(fr, to string) interface{} {
+			return &AggregatedCell{From: fr, To: to, Counts: &AggregatedCounts{}, PortProtocols: map[string]*AggregatedCounts{}}
+		}),
+	}
+
+	forEachGroupPair(results, groupOf, func(fromGroup string, toGroup string, pairs int, jobResults map[string]*JobResult) {
+		cell := aggregated.Get(fromGroup, toGroup)
+		for portProtocol, result := range jobResults {
+			if _, ok := cell.PortProtocols[portProtocol]; !ok {
+				cell.PortProtocols[portProtocol] = &AggregatedCounts{}
+			}
+			cell.PortProtocols[portProtocol].add(result.Combined, pairs)
+			cell.Counts.add(result.Combined, pairs)
+		}
+	})
+	for _, key := range aggregated.Wrapped.Keys() {
+		cell := aggregated.Get(key.From, key.To)
+		cell.Verdict = cell.Counts.Verdict()
+	}
+	return aggregated, nil
+}
+
+func (a *AggregatedTable) Get(from string, to string) *AggregatedCell {
+	return a.Wrapped.Get(from, to).(*AggregatedCell)
+}
+
+// Cells returns every cell, ordered by from and to
+func (a *AggregatedTable) Cells() []*AggregatedCell {
+	var cells []*AggregatedCell
+	for _, key := range a.Wrapped.Keys() {
+		cells = append(cells, a.Get(key.From, key.To))
+	}
+	return cells
+}
+
+func (a *AggregatedTable) MarshalJSON() (b []byte, e error) {
+	return json.Marshal(a.Cells())
+}
+
+// RenderTable renders the verdict of each cell, with counts by port/protocol to drill into mixed cells.  Unlike
+// TruthTable.Table, it doesn't wrap cells, which would run their lines together.
+func (a *AggregatedTable) RenderTable() string {
+	tableString := &strings.Builder{}
+	table := tablewriter.NewWriter(tableString)
+	table.SetHeader(append([]string{string(a.Level)}, a.Wrapped.Tos...))
+	table.SetRowLine(true)
+	table.SetAutoWrapText(false)
+
+	for _, from := range a.Wrapped.Froms {
+		line := []string{from}
+		for _, to := range a.Wrapped.Tos {
+			cell := a.Get(from, to)
+			lines := []string{cell.Counts.String()}
+			if cell.Verdict == AggregatedMixed {
+				for _, portProtocol := range slice.Sort(maps.Keys(cell.PortProtocols)) {
+					lines = append(lines, fmt.Sprintf("%s: %s", portProtocol, cell.PortProtocols[portProtocol]))
+				}
+			}
+			line = append(line, strings.Join(lines, "\n"))
+		}
+		table.Append(line)
+	}
+
+	table.Render()
+	return tableString.String()
+}
@@ -0,0 +1,71 @@
+package probe
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/generator"
+)
+
+func RunAggregationTests() {
+	Describe("AggregatedTable", func() {
+		resources, policies := testAllow80ToBModel()
+		table := NewSimulatedRunner(policies, &JobBuilder{TimeoutSeconds: 1}).RunProbeForConfig(generator.ProbeAllAvailable, resources)
+
+		It("Should collapse cells by namespace, with counts by port/protocol", func() {
+			aggregated, err := NewAggregatedTable(table, AggregationLevelNamespace, nil)
+			Expect(err).To(BeNil())
+			Expect(aggregated.Wrapped.Froms).To(Equal([]string{"x", "y"}))
+
+			xx := aggregated.Get("x", "x")
+			Expect(xx.Verdict).To(Equal(AggregatedMixed))
+			Expect(xx.Counts).To(Equal(&AggregatedCounts{Allowed: 3, Blocked: 1}))
+			Expect(xx.PortProtocols).To(Equal(map[string]*AggregatedCounts{
+				"TCP/80": {Allowed: 2},
+				"TCP/81": {Allowed: 1, Blocked: 1},
+			}))
+
+			Expect(aggregated.Get("x", "y").Verdict).To(Equal(AggregatedAllAllowed))
+			Expect(aggregated.Get("x", "y").Counts).To(Equal(&AggregatedCounts{Allowed: 4}))
+			Expect(aggregated.Get("y", "x").Verdict).To(Equal(AggregatedMixed))
+			Expect(aggregated.Get("y", "x").Counts).To(Equal(&AggregatedCounts{Allowed: 2, Blocked: 2}))
+			// y/c's traffic to itself isn't counted
+			Expect(aggregated.Get("y", "y").Verdict).To(Equal(AggregatedEmpty))
+
+			rendered := aggregated.RenderTable()
+			Expect(rendered).To(ContainSubstring("mixed: 3/4 allowed"))
+			Expect(rendered).To(ContainSubstring("TCP/81: mixed: 1/2 allowed"))
+			Expect(rendered).To(ContainSubstring("allowed (4)"))
+		})
+
+		It("Should collapse cells by workload", func() {
+			aggregated, err := NewAggregatedTable(table, AggregationLevelWorkload, map[string]string{"x/b": "x/deployment/web", "y/c": "y/deployment/web"})
+			Expect(err).To(BeNil())
+			Expect(aggregated.Wrapped.Froms).To(Equal([]string{"x/deployment/web", "x/pod/a", "y/deployment/web"}))
+			Expect(aggregated.Get("y/deployment/web", "x/deployment/web").Verdict).To(Equal(AggregatedAllBlocked))
+			Expect(aggregated.Get("y/deployment/web", "x/deployment/web").Counts).To(Equal(&AggregatedCounts{Blocked: 2}))
+			Expect(aggregated.Get("x/pod/a", "x/deployment/web").Verdict).To(Equal(AggregatedMixed))
+		})
+
+		It("Should tell cells whose probes are all invalid apart from mixed ones", func() {
+			resources, policies := testAllow80ToBModel()
+			// y/c doesn't serve the probed named port
+			resources.Pods[2].Containers = resources.Pods[2].Containers[:1]
+			namedPort := generator.NewProbeConfig(intstr.FromString("serve-81-tcp"), v1.ProtocolTCP, generator.ProbeModeServiceName)
+			table := NewSimulatedRunner(policies, &JobBuilder{TimeoutSeconds: 1}).RunProbeForConfig(namedPort, resources)
+
+			aggregated, err := NewAggregatedTable(table, AggregationLevelNamespace, nil)
+			Expect(err).To(BeNil())
+			Expect(aggregated.Get("x", "y").Verdict).To(Equal(AggregatedAllInvalid))
+			Expect(aggregated.Get("x", "y").Counts).To(Equal(&AggregatedCounts{Other: 2}))
+			Expect(aggregated.Get("x", "x").Verdict).To(Equal(AggregatedMixed))
+			Expect(aggregated.RenderTable()).To(ContainSubstring("invalid (2)"))
+		})
+
+		It("Should reject unknown levels", func() {
+			_, err := NewAggregatedTable(table, AggregationLevel("cluster"), nil)
+			Expect(err).ToNot(BeNil())
+		})
+	})
+}
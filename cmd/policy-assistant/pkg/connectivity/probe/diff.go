@@ -0,0 +1,56 @@
+package probe
+
+import (
+	"fmt"
+	"sort"
+
+	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/matcher"
+)
+
+// VerdictChange is a flow which is allowed by one set of policies and denied by the other
+type VerdictChange struct {
+	From         string
+	To           string
+	PortProtocol string
+	Before       *matcher.AllowedResult
+	After        *matcher.AllowedResult
+}
+
+// DiffVerdicts evaluates each job against both sets of policies, and returns the jobs whose verdict changed,
+// sorted by source, destination and port/protocol.
+// As for simulated probes, jobs from a pod to itself are skipped.
+func DiffVerdicts(before *matcher.Policy, after *matcher.Policy, jobs []*Job) []*VerdictChange {
+	seen := map[string]bool{}
+	var changes []*VerdictChange
+	for _, job := range jobs {
+		if job.FromKey == job.ToKey || seen[job.Key()] {
+			continue
+		}
+		seen[job.Key()] = true
+
+		traffic := job.Traffic()
+		beforeResult, afterResult := before.IsTrafficAllowed(traffic), after.IsTrafficAllowed(traffic)
+		if beforeResult.IsAllowed() == afterResult.IsAllowed() {
+			continue
+		}
+		changes = append(changes, &VerdictChange{
+			From:         job.FromKey,
+			To:           job.ToKey,
+			PortProtocol: fmt.Sprintf("%s/%d", job.Protocol, job.ResolvedPort),
+			Before:       beforeResult,
+			After:        afterResult,
+		})
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		a, b := changes[i], changes[j]
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		if a.To != b.To {
+			return a.To < b.To
+		}
+		return a.PortProtocol < b.PortProtocol
+	})
+	return changes
+}
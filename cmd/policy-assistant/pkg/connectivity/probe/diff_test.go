@@ -0,0 +1,37 @@
+package probe
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	networkingv1 "k8s.io/api/networking/v1"
+	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/generator"
+	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/matcher"
+)
+
+func RunDiffTests() {
+	Describe("DiffVerdicts", func() {
+		It("Should list the flows whose verdict changed", func() {
+			resources := testResources("x/a", "x/b")
+
+			before := matcher.BuildV1AndV2NetPols(true, nil, nil, nil)
+			after := matcher.BuildV1AndV2NetPols(true, []*networkingv1.NetworkPolicy{testAllow80ToB()}, nil, nil)
+			jobs := (&JobBuilder{TimeoutSeconds: 1}).GetJobsForProbeConfig(resources, generator.ProbeAllAvailable).Valid
+
+			Expect(DiffVerdicts(before, before, jobs)).To(BeEmpty())
+
+			changes := DiffVerdicts(before, after, jobs)
+			Expect(changes).To(HaveLen(1))
+			Expect(changes[0].From).To(Equal("x/a"))
+			Expect(changes[0].To).To(Equal("x/b"))
+			Expect(changes[0].PortProtocol).To(Equal("TCP/81"))
+			Expect(changes[0].Before.Verdict()).To(Equal("Allowed"))
+			Expect(changes[0].After.Verdict()).To(Equal("Denied"))
+			Expect(changes[0].After.Ingress.Flow()).To(Equal("[NPv1] Dropped (x/allow-80-to-b)"))
+
+			reverted := DiffVerdicts(after, before, jobs)
+			Expect(reverted).To(HaveLen(1))
+			Expect(reverted[0].Before.Verdict()).To(Equal("Denied"))
+			Expect(reverted[0].After.Verdict()).To(Equal("Allowed"))
+		})
+	})
+}
@@ -0,0 +1,167 @@
+package probe
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/generator"
+	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/matcher"
+)
+
+// PodClass is a set of pods which no policy can tell apart: they have the same namespace, namespace labels,
+// pod labels and serving ports -- and the same IP, if policies select peers by IP.  Traffic between any two
+// pods of two classes therefore has the same verdict as traffic between any other two.
+type PodClass struct {
+	ID   int
+	Key  string
+	Pods []*Pod
+}
+
+// PodClasses partitions the pods of a model into PodClasses
+type PodClasses struct {
+	Classes []*PodClass
+	classOf map[string]*PodClass
+}
+
+// NewPodClasses partitions pods, keeping their order: classes are ordered by their first pods
+func NewPodClasses(resources *Resources, byIP bool) *PodClasses {
+	classes := &PodClasses{classOf: map[string]*PodClass{}}
+	byKey := map[string]*PodClass{}
+	for _, pod := range resources.Pods {
+		key := podClassKey(pod, resources.Namespaces[pod.Namespace], byIP)
+		class, ok := byKey[key]
+		if !ok {
+			class = &PodClass{ID: len(classes.Classes), Key: key}
+			byKey[key] = class
+			classes.Classes = append(classes.Classes, class)
+		}
+		class.Pods = append(class.Pods, pod)
+		classes.classOf[pod.PodString().String()] = class
+	}
+	return classes
+}
+
+func podClassKey(pod *Pod, namespaceLabels map[string]string, byIP bool) string {
+	var ports []string
+	for _, cont := range pod.Containers {
+		ports = append(ports, fmt.Sprintf("%s/%d/%s", cont.Protocol, cont.Port, cont.PortName))
+	}
+	key := fmt.Sprintf("%s|%s|%s|%s", pod.Namespace, labels.Set(namespaceLabels).String(), labels.Set(pod.Labels).String(), strings.Join(ports, ","))
+	if byIP {
+		key += "|" + pod.IP
+	}
+	return key
+}
+
+// ClassOf returns the class of a pod, by PodString
+func (p *PodClasses) ClassOf(pod string) *PodClass {
+	return p.classOf[pod]
+}
+
+// EquivalenceClassEngine simulates probes like a SimulatedJobRunner, but evaluates policies once per pair of
+// PodClasses instead of once per pair of pods, which scales to models with thousands of pods
+type EquivalenceClassEngine struct {
+	Policies   *matcher.Policy
+	JobBuilder *JobBuilder
+}
+
+func NewEquivalenceClassEngine(policies *matcher.Policy, jobBuilder *JobBuilder) *EquivalenceClassEngine {
+	return &EquivalenceClassEngine{Policies: policies, JobBuilder: jobBuilder}
+}
+
+// ClassTable holds the results of a probe between each pair of PodClasses, keyed by port/protocol
+type ClassTable struct {
+	Classes *PodClasses
+	// from class ID -> to class ID -> port/protocol -> result for a representative pair of pods
+	Results [][]map[string]*JobResult
+
+	resources  *Resources
+	config     *generator.ProbeConfig
+	jobBuilder *JobBuilder
+}
+
+func (e *EquivalenceClassEngine) RunProbeForConfig(probeConfig *generator.ProbeConfig, resources *Resources) *ClassTable {
+	classes := NewPodClasses(resources, e.Policies.MatchesPeersByIP())
+	runner := &Runner{JobRunner: &SimulatedJobRunner{Policies: e.Policies}, JobBuilder: e.JobBuilder}
+	table := &ClassTable{
+		Classes:    classes,
+		Results:    make([][]map[string]*JobResult, len(classes.Classes)),
+		resources:  resources,
+		config:     probeConfig,
+		jobBuilder: e.JobBuilder,
+	}
+	for _, from := range classes.Classes {
+		table.Results[from.ID] = make([]map[string]*JobResult, len(classes.Classes))
+		for _, to := range classes.Classes {
+			podFrom, podTo := from.Pods[0], to.Pods[0]
+			if from == to {
+				// a pod's traffic to itself is undefined, so only classes of several pods have traffic within them
+				if len(from.Pods) < 2 {
+					continue
+				}
+				podTo = from.Pods[1]
+			}
+			results := map[string]*JobResult{}
+			for _, result := range runner.runProbe(e.JobBuilder.GetJobsForPodPair(resources, podFrom, podTo, probeConfig)) {
+				results[result.Key()] = result
+			}
+			table.Results[from.ID][to.ID] = results
+		}
+	}
+	return table
+}
+
+// Get returns the results of the probe from one pod to another, by PodString.  Their Jobs are for the
+// representative pods of the pods' classes.
+func (c *ClassTable) Get(from string, to string) map[string]*JobResult {
+	return c.Results[c.Classes.ClassOf(from).ID][c.Classes.ClassOf(to).ID]
+}
+
+// PodClasses returns the pods of each class, by PodString, indexed by class ID: see ClassResults
+func (c *ClassTable) PodClasses() [][]string {
+	classes := make([][]string, len(c.Classes.Classes))
+	for i, class := range c.Classes.Classes {
+		for _, pod := range class.Pods {
+			classes[i] = append(classes[i], pod.PodString().String())
+		}
+	}
+	return classes
+}
+
+// ClassPairResults returns the results between two classes, by ID: see ClassResults
+func (c *ClassTable) ClassPairResults(from int, to int) map[string]*JobResult {
+	return c.Results[from][to]
+}
+
+// Table expands the results into a Table, which has a cell for every pair of pods.  It doesn't evaluate or build
+// jobs for every pair: cells share the results of their pair of classes, whose Jobs are for the representative
+// pods.  Consumers which group pods don't need a Table: see ClassResults.
+func (c *ClassTable) Table() *Table {
+	selfResults := map[string]map[string]*JobResult{}
+	for _, pod := range c.resources.Pods {
+		selfResults[pod.PodString().String()] = c.selfResults(pod)
+	}
+	return &Table{Wrapped: NewTruthTableFromItems(c.resources.SortedPodNames(), func(fr, to string) interface{} {
+		results := selfResults[fr]
+		if fr != to {
+			results = c.Get(fr, to)
+		}
+		return &Item{From: fr, To: to, JobResults: results}
+	})}
+}
+
+// selfResults returns the results of the probe from a pod to itself, as from a SimulatedJobRunner: undefined, as
+// policies don't apply to it, unless its jobs are invalid
+func (c *ClassTable) selfResults(pod *Pod) map[string]*JobResult {
+	jobs := c.jobBuilder.GetJobsForPodPair(c.resources, pod, pod, c.config)
+	results := map[string]*JobResult{}
+	for _, job := range jobs.Valid {
+		connUndefined := ConnectivityUndefined
+		results[job.PortProtocolKey()] = &JobResult{Job: job, Ingress: &connUndefined, Egress: &connUndefined, Combined: ConnectivityUndefined}
+	}
+	for _, result := range invalidJobResults(jobs) {
+		results[result.Key()] = result
+	}
+	return results
+}
@@ -0,0 +1,84 @@
+package probe
+
+import (
+	"fmt"
+	"testing"
+
+	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/generator"
+	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/matcher"
+)
+
+// the synthetic models have 20 namespaces of 10 apps: 200 classes of pods
+const (
+	benchmarkNamespaces = 20
+	benchmarkApps       = 10
+)
+
+func benchmarkModel(pods int) (*Resources, *matcher.Policy) {
+	resources, netpols, anps := syntheticModel(pods, benchmarkNamespaces, benchmarkApps)
+	return resources, matcher.BuildV1AndV2NetPols(true, netpols, anps, nil)
+}
+
+// BenchmarkSimulatedRunner simulates every pair of pods.  10k pods are out of its reach: see
+// BenchmarkSimulatedRunnerFromOnePod.
+func BenchmarkSimulatedRunner(b *testing.B) {
+	resources, policies := benchmarkModel(500)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		NewSimulatedRunner(policies, &JobBuilder{TimeoutSeconds: 1}).RunProbeForConfig(generator.ProbeAllAvailable, resources)
+	}
+}
+
+// BenchmarkSimulatedRunnerFromOnePod simulates the traffic from a single pod to every pod of a 10k-pod
+// model: simulating the whole model takes 10k times as long
+func BenchmarkSimulatedRunnerFromOnePod(b *testing.B) {
+	resources, policies := benchmarkModel(10_000)
+	runner := &SimulatedJobRunner{Policies: policies}
+	jobBuilder := &JobBuilder{TimeoutSeconds: 1}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, podTo := range resources.Pods {
+			runner.RunJobs(jobBuilder.GetJobsForPodPair(resources, resources.Pods[0], podTo, generator.ProbeAllAvailable).Valid)
+		}
+	}
+}
+
+func BenchmarkEquivalenceClassEngine(b *testing.B) {
+	b.Run("pods=500,expanded", func(b *testing.B) {
+		resources, policies := benchmarkModel(500)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			NewEquivalenceClassEngine(policies, &JobBuilder{TimeoutSeconds: 1}).RunProbeForConfig(generator.ProbeAllAvailable, resources).Table()
+		}
+	})
+	// a Table of 10k pods has 100M cells, so this only evaluates class pairs
+	for _, pods := range []int{1_000, 10_000} {
+		b.Run(fmt.Sprintf("pods=%d", pods), func(b *testing.B) {
+			resources, policies := benchmarkModel(pods)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				NewEquivalenceClassEngine(policies, &JobBuilder{TimeoutSeconds: 1}).RunProbeForConfig(generator.ProbeAllAvailable, resources)
+			}
+		})
+	}
+}
+
+// BenchmarkSyntheticProbeByNamespace simulates a probe and reads it as the aggregated table and the graph do,
+// once per pair of classes: unlike a Table, this stays linear in the number of pods for a given number of classes
+func BenchmarkSyntheticProbeByNamespace(b *testing.B) {
+	for _, pods := range []int{1_000, 10_000} {
+		b.Run(fmt.Sprintf("pods=%d", pods), func(b *testing.B) {
+			resources, policies := benchmarkModel(pods)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				classTable := NewEquivalenceClassEngine(policies, &JobBuilder{TimeoutSeconds: 1}).RunProbeForConfig(generator.ProbeAllAvailable, resources)
+				if _, err := NewAggregatedTable(classTable, AggregationLevelNamespace, nil); err != nil {
+					b.Fatal(err)
+				}
+				if _, err := NewReachabilityGraph(classTable, AggregationLevelNamespace, nil); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
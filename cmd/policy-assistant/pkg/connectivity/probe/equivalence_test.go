@@ -0,0 +1,147 @@
+package probe
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/network-policy-api/apis/v1alpha1"
+	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/generator"
+	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/matcher"
+)
+
+// syntheticModel spreads pods over namespaces, each pod running one of a number of apps per namespace.  In each
+// namespace, ingress is denied except from each app to the next one on port 80; and an ANP denies egress on
+// port 81 from odd to even namespaces.
+func syntheticModel(pods int, namespaces int, apps int) (*Resources, []*networkingv1.NetworkPolicy, []*v1alpha1.AdminNetworkPolicy) {
+	resources := &Resources{Namespaces: map[string]map[string]string{}}
+	var policies []*networkingv1.NetworkPolicy
+	port80 := intstr.FromInt(80)
+	for i := 0; i < namespaces; i++ {
+		ns := fmt.Sprintf("ns-%d", i)
+		resources.Namespaces[ns] = map[string]string{"ns": ns, "parity": []string{"even", "odd"}[i%2]}
+		policies = append(policies, &networkingv1.NetworkPolicy{
+			ObjectMeta: metav1.ObjectMeta{Namespace: ns, Name: "deny-all"},
+			Spec:       networkingv1.NetworkPolicySpec{PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress}},
+		})
+		for j := 1; j < apps; j++ {
+			policies = append(policies, &networkingv1.NetworkPolicy{
+				ObjectMeta: metav1.ObjectMeta{Namespace: ns, Name: fmt.Sprintf("allow-to-app-%d", j)},
+				Spec: networkingv1.NetworkPolicySpec{
+					PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{"app": fmt.Sprintf("app-%d", j)}},
+					Ingress: []networkingv1.NetworkPolicyIngressRule{{
+						Ports: []networkingv1.NetworkPolicyPort{{Port: &port80}},
+						From:  []networkingv1.NetworkPolicyPeer{{PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": fmt.Sprintf("app-%d", j-1)}}}},
+					}},
+					PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+				},
+			})
+		}
+	}
+	for i := 0; i < pods; i++ {
+		resources.Pods = append(resources.Pods, NewPod(
+			fmt.Sprintf("ns-%d", i%namespaces),
+			fmt.Sprintf("pod-%d", i),
+			map[string]string{"app": fmt.Sprintf("app-%d", (i/namespaces)%apps)},
+			fmt.Sprintf("10.%d.%d.%d", (i>>16)&255, (i>>8)&255, i&255),
+			[]*Container{
+				{Name: "cont-80-tcp", Port: 80, Protocol: v1.ProtocolTCP, PortName: "serve-80-tcp"},
+				{Name: "cont-81-tcp", Port: 81, Protocol: v1.ProtocolTCP, PortName: "serve-81-tcp"},
+			}))
+	}
+	anp := &v1alpha1.AdminNetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "deny-odd-to-even-81"},
+		Spec: v1alpha1.AdminNetworkPolicySpec{
+			Priority: 10,
+			Subject:  v1alpha1.AdminNetworkPolicySubject{Namespaces: &metav1.LabelSelector{MatchLabels: map[string]string{"parity": "odd"}}},
+			Egress: []v1alpha1.AdminNetworkPolicyEgressRule{{
+				Name:   "deny-81",
+				Action: v1alpha1.AdminNetworkPolicyRuleActionDeny,
+				To:     []v1alpha1.AdminNetworkPolicyEgressPeer{{Namespaces: &metav1.LabelSelector{MatchLabels: map[string]string{"parity": "even"}}}},
+				Ports:  &[]v1alpha1.AdminNetworkPolicyPort{{PortNumber: &v1alpha1.Port{Protocol: v1.ProtocolTCP, Port: 81}}},
+			}},
+		},
+	}
+	return resources, policies, []*v1alpha1.AdminNetworkPolicy{anp}
+}
+
+func RunEquivalenceClassTests() {
+	Describe("EquivalenceClassEngine", func() {
+		jobBuilder := &JobBuilder{TimeoutSeconds: 1}
+		configs := []*generator.ProbeConfig{
+			generator.ProbeAllAvailable,
+			generator.NewProbeConfig(intstr.FromString("serve-81-tcp"), v1.ProtocolTCP, generator.ProbeModeServiceName),
+			generator.NewProbeConfig(intstr.FromInt(81), v1.ProtocolTCP, generator.ProbeModeServiceName),
+		}
+		expectSameTables := func(policies *matcher.Policy, resources *Resources) {
+			for _, config := range configs {
+				expected := NewSimulatedRunner(policies, jobBuilder).RunProbeForConfig(config, resources)
+				classTable := NewEquivalenceClassEngine(policies, jobBuilder).RunProbeForConfig(config, resources)
+				actual := classTable.Table()
+				Expect(actual.RenderIngress()).To(Equal(expected.RenderIngress()))
+				Expect(actual.RenderEgress()).To(Equal(expected.RenderEgress()))
+				Expect(actual.RenderTable()).To(Equal(expected.RenderTable()))
+
+				// consumers which group pods read the class pairs, without expanding them
+				for _, level := range []AggregationLevel{AggregationLevelPod, AggregationLevelNamespace} {
+					expectedAggregated, err := NewAggregatedTable(expected, level, nil)
+					Expect(err).To(BeNil())
+					actualAggregated, err := NewAggregatedTable(classTable, level, nil)
+					Expect(err).To(BeNil())
+					Expect(actualAggregated.Cells()).To(Equal(expectedAggregated.Cells()))
+
+					expectedGraph, err := NewReachabilityGraph(expected, level, nil)
+					Expect(err).To(BeNil())
+					actualGraph, err := NewReachabilityGraph(classTable, level, nil)
+					Expect(err).To(BeNil())
+					Expect(actualGraph).To(Equal(expectedGraph))
+				}
+			}
+		}
+
+		It("Should partition pods by namespace, labels and serving ports", func() {
+			resources, _, _ := syntheticModel(24, 2, 3)
+			// a pod of an existing class, but without port 81
+			resources.Pods = append(resources.Pods, NewPod("ns-0", "pod-no-81", map[string]string{"app": "app-0"}, "10.1.0.0",
+				[]*Container{{Name: "cont-80-tcp", Port: 80, Protocol: v1.ProtocolTCP, PortName: "serve-80-tcp"}}))
+
+			classes := NewPodClasses(resources, false)
+			Expect(classes.Classes).To(HaveLen(7))
+			Expect(classes.Classes[0].Pods).To(HaveLen(4))
+			Expect(classes.ClassOf("ns-0/pod-0")).To(Equal(classes.ClassOf("ns-0/pod-6")))
+			Expect(classes.ClassOf("ns-0/pod-0")).ToNot(Equal(classes.ClassOf("ns-1/pod-1")))
+			Expect(classes.ClassOf("ns-0/pod-no-81").Pods).To(HaveLen(1))
+
+			Expect(NewPodClasses(resources, true).Classes).To(HaveLen(len(resources.Pods)))
+		})
+
+		It("Should give the same results as simulating every pair of pods", func() {
+			resources, netpols, anps := syntheticModel(24, 2, 3)
+			resources.Pods = append(resources.Pods, NewPod("ns-0", "pod-no-81", map[string]string{"app": "app-0"}, "10.1.0.0",
+				[]*Container{{Name: "cont-80-tcp", Port: 80, Protocol: v1.ProtocolTCP, PortName: "serve-80-tcp"}}))
+			policies := matcher.BuildV1AndV2NetPols(true, netpols, anps, nil)
+			Expect(policies.MatchesPeersByIP()).To(BeFalse())
+
+			expectSameTables(policies, resources)
+		})
+
+		It("Should tell pods apart by IP when policies select peers by IP", func() {
+			resources, netpols, anps := syntheticModel(24, 2, 3)
+			anps[0].Spec.Egress = append(anps[0].Spec.Egress, v1alpha1.AdminNetworkPolicyEgressRule{
+				Name:   "deny-some-pods",
+				Action: v1alpha1.AdminNetworkPolicyRuleActionDeny,
+				To:     []v1alpha1.AdminNetworkPolicyEgressPeer{{Networks: []v1alpha1.CIDR{"10.0.0.0/29"}}},
+			})
+			policies := matcher.BuildV1AndV2NetPols(true, netpols, anps, nil)
+			Expect(policies.MatchesPeersByIP()).To(BeTrue())
+
+			classTable := NewEquivalenceClassEngine(policies, jobBuilder).RunProbeForConfig(generator.ProbeAllAvailable, resources)
+			Expect(classTable.Classes.Classes).To(HaveLen(len(resources.Pods)))
+			expectSameTables(policies, resources)
+		})
+	})
+}
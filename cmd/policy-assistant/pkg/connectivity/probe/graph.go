@@ -0,0 +1,189 @@
+package probe
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/mattfenwick/collections/pkg/slice"
+	"github.com/pkg/errors"
+	"golang.org/x/exp/maps"
+	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/matcher"
+)
+
+const (
+	GraphFormatDOT     = "dot"
+	GraphFormatMermaid = "mermaid"
+	GraphFormatJSON    = "json"
+)
+
+var AllGraphFormats = []string{GraphFormatDOT, GraphFormatMermaid, GraphFormatJSON}
+
+// graphDefaultPolicyKind labels flows which no policy decided, and which are therefore allowed by default
+const graphDefaultPolicyKind = "default"
+
+// ReachabilityGraph has an edge between two nodes if traffic is allowed between some of their pods
+type ReachabilityGraph struct {
+	Level AggregationLevel
+	Nodes []*GraphNode
+	Edges []*GraphEdge
+}
+
+// GraphNode is a pod, or the pods of a workload or of a namespace
+type GraphNode struct {
+	ID   string
+	Pods []string
+}
+
+// GraphEdge is the traffic allowed from the pods of one node to the pods of another
+type GraphEdge struct {
+	From  string
+	To    string
+	Flows []*GraphFlow
+}
+
+// GraphFlow is the traffic allowed on one port/protocol.  PolicyKinds are the kinds of the policies which
+// decided to allow it, in either direction, or "default" if none did.  Count is how many pod pairs it
+// is allowed between.
+type GraphFlow struct {
+	PortProtocol string
+	PolicyKinds  []string
+	Count        int
+}
+
+func (f *GraphFlow) Label() string {
+	return fmt.Sprintf("%s (%s)", f.PortProtocol, strings.Join(f.PolicyKinds, "+"))
+}
+
+// NewReachabilityGraph builds a graph from a simulated probe, whose nodes are pods grouped at level.  It reads the
+// results once per pair of classes.
+func NewReachabilityGraph(results ClassResults, level AggregationLevel, workloads map[string]string) (*ReachabilityGraph, error) {
+	nodeOf, err := level.Grouper(workloads)
+	if err != nil {
+		return nil, err
+	}
+
+	nodePods := map[string][]string{}
+	for _, pod := range slice.Sort(classPods(results)) {
+		nodePods[nodeOf(pod)] = append(nodePods[nodeOf(pod)], pod)
+	}
+	graph := &ReachabilityGraph{Level: level}
+	for _, id := range slice.Sort(maps.Keys(nodePods)) {
+		graph.Nodes = append(graph.Nodes, &GraphNode{ID: id, Pods: nodePods[id]})
+	}
+
+	// from node -> to node -> port/protocol -> flow
+	flows := map[string]map[string]map[string]*GraphFlow{}
+	kinds := map[*GraphFlow]map[string]bool{}
+	// policies don't apply to a pod's traffic to itself, which forEachGroupPair skips
+	forEachGroupPair(results, nodeOf, func(from string, to string, pairs int, jobResults map[string]*JobResult) {
+		for portProtocol, result := range jobResults {
+			if result.Combined != ConnectivityAllowed {
+				continue
+			}
+			if _, ok := flows[from]; !ok {
+				flows[from] = map[string]map[string]*GraphFlow{}
+			}
+			if _, ok := flows[from][to]; !ok {
+				flows[from][to] = map[string]*GraphFlow{}
+			}
+			flow, ok := flows[from][to][portProtocol]
+			if !ok {
+				flow = &GraphFlow{PortProtocol: portProtocol}
+				flows[from][to][portProtocol] = flow
+				kinds[flow] = map[string]bool{}
+			}
+			flow.Count += pairs
+			for _, kind := range decidingPolicyKinds(result) {
+				kinds[flow][kind] = true
+			}
+		}
+	})
+
+	for _, from := range slice.Sort(maps.Keys(flows)) {
+		for _, to := range slice.Sort(maps.Keys(flows[from])) {
+			edge := &GraphEdge{From: from, To: to}
+			for _, portProtocol := range slice.Sort(maps.Keys(flows[from][to])) {
+				flow := flows[from][to][portProtocol]
+				flow.PolicyKinds = slice.Sort(maps.Keys(kinds[flow]))
+				edge.Flows = append(edge.Flows, flow)
+			}
+			graph.Edges = append(graph.Edges, edge)
+		}
+	}
+	return graph, nil
+}
+
+func decidingPolicyKinds(result *JobResult) []string {
+	if result.Allowed == nil {
+		return []string{graphDefaultPolicyKind}
+	}
+	var kinds []string
+	for _, direction := range []matcher.DirectionResult{result.Allowed.Ingress, result.Allowed.Egress} {
+		if kind, ok := direction.DecidingPolicyKind(); ok {
+			kinds = append(kinds, string(kind))
+		}
+	}
+	if len(kinds) == 0 {
+		return []string{graphDefaultPolicyKind}
+	}
+	return kinds
+}
+
+func (g *ReachabilityGraph) Render(format string) (string, error) {
+	switch format {
+	case GraphFormatDOT:
+		return g.RenderDOT(), nil
+	case GraphFormatMermaid:
+		return g.RenderMermaid(), nil
+	case GraphFormatJSON:
+		bytes, err := json.MarshalIndent(g, "", "  ")
+		return string(bytes), errors.Wrapf(err, "unable to marshal graph")
+	default:
+		return "", errors.Errorf("invalid graph format %s; valid formats are %+v", format, AllGraphFormats)
+	}
+}
+
+func (g *ReachabilityGraph) edgeLabel(edge *GraphEdge) string {
+	var labels []string
+	for _, flow := range edge.Flows {
+		labels = append(labels, flow.Label())
+	}
+	return strings.Join(labels, "\n")
+}
+
+// RenderDOT renders the graph in Graphviz's DOT language
+func (g *ReachabilityGraph) RenderDOT() string {
+	str := &strings.Builder{}
+	str.WriteString("digraph reachability {\n")
+	str.WriteString("  node [shape=box];\n")
+	for _, node := range g.Nodes {
+		str.WriteString(fmt.Sprintf("  %q;\n", node.ID))
+	}
+	for _, edge := range g.Edges {
+		str.WriteString(fmt.Sprintf("  %q -> %q [label=%q];\n", edge.From, edge.To, g.edgeLabel(edge)))
+	}
+	str.WriteString("}\n")
+	return str.String()
+}
+
+// RenderMermaid renders the graph as a Mermaid flowchart.  Node ids are generated, since Mermaid ids can't
+// contain slashes.
+func (g *ReachabilityGraph) RenderMermaid() string {
+	ids := map[string]string{}
+	str := &strings.Builder{}
+	str.WriteString("flowchart LR\n")
+	for i, node := range g.Nodes {
+		ids[node.ID] = fmt.Sprintf("n%d", i)
+		str.WriteString(fmt.Sprintf("  %s[\"%s\"]\n", ids[node.ID], mermaidEscape(node.ID)))
+	}
+	for _, edge := range g.Edges {
+		label := mermaidEscape(strings.ReplaceAll(g.edgeLabel(edge), "\n", "<br/>"))
+		str.WriteString(fmt.Sprintf("  %s -->|\"%s\"| %s\n", ids[edge.From], label, ids[edge.To]))
+	}
+	return str.String()
+}
+
+func mermaidEscape(s string) string {
+	return strings.ReplaceAll(s, "\"", "#quot;")
+}
@@ -0,0 +1,59 @@
+package probe
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/generator"
+)
+
+func RunGraphTests() {
+	Describe("ReachabilityGraph", func() {
+		resources, policies := testAllow80ToBModel()
+		table := NewSimulatedRunner(policies, &JobBuilder{TimeoutSeconds: 1}).RunProbeForConfig(generator.ProbeAllAvailable, resources)
+
+		It("Should label allowed flows with the deciding policy kinds", func() {
+			graph, err := NewReachabilityGraph(table, AggregationLevelPod, nil)
+			Expect(err).To(BeNil())
+			Expect(graph.Nodes).To(HaveLen(3))
+
+			var toB *GraphEdge
+			for _, edge := range graph.Edges {
+				Expect(edge.From).ToNot(Equal(edge.To))
+				if edge.To == "x/b" {
+					Expect(edge.From).ToNot(Equal("y/c"))
+					toB = edge
+				}
+			}
+			Expect(toB).ToNot(BeNil())
+			Expect(toB.Flows).To(Equal([]*GraphFlow{{PortProtocol: "TCP/80", PolicyKinds: []string{"NPv1"}, Count: 1}}))
+
+			Expect(graph.RenderDOT()).To(ContainSubstring(`"x/a" -> "x/b" [label="TCP/80 (NPv1)"];`))
+			Expect(graph.RenderMermaid()).To(ContainSubstring(`n0 -->|"TCP/80 (NPv1)"| n1`))
+		})
+
+		It("Should aggregate pods into namespaces and workloads", func() {
+			graph, err := NewReachabilityGraph(table, AggregationLevelNamespace, nil)
+			Expect(err).To(BeNil())
+			Expect(graph.Nodes).To(Equal([]*GraphNode{{ID: "x", Pods: []string{"x/a", "x/b"}}, {ID: "y", Pods: []string{"y/c"}}}))
+			Expect(graph.Edges[0].From).To(Equal("x"))
+			Expect(graph.Edges[0].To).To(Equal("x"))
+			Expect(graph.Edges[0].Flows).To(Equal([]*GraphFlow{
+				{PortProtocol: "TCP/80", PolicyKinds: []string{"NPv1", "default"}, Count: 2},
+				{PortProtocol: "TCP/81", PolicyKinds: []string{"default"}, Count: 1},
+			}))
+
+			graph, err = NewReachabilityGraph(table, AggregationLevelWorkload, map[string]string{"x/a": "x/deployment/web", "x/b": "x/deployment/web"})
+			Expect(err).To(BeNil())
+			Expect(graph.Nodes[0].ID).To(Equal("x/deployment/web"))
+			Expect(graph.Nodes[1].ID).To(Equal("y/pod/c"))
+
+			rendered, err := graph.Render(GraphFormatJSON)
+			Expect(err).To(BeNil())
+			parsed := &ReachabilityGraph{}
+			Expect(json.Unmarshal([]byte(rendered), parsed)).To(Succeed())
+			Expect(parsed).To(Equal(graph))
+		})
+	})
+}
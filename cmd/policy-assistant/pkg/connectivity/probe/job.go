@@ -21,10 +21,12 @@ type JobResult struct {
 	Ingress  *Connectivity
 	Egress   *Connectivity
 	Combined Connectivity
+	// Allowed is only set for simulated jobs: it explains the verdict
+	Allowed *matcher.AllowedResult `json:"-"`
 }
 
 func (jr *JobResult) Key() string {
-	return fmt.Sprintf("%s/%d", jr.Job.Protocol, jr.Job.ResolvedPort)
+	return jr.Job.PortProtocolKey()
 }
 
 type Job struct {
@@ -55,6 +57,11 @@ func (j *Job) Key() string {
 	return fmt.Sprintf("%s/%s/%s/%s/%s/%d", j.FromKey, j.FromContainer, j.ToKey, j.ToContainer, j.Protocol, j.ResolvedPort)
 }
 
+// PortProtocolKey is the key of the job's result within a Table cell
+func (j *Job) PortProtocolKey() string {
+	return fmt.Sprintf("%s/%d", j.Protocol, j.ResolvedPort)
+}
+
 func (j *Job) ToAddress() string {
 	return net.JoinHostPort(j.ToHost, strconv.Itoa(j.ResolvedPort))
 }
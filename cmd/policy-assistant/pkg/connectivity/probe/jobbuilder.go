@@ -21,86 +21,107 @@ func (j *JobBuilder) GetJobsForProbeConfig(resources *Resources, config *generat
 	}
 }
 
+// GetJobsForPodPair returns the jobs of a probe from one pod to another
+func (j *JobBuilder) GetJobsForPodPair(resources *Resources, podFrom *Pod, podTo *Pod, config *generator.ProbeConfig) *Jobs {
+	jobs := &Jobs{}
+	if config.AllAvailable {
+		j.addJobsAllAvailableServers(jobs, resources, podFrom, podTo, config.Mode)
+	} else if config.PortProtocol != nil {
+		j.addJobsForNamedPortProtocol(jobs, resources, podFrom, podTo, config.PortProtocol.Port, config.PortProtocol.Protocol, config.Mode)
+	} else {
+		panic(errors.Errorf("invalid ProbeConfig %+v", config))
+	}
+	return jobs
+}
+
 func (j *JobBuilder) GetJobsForNamedPortProtocol(resources *Resources, port intstr.IntOrString, protocol v1.Protocol, mode generator.ProbeMode) *Jobs {
 	jobs := &Jobs{}
 	for _, podFrom := range resources.Pods {
 		for _, podTo := range resources.Pods {
-			job := &Job{
-				FromKey:             podFrom.PodString().String(),
-				FromNamespace:       podFrom.Namespace,
-				FromNamespaceLabels: resources.Namespaces[podFrom.Namespace],
-				FromPod:             podFrom.Name,
-				FromPodLabels:       podFrom.Labels,
-				FromContainer:       podFrom.Containers[0].Name,
-				FromIP:              podFrom.IP,
-				ToKey:               podTo.PodString().String(),
-				ToHost:              podTo.Host(mode),
-				ToNamespace:         podTo.Namespace,
-				ToNamespaceLabels:   resources.Namespaces[podTo.Namespace],
-				ToPodLabels:         podTo.Labels,
-				ToIP:                podTo.IP,
-				ResolvedPort:        -1,
-				ResolvedPortName:    "",
-				Protocol:            protocol,
-				TimeoutSeconds:      j.TimeoutSeconds,
-			}
+			j.addJobsForNamedPortProtocol(jobs, resources, podFrom, podTo, port, protocol, mode)
+		}
+	}
+	return jobs
+}
 
-			switch port.Type {
-			case intstr.String:
-				job.ResolvedPortName = port.StrVal
-				// TODO what about protocol?
-				portInt, err := podTo.ResolveNamedPort(port.StrVal)
-				if err != nil {
-					jobs.BadNamedPort = append(jobs.BadNamedPort, job)
-					continue
-				}
-				job.ResolvedPort = portInt
-			case intstr.Int:
-				job.ResolvedPort = int(port.IntVal)
-				// TODO what about protocol?
-				portName, err := podTo.ResolveNumberedPort(int(port.IntVal))
-				if err != nil {
-					jobs.BadPortProtocol = append(jobs.BadPortProtocol, job)
-					continue
-				}
-				job.ResolvedPortName = portName
-			default:
-				panic(errors.Errorf("invalid IntOrString value %+v", port))
-			}
+func (j *JobBuilder) addJobsForNamedPortProtocol(jobs *Jobs, resources *Resources, podFrom *Pod, podTo *Pod, port intstr.IntOrString, protocol v1.Protocol, mode generator.ProbeMode) {
+	job := &Job{
+		FromKey:             podFrom.PodString().String(),
+		FromNamespace:       podFrom.Namespace,
+		FromNamespaceLabels: resources.Namespaces[podFrom.Namespace],
+		FromPod:             podFrom.Name,
+		FromPodLabels:       podFrom.Labels,
+		FromContainer:       podFrom.Containers[0].Name,
+		FromIP:              podFrom.IP,
+		ToKey:               podTo.PodString().String(),
+		ToHost:              podTo.Host(mode),
+		ToNamespace:         podTo.Namespace,
+		ToNamespaceLabels:   resources.Namespaces[podTo.Namespace],
+		ToPodLabels:         podTo.Labels,
+		ToIP:                podTo.IP,
+		ResolvedPort:        -1,
+		ResolvedPortName:    "",
+		Protocol:            protocol,
+		TimeoutSeconds:      j.TimeoutSeconds,
+	}
 
-			jobs.Valid = append(jobs.Valid, job)
+	switch port.Type {
+	case intstr.String:
+		job.ResolvedPortName = port.StrVal
+		// TODO what about protocol?
+		portInt, err := podTo.ResolveNamedPort(port.StrVal)
+		if err != nil {
+			jobs.BadNamedPort = append(jobs.BadNamedPort, job)
+			return
 		}
+		job.ResolvedPort = portInt
+	case intstr.Int:
+		job.ResolvedPort = int(port.IntVal)
+		// TODO what about protocol?
+		portName, err := podTo.ResolveNumberedPort(int(port.IntVal))
+		if err != nil {
+			jobs.BadPortProtocol = append(jobs.BadPortProtocol, job)
+			return
+		}
+		job.ResolvedPortName = portName
+	default:
+		panic(errors.Errorf("invalid IntOrString value %+v", port))
 	}
-	return jobs
+
+	jobs.Valid = append(jobs.Valid, job)
 }
 
 func (j *JobBuilder) GetJobsAllAvailableServers(resources *Resources, mode generator.ProbeMode) *Jobs {
-	var jobs []*Job
+	jobs := &Jobs{}
 	for _, podFrom := range resources.Pods {
 		for _, podTo := range resources.Pods {
-			for _, contTo := range podTo.Containers {
-				jobs = append(jobs, &Job{
-					FromKey:             podFrom.PodString().String(),
-					FromNamespace:       podFrom.Namespace,
-					FromNamespaceLabels: resources.Namespaces[podFrom.Namespace],
-					FromPod:             podFrom.Name,
-					FromPodLabels:       podFrom.Labels,
-					FromContainer:       podFrom.Containers[0].Name,
-					FromIP:              podFrom.IP,
-					ToKey:               podTo.PodString().String(),
-					ToHost:              podTo.Host(mode),
-					ToNamespace:         podTo.Namespace,
-					ToNamespaceLabels:   resources.Namespaces[podTo.Namespace],
-					ToPodLabels:         podTo.Labels,
-					ToContainer:         contTo.Name,
-					ToIP:                podTo.IP,
-					ResolvedPort:        contTo.Port,
-					ResolvedPortName:    contTo.PortName,
-					Protocol:            contTo.Protocol,
-					TimeoutSeconds:      j.TimeoutSeconds,
-				})
-			}
+			j.addJobsAllAvailableServers(jobs, resources, podFrom, podTo, mode)
 		}
 	}
-	return &Jobs{Valid: jobs}
+	return jobs
+}
+
+func (j *JobBuilder) addJobsAllAvailableServers(jobs *Jobs, resources *Resources, podFrom *Pod, podTo *Pod, mode generator.ProbeMode) {
+	for _, contTo := range podTo.Containers {
+		jobs.Valid = append(jobs.Valid, &Job{
+			FromKey:             podFrom.PodString().String(),
+			FromNamespace:       podFrom.Namespace,
+			FromNamespaceLabels: resources.Namespaces[podFrom.Namespace],
+			FromPod:             podFrom.Name,
+			FromPodLabels:       podFrom.Labels,
+			FromContainer:       podFrom.Containers[0].Name,
+			FromIP:              podFrom.IP,
+			ToKey:               podTo.PodString().String(),
+			ToHost:              podTo.Host(mode),
+			ToNamespace:         podTo.Namespace,
+			ToNamespaceLabels:   resources.Namespaces[podTo.Namespace],
+			ToPodLabels:         podTo.Labels,
+			ToContainer:         contTo.Name,
+			ToIP:                podTo.IP,
+			ResolvedPort:        contTo.Port,
+			ResolvedPortName:    contTo.PortName,
+			Protocol:            contTo.Protocol,
+			TimeoutSeconds:      j.TimeoutSeconds,
+		})
+	}
 }
@@ -8,6 +8,7 @@ import (
 	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/generator"
 	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/kube"
 	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/matcher"
+	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/telemetry"
 	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/worker"
 )
 
@@ -33,8 +34,12 @@ func (p *Runner) RunProbeForConfig(probeConfig *generator.ProbeConfig, resources
 }
 
 func (p *Runner) runProbe(jobs *Jobs) []*JobResult {
-	resultSlice := p.JobRunner.RunJobs(jobs.Valid)
+	return append(p.JobRunner.RunJobs(jobs.Valid), invalidJobResults(jobs)...)
+}
 
+// invalidJobResults returns the results of jobs with bad ports or protocols, which can't be run
+func invalidJobResults(jobs *Jobs) []*JobResult {
+	var resultSlice []*JobResult
 	invalidPP := ConnectivityInvalidPortProtocol
 	unknown := ConnectivityUnknown
 	for _, j := range jobs.BadPortProtocol {
@@ -82,9 +87,11 @@ func (s *SimulatedJobRunner) RunJob(job *Job) *JobResult {
 	}
 
 	allowed := s.Policies.IsTrafficAllowed(job.Traffic())
-	// TODO could also keep the whole `allowed` struct somewhere
 
-	logrus.Tracef("to %s\n%s\n", json.MustMarshalToString(job), allowed.Table())
+	// rendering the trace is much slower than evaluating the policies
+	if logrus.IsLevelEnabled(logrus.TraceLevel) {
+		logrus.Tracef("to %s\n%s\n", json.MustMarshalToString(job), allowed.Table())
+	}
 
 	var combined, ingress, egress = ConnectivityBlocked, ConnectivityBlocked, ConnectivityBlocked
 	if allowed.Ingress.IsAllowed() {
@@ -97,7 +104,7 @@ func (s *SimulatedJobRunner) RunJob(job *Job) *JobResult {
 		combined = ConnectivityAllowed
 	}
 
-	return &JobResult{Job: job, Ingress: &ingress, Egress: &egress, Combined: combined}
+	return &JobResult{Job: job, Ingress: &ingress, Egress: &egress, Combined: combined, Allowed: allowed}
 }
 
 type KubeJobRunner struct {
@@ -140,19 +147,35 @@ func (k *KubeJobRunner) worker(jobs <-chan *Job, results chan<- *JobResult) {
 
 func probeConnectivity(k8s kube.IKubernetes, job *Job) (Connectivity, string) {
 	commandDebugString := strings.Join(job.KubeExecCommand(), " ")
+	probeDone := telemetry.ProbeStarted(telemetry.RunnerKube)
 	stdout, stderr, commandErr, err := k8s.ExecuteRemoteCommand(job.FromNamespace, job.FromPod, job.FromContainer, job.ClientCommand())
+	probeDone()
 	logrus.Debugf("stdout, stderr from %s: \n%s\n%s", commandDebugString, stdout, stderr)
 	if err != nil {
 		logrus.Errorf("unable to set up command %s: %+v", commandDebugString, err)
+		telemetry.ObserveProbe(telemetry.RunnerKube, job.Protocol, telemetry.ProbeResultFailed)
 		return ConnectivityCheckFailed, commandDebugString
 	}
 	if commandErr != nil {
 		logrus.Debugf("unable to run command %s: %+v", commandDebugString, commandErr)
+		telemetry.ObserveProbe(telemetry.RunnerKube, job.Protocol, blockedProbeResult(stdout, stderr))
 		return ConnectivityBlocked, commandDebugString
 	}
+	telemetry.ObserveProbe(telemetry.RunnerKube, job.Protocol, telemetry.ProbeResultAllowed)
 	return ConnectivityAllowed, commandDebugString
 }
 
+// blockedProbeResult tells apart connections which timed out from connections which were refused, based on
+// the output of agnhost connect
+func blockedProbeResult(outputs ...string) string {
+	for _, output := range outputs {
+		if strings.Contains(output, "TIMEOUT") {
+			return telemetry.ProbeResultTimedOut
+		}
+	}
+	return telemetry.ProbeResultBlocked
+}
+
 type KubeBatchJobRunner struct {
 	Client  *worker.Client
 	Workers int
@@ -206,10 +229,13 @@ func (k *KubeBatchJobRunner) RunJobs(jobs []*Job) []*JobResult {
 
 func (k *KubeBatchJobRunner) worker(jobMap map[string]*Job, batches <-chan *worker.Batch, jobResults chan<- *JobResult) {
 	for b := range batches {
+		batchDone := telemetry.ProbeStarted(telemetry.RunnerKubeBatch)
 		results, err := k.Client.Batch(b)
+		batchDone()
 		if err != nil {
 			logrus.Errorf("unable to issue batch request: %+v", err)
 			for _, r := range b.Requests {
+				telemetry.ObserveProbe(telemetry.RunnerKubeBatch, r.Protocol, telemetry.ProbeResultFailed)
 				jobResults <- &JobResult{
 					Job:      jobMap[r.Key],
 					Combined: ConnectivityCheckFailed,
@@ -220,9 +246,11 @@ func (k *KubeBatchJobRunner) worker(jobMap map[string]*Job, batches <-chan *work
 				var c Connectivity
 				if r.IsSuccess() {
 					c = ConnectivityAllowed
+					telemetry.ObserveProbe(telemetry.RunnerKubeBatch, r.Request.Protocol, telemetry.ProbeResultAllowed)
 				} else {
 					logrus.Debugf("request to %s failed: %s", r.Request.Key, r.Error)
 					c = ConnectivityBlocked
+					telemetry.ObserveProbe(telemetry.RunnerKubeBatch, r.Request.Protocol, blockedProbeResult(r.Output, r.Error))
 				}
 				jobResults <- &JobResult{
 					Job:      jobMap[r.Request.Key],
@@ -0,0 +1,242 @@
+package probe
+
+import (
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mattfenwick/collections/pkg/slice"
+	"github.com/pkg/errors"
+	"golang.org/x/exp/maps"
+	v1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/network-policy-api/apis/v1alpha1"
+	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/kube"
+	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/matcher"
+)
+
+// MockCNIPolicies are the policies which the mock CNI enforces
+type MockCNIPolicies struct {
+	Policies []*networkingv1.NetworkPolicy
+	ANPs     []*v1alpha1.AdminNetworkPolicy
+	BANP     *v1alpha1.BaselineAdminNetworkPolicy
+}
+
+// MockCNIBug simulates a bug of a real CNI, by hooking into how the mock CNI enforces policies
+type MockCNIBug struct {
+	Description string
+	// Policies, if set, changes which policies are enforced
+	Policies func(policies *MockCNIPolicies)
+	// Traffic, if set, changes the traffic before policies are enforced on it
+	Traffic func(traffic *matcher.Traffic)
+	// Allowed, if set, changes whether traffic is allowed after policies are enforced on it
+	Allowed func(traffic *matcher.Traffic, allowed bool) bool
+}
+
+var MockCNIBugs = map[string]*MockCNIBug{
+	"ignore-named-ports": {
+		Description: "named ports are not resolved, so rules only match port numbers",
+		Traffic: func(traffic *matcher.Traffic) {
+			traffic.ResolvedPortName = ""
+		},
+	},
+	"ignore-sctp": {
+		Description: "policies are not enforced on SCTP, so all SCTP traffic is allowed",
+		Allowed: func(traffic *matcher.Traffic, allowed bool) bool {
+			return allowed || traffic.Protocol == v1.ProtocolSCTP
+		},
+	},
+	"ignore-admin-network-policies": {
+		Description: "ANPs and BANPs are not enforced",
+		Policies: func(policies *MockCNIPolicies) {
+			policies.ANPs, policies.BANP = nil, nil
+		},
+	},
+}
+
+// MockCNI enforces the policies of a kube.MockKubernetes on probes run in its pods, the same way the simulator
+// does -- unless bugs are injected.  This makes runs against the mock deterministic.
+type MockCNI struct {
+	Bugs []*MockCNIBug
+}
+
+// NewMockCNI looks up bugs by their names in MockCNIBugs
+func NewMockCNI(bugNames []string) (*MockCNI, error) {
+	cni := &MockCNI{}
+	for _, name := range bugNames {
+		bug, ok := MockCNIBugs[name]
+		if !ok {
+			return nil, errors.Errorf("invalid mock CNI bug %s; valid bugs are %+v", name, slice.Sort(maps.Keys(MockCNIBugs)))
+		}
+		cni.Bugs = append(cni.Bugs, bug)
+	}
+	return cni, nil
+}
+
+// NewMockCNIKubernetes is a kube.MockKubernetes whose probes are decided by a MockCNI
+func NewMockCNIKubernetes(bugNames []string) (*kube.MockKubernetes, error) {
+	cni, err := NewMockCNI(bugNames)
+	if err != nil {
+		return nil, err
+	}
+	kubernetes := kube.NewMockKubernetes(1.0)
+	kubernetes.Connectivity = cni.Connectivity
+	return kubernetes, nil
+}
+
+// Connectivity is a kube.MockConnectivityFunc for the agnhost connect commands built by Job.ClientCommand
+func (c *MockCNI) Connectivity(m *kube.MockKubernetes, namespace string, pod string, container string, command []string) (bool, error) {
+	host, port, protocol, err := parseConnectCommand(command)
+	if err != nil {
+		return false, err
+	}
+	fromPod, err := m.GetPod(namespace, pod)
+	if err != nil {
+		return false, err
+	}
+	toPod, err := resolveMockHost(m, host)
+	if err != nil {
+		return false, err
+	}
+	portName, ok := mockContainerPortName(toPod, port, protocol)
+	if !ok {
+		// nothing is listening
+		return false, nil
+	}
+	if fromPod.Namespace == toPod.Namespace && fromPod.Name == toPod.Name {
+		// policies don't apply to a pod's traffic to itself
+		return true, nil
+	}
+
+	traffic, err := mockTraffic(m, fromPod, toPod, port, portName, protocol)
+	if err != nil {
+		return false, err
+	}
+	policies := mockPolicies(m)
+	for _, bug := range c.Bugs {
+		if bug.Policies != nil {
+			bug.Policies(policies)
+		}
+		if bug.Traffic != nil {
+			bug.Traffic(traffic)
+		}
+	}
+
+	allowed := matcher.BuildV1AndV2NetPols(true, policies.Policies, policies.ANPs, policies.BANP).IsTrafficAllowed(traffic).IsAllowed()
+	for _, bug := range c.Bugs {
+		if bug.Allowed != nil {
+			allowed = bug.Allowed(traffic, allowed)
+		}
+	}
+	return allowed, nil
+}
+
+// parseConnectCommand is the inverse of Job.ClientCommand
+func parseConnectCommand(command []string) (string, int, v1.Protocol, error) {
+	if len(command) < 3 || command[1] != "connect" {
+		return "", 0, "", errors.Errorf("unable to parse command %+v: expected agnhost connect", command)
+	}
+	host, portString, err := net.SplitHostPort(command[2])
+	if err != nil {
+		return "", 0, "", errors.Wrapf(err, "unable to parse address %s", command[2])
+	}
+	port, err := strconv.Atoi(portString)
+	if err != nil {
+		return "", 0, "", errors.Wrapf(err, "unable to parse port %s", portString)
+	}
+	protocol := v1.ProtocolTCP
+	for _, arg := range command[3:] {
+		if value, ok := strings.CutPrefix(arg, "--protocol="); ok {
+			protocol = v1.Protocol(strings.ToUpper(value))
+		}
+	}
+	return host, port, protocol, nil
+}
+
+// resolveMockHost finds the pod behind a pod IP, a service IP or a service name
+func resolveMockHost(m *kube.MockKubernetes, host string) (*v1.Pod, error) {
+	for _, ns := range slice.Sort(maps.Keys(m.Namespaces)) {
+		nsObject := m.Namespaces[ns]
+		for _, pod := range nsObject.Pods {
+			if pod.Status.PodIP == host {
+				return pod, nil
+			}
+		}
+		for _, svc := range nsObject.Services {
+			if svc.Spec.ClusterIP != host && kube.QualifiedServiceAddress(svc.Name, svc.Namespace) != host {
+				continue
+			}
+			var pods []*v1.Pod
+			for _, pod := range nsObject.Pods {
+				if labels.SelectorFromSet(svc.Spec.Selector).Matches(labels.Set(pod.Labels)) {
+					pods = append(pods, pod)
+				}
+			}
+			if len(pods) == 0 {
+				return nil, errors.Errorf("service %s/%s has no pods", svc.Namespace, svc.Name)
+			}
+			sort.Slice(pods, func(i, j int) bool { return pods[i].Name < pods[j].Name })
+			return pods[0], nil
+		}
+	}
+	return nil, errors.Errorf("unable to resolve host %s", host)
+}
+
+func mockContainerPortName(pod *v1.Pod, port int, protocol v1.Protocol) (string, bool) {
+	for _, cont := range pod.Spec.Containers {
+		for _, containerPort := range cont.Ports {
+			if int(containerPort.ContainerPort) == port && containerPort.Protocol == protocol {
+				return containerPort.Name, true
+			}
+		}
+	}
+	return "", false
+}
+
+func mockTraffic(m *kube.MockKubernetes, fromPod *v1.Pod, toPod *v1.Pod, port int, portName string, protocol v1.Protocol) (*matcher.Traffic, error) {
+	fromNamespace, err := m.GetNamespace(fromPod.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	toNamespace, err := m.GetNamespace(toPod.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	return &matcher.Traffic{
+		Source: &matcher.TrafficPeer{
+			Internal: &matcher.InternalPeer{
+				PodLabels:       fromPod.Labels,
+				NamespaceLabels: fromNamespace.Labels,
+				Namespace:       fromPod.Namespace,
+			},
+			IP: fromPod.Status.PodIP,
+		},
+		Destination: &matcher.TrafficPeer{
+			Internal: &matcher.InternalPeer{
+				PodLabels:       toPod.Labels,
+				NamespaceLabels: toNamespace.Labels,
+				Namespace:       toPod.Namespace,
+			},
+			IP: toPod.Status.PodIP,
+		},
+		ResolvedPort:     port,
+		ResolvedPortName: portName,
+		Protocol:         protocol,
+	}, nil
+}
+
+func mockPolicies(m *kube.MockKubernetes) *MockCNIPolicies {
+	policies := &MockCNIPolicies{BANP: m.BaselineNetworkPolicy}
+	for _, ns := range slice.Sort(maps.Keys(m.Namespaces)) {
+		netpols := m.Namespaces[ns].Netpols
+		for _, name := range slice.Sort(maps.Keys(netpols)) {
+			policies.Policies = append(policies.Policies, netpols[name])
+		}
+	}
+	for i := range m.AdminNetworkPolicies {
+		policies.ANPs = append(policies.ANPs, &m.AdminNetworkPolicies[i])
+	}
+	return policies
+}
@@ -0,0 +1,52 @@
+package probe
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/generator"
+)
+
+func RunMockCNITests() {
+	Describe("MockCNI", func() {
+		denyIngressToB := &networkingv1.NetworkPolicy{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "x", Name: "deny-ingress-to-b"},
+			Spec: networkingv1.NetworkPolicySpec{
+				PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{"pod": "b"}},
+				PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+			},
+		}
+		probeWithBugs := func(mode generator.ProbeMode, bugs ...string) *Table {
+			kubernetes, err := NewMockCNIKubernetes(bugs)
+			Expect(err).To(Succeed())
+			resources, err := NewDefaultResources(kubernetes, []string{"x"}, []string{"a", "b"}, []int{80}, []v1.Protocol{v1.ProtocolTCP, v1.ProtocolSCTP}, nil, 5, false, "registry.k8s.io")
+			Expect(err).To(Succeed())
+			_, err = kubernetes.CreateNetworkPolicy(denyIngressToB)
+			Expect(err).To(Succeed())
+			return NewKubeRunner(kubernetes, 1, &JobBuilder{TimeoutSeconds: 1}).RunProbeForConfig(generator.NewAllAvailable(mode), resources)
+		}
+
+		It("enforces policies", func() {
+			for _, mode := range []generator.ProbeMode{generator.ProbeModeServiceName, generator.ProbeModePodIP} {
+				table := probeWithBugs(mode)
+				Expect(table.Get("x/a", "x/b").JobResults["TCP/80"].Combined).To(Equal(ConnectivityBlocked))
+				Expect(table.Get("x/a", "x/b").JobResults["SCTP/80"].Combined).To(Equal(ConnectivityBlocked))
+				Expect(table.Get("x/b", "x/a").JobResults["TCP/80"].Combined).To(Equal(ConnectivityAllowed))
+				Expect(table.Get("x/b", "x/b").JobResults["TCP/80"].Combined).To(Equal(ConnectivityAllowed))
+			}
+		})
+
+		It("injects bugs", func() {
+			table := probeWithBugs(generator.ProbeModeServiceName, "ignore-sctp")
+			Expect(table.Get("x/a", "x/b").JobResults["TCP/80"].Combined).To(Equal(ConnectivityBlocked))
+			Expect(table.Get("x/a", "x/b").JobResults["SCTP/80"].Combined).To(Equal(ConnectivityAllowed))
+		})
+
+		It("rejects unknown bugs", func() {
+			_, err := NewMockCNI([]string{"no-such-bug"})
+			Expect(err).ToNot(Succeed())
+		})
+	})
+}
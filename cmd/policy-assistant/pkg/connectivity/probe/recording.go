@@ -0,0 +1,192 @@
+package probe
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	networkingv1 "k8s.io/api/networking/v1"
+	"sigs.k8s.io/network-policy-api/apis/v1alpha1"
+	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/kube"
+	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/utils"
+)
+
+const recordedResourcesFile = "resources.json"
+
+// RoundKey identifies a round of kube probes: one try of one step of one test case
+type RoundKey struct {
+	TestCaseIndex int
+	TestCase      string
+	Step          int
+	Try           int
+}
+
+func (k *RoundKey) fileName() string {
+	return fmt.Sprintf("test-case-%04d-step-%02d-try-%02d.json", k.TestCaseIndex, k.Step, k.Try)
+}
+
+// Round is a round of kube probes, along with the policies in force while it ran
+type Round struct {
+	RoundKey
+	Policies []*networkingv1.NetworkPolicy
+	ANPs     []*v1alpha1.AdminNetworkPolicy
+	BANP     *v1alpha1.BaselineAdminNetworkPolicy
+}
+
+// Recording is the serialized format of a round of kube probes and its results
+type Recording struct {
+	Round
+	Results []*JobResult
+}
+
+// RoundAwareJobRunner is a JobRunner which needs to know which round its jobs belong to, such as when
+// recording or replaying results
+type RoundAwareJobRunner interface {
+	JobRunner
+	StartRound(round *Round) error
+}
+
+// RecordingJobRunner saves every result of the wrapped JobRunner to a directory, one file per round
+type RecordingJobRunner struct {
+	JobRunner JobRunner
+	Directory string
+	round     *Round
+}
+
+func NewRecordingJobRunner(jobRunner JobRunner, directory string) *RecordingJobRunner {
+	return &RecordingJobRunner{JobRunner: jobRunner, Directory: directory}
+}
+
+func (r *RecordingJobRunner) StartRound(round *Round) error {
+	r.round = round
+	return nil
+}
+
+func (r *RecordingJobRunner) RunJobs(jobs []*Job) []*JobResult {
+	results := r.JobRunner.RunJobs(jobs)
+	if r.round == nil {
+		panic(errors.Errorf("unable to record results: no round started"))
+	}
+	utils.DoOrDie(writeJson(filepath.Join(r.Directory, r.round.fileName()), &Recording{Round: *r.round, Results: results}))
+	return results
+}
+
+// ReplayJobRunner returns the results saved by a RecordingJobRunner, instead of probing a cluster.  Replays may
+// take more tries than the recording did, for instance if a retry is needed for a step which passed when recorded
+// but fails now: those replay the last recorded try of the step.
+type ReplayJobRunner struct {
+	Directory string
+	recording *Recording
+	path      string
+}
+
+func NewReplayJobRunner(directory string) *ReplayJobRunner {
+	return &ReplayJobRunner{Directory: directory}
+}
+
+func (r *ReplayJobRunner) StartRound(round *Round) error {
+	r.recording, r.path = nil, ""
+	key := round.RoundKey
+	for ; key.Try > 0; key.Try-- {
+		path := filepath.Join(r.Directory, key.fileName())
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		recording := &Recording{}
+		if err := readJson(path, recording); err != nil {
+			return err
+		}
+		if recording.TestCase != round.TestCase {
+			return errors.Errorf("recording %s is for test case '%s', not '%s'", path, recording.TestCase, round.TestCase)
+		}
+		if key.Try != round.Try {
+			logrus.Infof("no recording of try %d of step %d, replaying try %d instead", round.Try, round.Step, key.Try)
+		}
+		r.recording, r.path = recording, path
+		return nil
+	}
+	return errors.Errorf("no recording of test case %d step %d in %s", round.TestCaseIndex, round.Step, r.Directory)
+}
+
+func (r *ReplayJobRunner) RunJobs(jobs []*Job) []*JobResult {
+	if r.recording == nil {
+		panic(errors.Errorf("unable to replay results: no round started"))
+	}
+
+	recordedResults := map[string]*JobResult{}
+	for _, result := range r.recording.Results {
+		recordedResults[result.Job.Key()] = result
+	}
+	var results []*JobResult
+	for _, job := range jobs {
+		result := &JobResult{Job: job, Combined: ConnectivityUnknown}
+		if recorded, ok := recordedResults[job.Key()]; ok {
+			result.Combined = recorded.Combined
+		} else {
+			logrus.Warnf("no recorded result for job %s in %s", job.Key(), r.path)
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// recordedResources is the serialized format of Resources
+type recordedResources struct {
+	Namespaces map[string]map[string]string
+	Pods       []*Pod
+}
+
+// RecordResources saves the resources which recorded probes ran against, so that they can be replayed without a cluster
+func RecordResources(directory string, resources *Resources) error {
+	if err := os.MkdirAll(directory, 0755); err != nil {
+		return errors.Wrapf(err, "unable to create directory %s", directory)
+	}
+	return writeJson(filepath.Join(directory, recordedResourcesFile), &recordedResources{Namespaces: resources.Namespaces, Pods: resources.Pods})
+}
+
+// NewReplayResources reads the recorded resources, and creates them in kubernetes -- which is usually a mock
+func NewReplayResources(kubernetes kube.IKubernetes, directory string) (*Resources, error) {
+	recorded := &recordedResources{}
+	if err := readJson(filepath.Join(directory, recordedResourcesFile), recorded); err != nil {
+		return nil, err
+	}
+	resources := &Resources{Namespaces: recorded.Namespaces, Pods: recorded.Pods}
+	for _, pod := range resources.Pods {
+		for _, cont := range pod.Containers {
+			resources.ports = appendIfMissing(resources.ports, cont.Port)
+			resources.protocols = appendIfMissing(resources.protocols, cont.Protocol)
+		}
+	}
+	if err := resources.CreateResourcesInKube(kubernetes); err != nil {
+		return nil, err
+	}
+	return resources, nil
+}
+
+func appendIfMissing[T comparable](elems []T, elem T) []T {
+	for _, e := range elems {
+		if e == elem {
+			return elems
+		}
+	}
+	return append(elems, elem)
+}
+
+func writeJson(path string, obj interface{}) error {
+	bytes, err := json.MarshalIndent(obj, "", "  ")
+	if err != nil {
+		return errors.Wrapf(err, "unable to marshal json")
+	}
+	return errors.Wrapf(os.WriteFile(path, bytes, 0644), "unable to write file %s", path)
+}
+
+func readJson(path string, obj interface{}) error {
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		return errors.Wrapf(err, "unable to read file %s", path)
+	}
+	return errors.Wrapf(json.Unmarshal(bytes, obj), "unable to unmarshal json from %s", path)
+}
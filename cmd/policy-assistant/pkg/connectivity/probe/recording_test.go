@@ -0,0 +1,80 @@
+package probe
+
+import (
+	"os"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/kube"
+)
+
+type fixedJobRunner struct {
+	connectivity Connectivity
+}
+
+func (f *fixedJobRunner) RunJobs(jobs []*Job) []*JobResult {
+	var results []*JobResult
+	for _, job := range jobs {
+		results = append(results, &JobResult{Job: job, Combined: f.connectivity})
+	}
+	return results
+}
+
+func RunRecordingTests() {
+	Describe("Recording", func() {
+		It("Should replay recorded results by round and job", func() {
+			dir, err := os.MkdirTemp("", "recording")
+			Expect(err).To(Succeed())
+			defer os.RemoveAll(dir)
+
+			resources := &Resources{
+				Namespaces: map[string]map[string]string{"x": {"ns": "x"}},
+				Pods: []*Pod{
+					NewPod("x", "a", map[string]string{"pod": "a"}, "1.2.3.4", []*Container{NewDefaultContainer(80, v1.ProtocolTCP, false, "registry.k8s.io")}),
+				},
+			}
+			Expect(RecordResources(dir, resources)).To(Succeed())
+			job := &Job{FromKey: "x/a", ToKey: "x/b", Protocol: v1.ProtocolTCP, ResolvedPort: 80}
+			otherJob := &Job{FromKey: "x/a", ToKey: "x/c", Protocol: v1.ProtocolTCP, ResolvedPort: 80}
+			round := &Round{RoundKey: RoundKey{TestCaseIndex: 1, TestCase: "test", Step: 1, Try: 1}}
+
+			recorder := NewRecordingJobRunner(&fixedJobRunner{connectivity: ConnectivityBlocked}, dir)
+			Expect(recorder.StartRound(round)).To(Succeed())
+			Expect(recorder.RunJobs([]*Job{job})[0].Combined).To(Equal(ConnectivityBlocked))
+
+			replayer := NewReplayJobRunner(dir)
+			Expect(replayer.StartRound(round)).To(Succeed())
+			results := replayer.RunJobs([]*Job{job, otherJob})
+			Expect(results[0].Combined).To(Equal(ConnectivityBlocked))
+			Expect(results[1].Combined).To(Equal(ConnectivityUnknown))
+
+			replayed, err := NewReplayResources(kube.NewMockKubernetes(1.0), dir)
+			Expect(err).To(Succeed())
+			Expect(replayed.Pods[0].IP).To(Equal("1.2.3.4"))
+			Expect(replayed.ports).To(Equal([]int{80}))
+			Expect(replayed.protocols).To(Equal([]v1.Protocol{v1.ProtocolTCP}))
+		})
+
+		It("Should replay the last recorded try of a step, and fail on unrecorded steps", func() {
+			dir, err := os.MkdirTemp("", "recording")
+			Expect(err).To(Succeed())
+			defer os.RemoveAll(dir)
+
+			job := &Job{FromKey: "x/a", ToKey: "x/b", Protocol: v1.ProtocolTCP, ResolvedPort: 80}
+			recorder := NewRecordingJobRunner(&fixedJobRunner{connectivity: ConnectivityBlocked}, dir)
+			Expect(recorder.StartRound(&Round{RoundKey: RoundKey{TestCaseIndex: 1, TestCase: "test", Step: 1, Try: 1}})).To(Succeed())
+			recorder.RunJobs([]*Job{job})
+			recorder.JobRunner = &fixedJobRunner{connectivity: ConnectivityAllowed}
+			Expect(recorder.StartRound(&Round{RoundKey: RoundKey{TestCaseIndex: 1, TestCase: "test", Step: 1, Try: 2}})).To(Succeed())
+			recorder.RunJobs([]*Job{job})
+
+			replayer := NewReplayJobRunner(dir)
+			Expect(replayer.StartRound(&Round{RoundKey: RoundKey{TestCaseIndex: 1, TestCase: "test", Step: 1, Try: 4}})).To(Succeed())
+			Expect(replayer.RunJobs([]*Job{job})[0].Combined).To(Equal(ConnectivityAllowed))
+
+			Expect(replayer.StartRound(&Round{RoundKey: RoundKey{TestCaseIndex: 1, TestCase: "test", Step: 2, Try: 1}})).NotTo(Succeed())
+			Expect(replayer.StartRound(&Round{RoundKey: RoundKey{TestCaseIndex: 1, TestCase: "other", Step: 1, Try: 1}})).NotTo(Succeed())
+		})
+	})
+}
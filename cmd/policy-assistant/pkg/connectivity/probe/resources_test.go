@@ -1,8 +1,15 @@
 package probe
 
 import (
+	"fmt"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/matcher"
 )
 
 func RunResourcesTests() {
@@ -51,3 +58,39 @@ func RunResourcesTests() {
 		})
 	})
 }
+
+// testResources models pods, given as "<namespace>/<name>" and labeled pod=<name>, which serve TCP on ports 80 and
+// 81, named serve-80-tcp and serve-81-tcp.  Their namespaces are labeled ns=<namespace>.
+func testResources(pods ...string) *Resources {
+	resources := &Resources{Namespaces: map[string]map[string]string{}}
+	for i, pod := range pods {
+		podString := PodString(pod)
+		resources.Namespaces[podString.Namespace()] = map[string]string{"ns": podString.Namespace()}
+		resources.Pods = append(resources.Pods, NewPod(podString.Namespace(), podString.PodName(),
+			map[string]string{"pod": podString.PodName()}, fmt.Sprintf("1.2.3.%d", 4+i),
+			[]*Container{
+				{Name: "cont-80-tcp", Port: 80, Protocol: v1.ProtocolTCP, PortName: "serve-80-tcp"},
+				{Name: "cont-81-tcp", Port: 81, Protocol: v1.ProtocolTCP, PortName: "serve-81-tcp"},
+			}))
+	}
+	return resources
+}
+
+// testAllow80ToB allows ingress to x/b on port 80 from the peers, or from anywhere if there are none
+func testAllow80ToB(from ...networkingv1.NetworkPolicyPeer) *networkingv1.NetworkPolicy {
+	port80 := intstr.FromInt(80)
+	return &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "x", Name: "allow-80-to-b"},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{"pod": "b"}},
+			Ingress:     []networkingv1.NetworkPolicyIngressRule{{Ports: []networkingv1.NetworkPolicyPort{{Port: &port80}}, From: from}},
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+		},
+	}
+}
+
+// testAllow80ToBModel is x/a, x/b and y/c, where only port 80 of x/b is open, and only to x
+func testAllow80ToBModel() (*Resources, *matcher.Policy) {
+	allow80ToB := testAllow80ToB(networkingv1.NetworkPolicyPeer{PodSelector: &metav1.LabelSelector{}})
+	return testResources("x/a", "x/b", "y/c"), matcher.BuildV1AndV2NetPols(true, []*networkingv1.NetworkPolicy{allow80ToB}, nil, nil)
+}
@@ -10,5 +10,13 @@ import (
 func TestProbe(t *testing.T) {
 	RegisterFailHandler(Fail)
 	RunResourcesTests()
+	RunTableTests()
+	RunDiffTests()
+	RunRecordingTests()
+	RunMockCNITests()
+	RunVerdictTrackerTests()
+	RunGraphTests()
+	RunAggregationTests()
+	RunEquivalenceClassTests()
 	RunSpecs(t, "generator suite")
 }
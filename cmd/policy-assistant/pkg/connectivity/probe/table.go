@@ -1,6 +1,7 @@
 package probe
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 
@@ -132,6 +133,54 @@ func (t *Table) Get(from string, to string) *Item {
 	return t.Wrapped.Get(from, to).(*Item)
 }
 
+// PodClasses makes each pod of the table a class of its own: see ClassResults
+func (t *Table) PodClasses() [][]string {
+	classes := make([][]string, len(t.Wrapped.Froms))
+	for i, pod := range t.Wrapped.Froms {
+		classes[i] = []string{pod}
+	}
+	return classes
+}
+
+// ClassPairResults returns the results of the cell between two pods, by index: see ClassResults
+func (t *Table) ClassPairResults(from int, to int) map[string]*JobResult {
+	return t.Get(t.Wrapped.Froms[from], t.Wrapped.Froms[to]).JobResults
+}
+
+// TableCell is the serialized form of the result of a single from/to/port/protocol probe
+type TableCell struct {
+	From         string
+	To           string
+	PortProtocol string
+	Ingress      *Connectivity `json:",omitempty"`
+	Egress       *Connectivity `json:",omitempty"`
+	Combined     Connectivity
+}
+
+// Cells returns the result of every probe in the table, ordered by from, to, and port/protocol
+func (t *Table) Cells() []*TableCell {
+	var cells []*TableCell
+	for _, key := range t.Wrapped.Keys() {
+		jobResults := t.Get(key.From, key.To).JobResults
+		for _, portProtocol := range slice.Sort(maps.Keys(jobResults)) {
+			jr := jobResults[portProtocol]
+			cells = append(cells, &TableCell{
+				From:         key.From,
+				To:           key.To,
+				PortProtocol: portProtocol,
+				Ingress:      jr.Ingress,
+				Egress:       jr.Egress,
+				Combined:     jr.Combined,
+			})
+		}
+	}
+	return cells
+}
+
+func (t *Table) MarshalJSON() (b []byte, e error) {
+	return json.Marshal(t.Cells())
+}
+
 func (t *Table) RenderIngress() string {
 	return t.renderTableHelper(getIngress)
 }
@@ -152,7 +201,6 @@ func (t *Table) renderTableHelper(render func(*JobResult) string) string {
 		dict := t.Get(key.From, key.To).JobResults
 		if len(dict) != 1 {
 			isSingleElement = false
-			break
 		}
 		keys := slice.Sort(maps.Keys(dict))
 		schema[strings.Join(keys, "_")] = true
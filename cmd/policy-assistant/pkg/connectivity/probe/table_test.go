@@ -0,0 +1,61 @@
+package probe
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+)
+
+func RunTableTests() {
+	Describe("Table", func() {
+		It("Should serialize to a list of cells", func() {
+			table := NewTable([]string{"x/a", "x/b"})
+			allowed, blocked := ConnectivityAllowed, ConnectivityBlocked
+			Expect(table.Get("x/a", "x/b").AddJobResult(&JobResult{
+				Job:      &Job{ResolvedPort: 81, Protocol: v1.ProtocolUDP},
+				Ingress:  &blocked,
+				Egress:   &allowed,
+				Combined: ConnectivityBlocked,
+			})).To(Succeed())
+			Expect(table.Get("x/a", "x/b").AddJobResult(&JobResult{
+				Job:      &Job{ResolvedPort: 80, Protocol: v1.ProtocolTCP},
+				Combined: ConnectivityAllowed,
+			})).To(Succeed())
+
+			bytes, err := json.Marshal(table)
+			Expect(err).To(Succeed())
+			Expect(bytes).To(MatchJSON(`[
+				{"From": "x/a", "To": "x/b", "PortProtocol": "TCP/80", "Combined": "allowed"},
+				{"From": "x/a", "To": "x/b", "PortProtocol": "UDP/81", "Ingress": "blocked", "Egress": "allowed", "Combined": "blocked"}
+			]`))
+		})
+
+		It("Should list the ports of each cell when cells have different ports", func() {
+			table := NewTable([]string{"x/a", "x/b"})
+			allowed, blocked := ConnectivityAllowed, ConnectivityBlocked
+			for _, key := range table.Wrapped.Keys() {
+				Expect(table.Get(key.From, key.To).AddJobResult(&JobResult{
+					Job:      &Job{ResolvedPort: 80, Protocol: v1.ProtocolTCP},
+					Ingress:  &allowed,
+					Egress:   &allowed,
+					Combined: ConnectivityAllowed,
+				})).To(Succeed())
+			}
+			// only x/b serves on port 81
+			Expect(table.Get("x/a", "x/b").AddJobResult(&JobResult{
+				Job:      &Job{ResolvedPort: 81, Protocol: v1.ProtocolTCP},
+				Ingress:  &blocked,
+				Egress:   &allowed,
+				Combined: ConnectivityBlocked,
+			})).To(Succeed())
+
+			rendered := table.RenderTable()
+			Expect(rendered).To(ContainSubstring("TCP/80: ."))
+			Expect(rendered).To(ContainSubstring("TCP/81: X"))
+			Expect(table.RenderIngress()).To(ContainSubstring("TCP/81: X"))
+			Expect(table.RenderEgress()).To(ContainSubstring("TCP/81: ."))
+		})
+	})
+}
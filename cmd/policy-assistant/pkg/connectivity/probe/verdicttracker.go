@@ -0,0 +1,85 @@
+package probe
+
+import (
+	"fmt"
+	"sort"
+
+	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/generator"
+	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/matcher"
+)
+
+// VerdictUpdate is the result of re-evaluating flows.  Flows to or from new or deleted pods are counted,
+// but they aren't changes.
+type VerdictUpdate struct {
+	Changes   []*VerdictChange
+	Evaluated int
+	Added     int
+	Removed   int
+}
+
+// VerdictTracker keeps the verdict of every flow between the pods of Resources, on every port they serve,
+// and reports which verdicts change as policies and resources change
+type VerdictTracker struct {
+	JobBuilder *JobBuilder
+	// Verdicts is keyed by Job.Key
+	Verdicts map[string]*matcher.AllowedResult
+}
+
+func NewVerdictTracker() *VerdictTracker {
+	return &VerdictTracker{JobBuilder: &JobBuilder{}}
+}
+
+// Update evaluates the flows between the pods of resources.  If isStale is nil, every flow is re-evaluated;
+// otherwise, only new flows and flows for which isStale is true are, and the rest keep their previous verdict.
+// After policies change, isStale need only select the flows which matcher.ChangedSubjects.Affects.  The first update sets the baseline, so it has no changes.
+// As for DiffVerdicts, flows from a pod to itself are skipped, and changes are sorted.
+func (t *VerdictTracker) Update(policies *matcher.Policy, resources *Resources, isStale func(job *Job) bool) *VerdictUpdate {
+	update := &VerdictUpdate{}
+	verdicts := map[string]*matcher.AllowedResult{}
+	for _, job := range t.JobBuilder.GetJobsAllAvailableServers(resources, generator.ProbeModeServiceName).Valid {
+		key := job.Key()
+		if job.FromKey == job.ToKey || verdicts[key] != nil {
+			continue
+		}
+		previous, ok := t.Verdicts[key]
+		if ok && isStale != nil && !isStale(job) {
+			verdicts[key] = previous
+			continue
+		}
+
+		update.Evaluated++
+		result := policies.IsTrafficAllowed(job.Traffic())
+		verdicts[key] = result
+		if !ok {
+			if t.Verdicts != nil {
+				update.Added++
+			}
+		} else if previous.IsAllowed() != result.IsAllowed() {
+			update.Changes = append(update.Changes, &VerdictChange{
+				From:         job.FromKey,
+				To:           job.ToKey,
+				PortProtocol: fmt.Sprintf("%s/%d", job.Protocol, job.ResolvedPort),
+				Before:       previous,
+				After:        result,
+			})
+		}
+	}
+	for key := range t.Verdicts {
+		if _, ok := verdicts[key]; !ok {
+			update.Removed++
+		}
+	}
+	t.Verdicts = verdicts
+
+	sort.SliceStable(update.Changes, func(i, j int) bool {
+		a, b := update.Changes[i], update.Changes[j]
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		if a.To != b.To {
+			return a.To < b.To
+		}
+		return a.PortProtocol < b.PortProtocol
+	})
+	return update
+}
@@ -0,0 +1,75 @@
+package probe
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/matcher"
+)
+
+func RunVerdictTrackerTests() {
+	Describe("VerdictTracker", func() {
+		// x/a and x/b, which serve ports 80 and 81, followed by the pods, with b labeled bLabel
+		newResources := func(bLabel string, pods ...string) *Resources {
+			resources := testResources(append([]string{"x/a", "x/b"}, pods...)...)
+			resources.Pods[1].Labels = map[string]string{"pod": bLabel}
+			return resources
+		}
+		denyToB := &networkingv1.NetworkPolicy{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "x", Name: "deny-to-b"},
+			Spec: networkingv1.NetworkPolicySpec{
+				PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{"pod": "b"}},
+				PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+			},
+		}
+		noPolicies := matcher.BuildV1AndV2NetPols(true, nil, nil, nil)
+		denyPolicies := matcher.BuildV1AndV2NetPols(true, []*networkingv1.NetworkPolicy{denyToB}, nil, nil)
+
+		It("Should report verdicts changed by policies", func() {
+			tracker := NewVerdictTracker()
+			baseline := tracker.Update(noPolicies, newResources("b"), nil)
+			Expect(baseline.Evaluated).To(Equal(4))
+			Expect(baseline.Changes).To(BeEmpty())
+
+			update := tracker.Update(denyPolicies, newResources("b"), nil)
+			Expect(update.Changes).To(HaveLen(2))
+			for i, portProtocol := range []string{"TCP/80", "TCP/81"} {
+				Expect(update.Changes[i].From).To(Equal("x/a"))
+				Expect(update.Changes[i].To).To(Equal("x/b"))
+				Expect(update.Changes[i].PortProtocol).To(Equal(portProtocol))
+				Expect(update.Changes[i].Before.IsAllowed()).To(BeTrue())
+				Expect(update.Changes[i].After.IsAllowed()).To(BeFalse())
+			}
+		})
+
+		It("Should only re-evaluate stale flows after label changes", func() {
+			tracker := NewVerdictTracker()
+			tracker.Update(denyPolicies, newResources("b"), nil)
+
+			notStale := tracker.Update(denyPolicies, newResources("c"), func(job *Job) bool { return false })
+			Expect(notStale.Evaluated).To(Equal(0))
+			Expect(notStale.Changes).To(BeEmpty())
+
+			stale := tracker.Update(denyPolicies, newResources("c"), func(job *Job) bool { return job.ToKey == "x/b" })
+			Expect(stale.Evaluated).To(Equal(2))
+			Expect(stale.Changes).To(HaveLen(2))
+			Expect(stale.Changes[0].After.IsAllowed()).To(BeTrue())
+			Expect(stale.Changes[1].After.IsAllowed()).To(BeTrue())
+		})
+
+		It("Should count flows of new and deleted pods, without reporting them as changes", func() {
+			tracker := NewVerdictTracker()
+			tracker.Update(noPolicies, newResources("b"), nil)
+
+			added := tracker.Update(noPolicies, newResources("b", "x/c"), func(job *Job) bool { return false })
+			Expect(added.Added).To(Equal(8))
+			Expect(added.Evaluated).To(Equal(8))
+			Expect(added.Changes).To(BeEmpty())
+
+			removed := tracker.Update(noPolicies, newResources("b"), func(job *Job) bool { return false })
+			Expect(removed.Removed).To(Equal(8))
+			Expect(removed.Changes).To(BeEmpty())
+		})
+	})
+}
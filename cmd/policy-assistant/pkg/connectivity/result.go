@@ -38,3 +38,12 @@ func (r *Result) Passed(ignoreLoopback bool) bool {
 	}
 	return true
 }
+
+func (r *Result) Flaky(ignoreLoopback bool) bool {
+	for _, step := range r.Steps {
+		if step.Flaky(ignoreLoopback) {
+			return true
+		}
+	}
+	return false
+}
@@ -15,14 +15,21 @@ type StepResult struct {
 	KubePolicies   []*networkingv1.NetworkPolicy
 	ANPs           []*v1alpha1.AdminNetworkPolicy
 	BANP           *v1alpha1.BaselineAdminNetworkPolicy
-	comparisons    []*ComparisonTable
+	// Convergence is only measured if InterpreterConfig.ConvergenceTimeoutSeconds is set
+	Convergence *Convergence
+	// RepeatedKubeProbes is set if every retry was run, even after a try passed: only then are pairs classified over
+	// every try.  Otherwise, earlier tries only failed on the way to the last one, and pairs are judged by it.
+	RepeatedKubeProbes bool
+	comparisons        []*ComparisonTable
 }
 
-func NewStepResult(simulated *probe.Table, policy *matcher.Policy, kubePolicies []*networkingv1.NetworkPolicy) *StepResult {
+func NewStepResult(simulated *probe.Table, policy *matcher.Policy, kubePolicies []*networkingv1.NetworkPolicy, anps []*v1alpha1.AdminNetworkPolicy, banp *v1alpha1.BaselineAdminNetworkPolicy) *StepResult {
 	return &StepResult{
 		SimulatedProbe: simulated,
 		Policy:         policy,
 		KubePolicies:   kubePolicies,
+		ANPs:           anps,
+		BANP:           banp,
 	}
 }
 
@@ -46,6 +53,46 @@ func (s *StepResult) LastKubeProbe() *probe.Table {
 	return s.KubeProbes[len(s.KubeProbes)-1]
 }
 
+func (s *StepResult) Comparisons() []*ComparisonTable {
+	var comparisons []*ComparisonTable
+	for i := range s.KubeProbes {
+		comparisons = append(comparisons, s.Comparison(i))
+	}
+	return comparisons
+}
+
+// Classify compares a from/to pair over every try if the kube probes were repeated, and on the last try otherwise
+func (s *StepResult) Classify(from string, to string, ignoreLoopback bool) Comparison {
+	if ignoreLoopback && from == to {
+		return IgnoredComparison
+	}
+	if !s.RepeatedKubeProbes {
+		return Classify([]*ComparisonTable{s.LastComparison()}, from, to)
+	}
+	return Classify(s.Comparisons(), from, to)
+}
+
+// ValueCounts counts the from/to pairs which were the same, different, flaky or ignored: see Classify
+func (s *StepResult) ValueCounts(ignoreLoopback bool) map[Comparison]int {
+	counts := map[Comparison]int{}
+	for _, key := range s.SimulatedProbe.Wrapped.Keys() {
+		counts[s.Classify(key.From, key.To, ignoreLoopback)]++
+	}
+	return counts
+}
+
+func (s *StepResult) RenderClassificationTable(ignoreLoopback bool) string {
+	return s.LastComparison().Wrapped.Table("", false, func(fr, to string, i interface{}) string {
+		return s.Classify(fr, to, ignoreLoopback).ShortString()
+	})
+}
+
+// Passed is true if no from/to pair was wrong on the last try or, if the kube probes were repeated, on every try:
+// flaky pairs don't fail a step
 func (s *StepResult) Passed(ignoreLoopback bool) bool {
-	return s.LastComparison().ValueCounts(ignoreLoopback)[DifferentComparison] == 0
+	return s.ValueCounts(ignoreLoopback)[DifferentComparison] == 0
+}
+
+func (s *StepResult) Flaky(ignoreLoopback bool) bool {
+	return s.ValueCounts(ignoreLoopback)[FlakyComparison] > 0
 }
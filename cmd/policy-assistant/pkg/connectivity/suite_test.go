@@ -11,5 +11,8 @@ func TestConnectivity(t *testing.T) {
 	RegisterFailHandler(Fail)
 	RunTestCaseStateTests()
 	RunPrinterTests()
+	RunComparisonTableTests()
+	RunConvergenceTests()
+	RunMultipleContextTesterTests()
 	RunSpecs(t, "connectivity suite")
 }
@@ -6,9 +6,11 @@ import (
 )
 
 type SummaryTable struct {
-	Tests                [][]string
-	Passed               int
-	Failed               int
+	Tests  [][]string
+	Passed int
+	Failed int
+	// Flaky counts the passed tests which had flaky results: see Classify
+	Flaky                int
 	ProtocolCounts       map[v1.Protocol]map[Comparison]int
 	TagCounts            map[string]map[string]map[bool]int
 	TagPrimaryCounts     map[string]map[bool]int
@@ -27,7 +29,7 @@ func NewSummaryTableFromResults(ignoreLoopback bool, results []*Result) *Summary
 		FeatureCounts:        map[string]map[string]map[bool]int{},
 		FeaturePrimaryCounts: map[string]map[bool]int{},
 	}
-	passedTotal, failedTotal := 0, 0
+	passedTotal, failedTotal, flakyTotal := 0, 0, 0
 
 	for testNumber, result := range results {
 		passed := result.Passed(ignoreLoopback)
@@ -50,7 +52,11 @@ func NewSummaryTableFromResults(ignoreLoopback bool, results []*Result) *Summary
 		}
 
 		var testResult string
-		if passed {
+		if passed && result.Flaky(ignoreLoopback) {
+			testResult = "passed (flaky)"
+			passedTotal++
+			flakyTotal++
+		} else if passed {
 			testResult = "passed"
 			passedTotal++
 		} else {
@@ -60,7 +66,7 @@ func NewSummaryTableFromResults(ignoreLoopback bool, results []*Result) *Summary
 
 		summary.Tests = append(summary.Tests, []string{
 			fmt.Sprintf("%d: %s", testNumber+1, result.TestCase.Description),
-			testResult, "", "", "", "",
+			testResult, "", "", "", "", "",
 			"", "", "",
 		})
 
@@ -76,6 +82,7 @@ func NewSummaryTableFromResults(ignoreLoopback bool, results []*Result) *Summary
 					"",
 					fmt.Sprintf("Step %d, try %d", stepNumber+1, tryNumber+1),
 					intToString(counts[DifferentComparison]),
+					"",
 					intToString(counts[SameComparison]),
 					intToString(counts[IgnoredComparison]),
 					protocolResult(tcp[SameComparison], tcp[DifferentComparison]),
@@ -90,11 +97,25 @@ func NewSummaryTableFromResults(ignoreLoopback bool, results []*Result) *Summary
 				summary.ProtocolCounts[v1.ProtocolUDP][SameComparison] += udp[SameComparison]
 				summary.ProtocolCounts[v1.ProtocolUDP][DifferentComparison] += udp[DifferentComparison]
 			}
+			if len(step.KubeProbes) > 1 {
+				counts := step.ValueCounts(ignoreLoopback)
+				summary.Tests = append(summary.Tests, []string{
+					"",
+					"",
+					fmt.Sprintf("Step %d, all %d tries", stepNumber+1, len(step.KubeProbes)),
+					intToString(counts[DifferentComparison]),
+					intToString(counts[FlakyComparison]),
+					intToString(counts[SameComparison]),
+					intToString(counts[IgnoredComparison]),
+					"", "", "",
+				})
+			}
 		}
 	}
 
 	summary.Passed = passedTotal
 	summary.Failed = failedTotal
+	summary.Flaky = flakyTotal
 
 	return summary
 }
@@ -4,10 +4,13 @@ import (
 	"context"
 	"time"
 
+	"github.com/mattfenwick/collections/pkg/slice"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	"golang.org/x/exp/maps"
 	v1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
+	"sigs.k8s.io/network-policy-api/apis/v1alpha1"
 	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/connectivity/probe"
 	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/kube"
 )
@@ -16,6 +19,28 @@ type TestCaseState struct {
 	Kubernetes kube.IKubernetes
 	Resources  *probe.Resources
 	Policies   []*networkingv1.NetworkPolicy
+	ANPs       []*v1alpha1.AdminNetworkPolicy
+	BANP       *v1alpha1.BaselineAdminNetworkPolicy
+	// Created is shared between test cases, so that a reset only deletes the admin network policies they created
+	Created *CreatedAdminNetworkPolicies
+}
+
+// CreatedAdminNetworkPolicies records the names of the cluster-scoped ANPs and BANP which test cases created and
+// haven't deleted yet
+type CreatedAdminNetworkPolicies struct {
+	ANPs map[string]bool
+	BANP string
+}
+
+func NewCreatedAdminNetworkPolicies() *CreatedAdminNetworkPolicies {
+	return &CreatedAdminNetworkPolicies{ANPs: map[string]bool{}}
+}
+
+func (t *TestCaseState) createdAdminNetworkPolicies() *CreatedAdminNetworkPolicies {
+	if t.Created == nil {
+		t.Created = NewCreatedAdminNetworkPolicies()
+	}
+	return t.Created
 }
 
 func (t *TestCaseState) CreatePolicy(policy *networkingv1.NetworkPolicy) error {
@@ -51,6 +76,87 @@ func (t *TestCaseState) UpdatePolicy(policy *networkingv1.NetworkPolicy) error {
 	return err
 }
 
+func (t *TestCaseState) CreateAdminNetworkPolicy(policy *v1alpha1.AdminNetworkPolicy) error {
+	for _, anp := range t.ANPs {
+		if anp.Name == policy.Name {
+			return errors.Errorf("cannot create admin network policy %s: already exists", policy.Name)
+		}
+	}
+	t.ANPs = append(t.ANPs, policy)
+
+	_, err := t.Kubernetes.CreateAdminNetworkPolicy(context.TODO(), policy)
+	if err != nil {
+		return err
+	}
+	t.createdAdminNetworkPolicies().ANPs[policy.Name] = true
+	return nil
+}
+
+func (t *TestCaseState) UpdateAdminNetworkPolicy(policy *v1alpha1.AdminNetworkPolicy) error {
+	for i, anp := range t.ANPs {
+		if anp.Name == policy.Name {
+			t.ANPs[i] = policy
+			_, err := t.Kubernetes.UpdateAdminNetworkPolicy(context.TODO(), policy)
+			return err
+		}
+	}
+	return errors.Errorf("cannot update admin network policy %s: not found", policy.Name)
+}
+
+func (t *TestCaseState) DeleteAdminNetworkPolicy(name string) error {
+	for i, anp := range t.ANPs {
+		if anp.Name == name {
+			t.ANPs = append(append([]*v1alpha1.AdminNetworkPolicy{}, t.ANPs[:i]...), t.ANPs[i+1:]...)
+			err := t.Kubernetes.DeleteAdminNetworkPolicy(context.TODO(), name)
+			if err != nil {
+				return err
+			}
+			delete(t.createdAdminNetworkPolicies().ANPs, name)
+			return nil
+		}
+	}
+	return errors.Errorf("cannot delete admin network policy %s: not found", name)
+}
+
+func (t *TestCaseState) CreateBaselineAdminNetworkPolicy(policy *v1alpha1.BaselineAdminNetworkPolicy) error {
+	// there's at most one BANP per cluster
+	if t.BANP != nil {
+		return errors.Errorf("cannot create baseline admin network policy %s: %s already exists", policy.Name, t.BANP.Name)
+	}
+	t.BANP = policy
+
+	_, err := t.Kubernetes.CreateBaselineAdminNetworkPolicy(context.TODO(), policy)
+	if err != nil {
+		return err
+	}
+	t.createdAdminNetworkPolicies().BANP = policy.Name
+	return nil
+}
+
+func (t *TestCaseState) UpdateBaselineAdminNetworkPolicy(policy *v1alpha1.BaselineAdminNetworkPolicy) error {
+	if t.BANP == nil || t.BANP.Name != policy.Name {
+		return errors.Errorf("cannot update baseline admin network policy %s: not found", policy.Name)
+	}
+	t.BANP = policy
+
+	_, err := t.Kubernetes.UpdateBaselineAdminNetworkPolicy(context.TODO(), policy)
+	return err
+}
+
+func (t *TestCaseState) DeleteBaselineAdminNetworkPolicy(name string) error {
+	if t.BANP == nil || t.BANP.Name != name {
+		return errors.Errorf("cannot delete baseline admin network policy %s: not found", name)
+	}
+	t.BANP = nil
+
+	err := t.Kubernetes.DeleteBaselineAdminNetworkPolicy(context.TODO(), name)
+	if err != nil {
+		return err
+	}
+	t.createdAdminNetworkPolicies().BANP = ""
+	return nil
+}
+
 func (t *TestCaseState) CreateNamespace(ns string, labels map[string]string) error {
 	newResources, err := t.Resources.CreateNamespace(ns, labels)
 	if err != nil {
@@ -314,9 +420,35 @@ func (t *TestCaseState) ResetClusterState() error {
 		return err
 	}
 
+	err = t.deleteCreatedAdminNetworkPolicies()
+	if err != nil {
+		return err
+	}
+
 	return t.resetLabelsInKubeHelper()
 }
 
+// deleteCreatedAdminNetworkPolicies deletes the ANPs and BANP left behind by earlier test cases, and leaves any
+// other admin network policies in the cluster alone
+func (t *TestCaseState) deleteCreatedAdminNetworkPolicies() error {
+	created := t.createdAdminNetworkPolicies()
+	for _, name := range slice.Sort(maps.Keys(created.ANPs)) {
+		err := t.Kubernetes.DeleteAdminNetworkPolicy(context.TODO(), name)
+		if err != nil {
+			return err
+		}
+		delete(created.ANPs, name)
+	}
+	if created.BANP != "" {
+		err := t.Kubernetes.DeleteBaselineAdminNetworkPolicy(context.TODO(), created.BANP)
+		if err != nil {
+			return err
+		}
+		created.BANP = ""
+	}
+	return nil
+}
+
 func (t *TestCaseState) VerifyClusterState() error {
 	err := t.verifyClusterStateHelper()
 	if err != nil {
@@ -330,5 +462,23 @@ func (t *TestCaseState) VerifyClusterState() error {
 	if len(policies) > 0 {
 		return errors.Errorf("expected 0 policies in namespaces %+v, found %d", t.Resources.NamespacesSlice(), len(policies))
 	}
+
+	anps, banp, err := kube.GetAdminNetworkPoliciesIfInstalled(context.TODO(), t.Kubernetes)
+	if err != nil {
+		return err
+	}
+	created := t.createdAdminNetworkPolicies()
+	for _, anp := range anps {
+		if created.ANPs[anp.Name] {
+			return errors.Errorf("expected admin network policy %s to have been deleted", anp.Name)
+		}
+		logrus.Warnf("found admin network policy %s which no test case created", anp.Name)
+	}
+	if banp != nil {
+		if banp.Name == created.BANP {
+			return errors.Errorf("expected baseline admin network policy %s to have been deleted", banp.Name)
+		}
+		logrus.Warnf("found baseline admin network policy %s which no test case created", banp.Name)
+	}
 	return nil
 }
@@ -1,9 +1,15 @@
 package connectivity
 
 import (
+	"context"
 	"fmt"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/network-policy-api/apis/v1alpha1"
+	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/connectivity/probe"
+	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/kube"
 )
 
 type buildLabelDiffCase struct {
@@ -103,4 +109,61 @@ func RunTestCaseStateTests() {
 			}
 		})
 	})
+
+	Describe("TestCaseState admin network policies", func() {
+		anp := func(name string, priority int32) *v1alpha1.AdminNetworkPolicy {
+			return &v1alpha1.AdminNetworkPolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: name},
+				Spec:       v1alpha1.AdminNetworkPolicySpec{Priority: priority},
+			}
+		}
+		banp := &v1alpha1.BaselineAdminNetworkPolicy{ObjectMeta: metav1.ObjectMeta{Name: "default"}}
+
+		It("Should track ANPs and the BANP in the state and in kube", func() {
+			mock := kube.NewMockKubernetes(1.0)
+			state := &TestCaseState{Kubernetes: mock, Resources: &probe.Resources{Namespaces: map[string]map[string]string{}}}
+
+			Expect(state.CreateAdminNetworkPolicy(anp("a", 1))).To(Succeed())
+			Expect(state.CreateAdminNetworkPolicy(anp("b", 2))).To(Succeed())
+			Expect(state.CreateAdminNetworkPolicy(anp("a", 3))).NotTo(Succeed())
+			Expect(state.UpdateAdminNetworkPolicy(anp("a", 4))).To(Succeed())
+			Expect(state.UpdateAdminNetworkPolicy(anp("c", 4))).NotTo(Succeed())
+			Expect(state.DeleteAdminNetworkPolicy("b")).To(Succeed())
+			Expect(state.DeleteAdminNetworkPolicy("b")).NotTo(Succeed())
+			Expect(state.ANPs).To(Equal([]*v1alpha1.AdminNetworkPolicy{anp("a", 4)}))
+			Expect(mock.AdminNetworkPolicies).To(Equal([]v1alpha1.AdminNetworkPolicy{*anp("a", 4)}))
+
+			Expect(state.CreateBaselineAdminNetworkPolicy(banp)).To(Succeed())
+			Expect(state.CreateBaselineAdminNetworkPolicy(banp)).NotTo(Succeed())
+			Expect(mock.BaselineNetworkPolicy).To(Equal(banp))
+			Expect(state.VerifyClusterState()).NotTo(Succeed())
+
+			Expect(state.ResetClusterState()).To(Succeed())
+			Expect(mock.AdminNetworkPolicies).To(BeEmpty())
+			Expect(mock.BaselineNetworkPolicy).To(BeNil())
+			Expect(state.VerifyClusterState()).To(Succeed())
+		})
+
+		It("Should only reset the ANPs and BANP created by earlier test cases", func() {
+			mock := kube.NewMockKubernetes(1.0)
+			resources := &probe.Resources{Namespaces: map[string]map[string]string{}}
+			_, err := mock.CreateAdminNetworkPolicy(context.TODO(), anp("pre-existing", 5))
+			Expect(err).NotTo(HaveOccurred())
+
+			created := NewCreatedAdminNetworkPolicies()
+			first := &TestCaseState{Kubernetes: mock, Resources: resources, Created: created}
+			Expect(first.VerifyClusterState()).To(Succeed())
+			Expect(first.CreateAdminNetworkPolicy(anp("a", 1))).To(Succeed())
+			Expect(first.CreateAdminNetworkPolicy(anp("b", 2))).To(Succeed())
+			Expect(first.DeleteAdminNetworkPolicy("b")).To(Succeed())
+			Expect(first.CreateBaselineAdminNetworkPolicy(banp)).To(Succeed())
+
+			second := &TestCaseState{Kubernetes: mock, Resources: resources, Created: created}
+			Expect(second.VerifyClusterState()).NotTo(Succeed())
+			Expect(second.ResetClusterState()).To(Succeed())
+			Expect(mock.AdminNetworkPolicies).To(Equal([]v1alpha1.AdminNetworkPolicy{*anp("pre-existing", 5)}))
+			Expect(mock.BaselineNetworkPolicy).To(BeNil())
+			Expect(second.VerifyClusterState()).To(Succeed())
+		})
+	})
 }
@@ -0,0 +1,286 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/network-policy-api/apis/v1alpha1"
+)
+
+/*
+Admin policies, all with pod x/a as their subject:
+ - priority: lower priority values win, and within an ANP the first matching rule wins
+ - pass: falls through to NPv1, and then to the BANP
+ - ANP overrides NPv1 in both directions: Deny over Allow, Allow over Deny
+ - port ranges
+ - named ports
+*/
+
+var (
+	anpSubjectXA = v1alpha1.AdminNetworkPolicySubject{
+		Pods: &v1alpha1.NamespacedPod{
+			NamespaceSelector: *nsXMatchLabelsSelector,
+			PodSelector:       *podAMatchLabelsSelector,
+		},
+	}
+	netpolTargetXA = NewNetpolTarget("x", map[string]string{"pod": "a"}, nil)
+
+	nsYMatchLabelsSelector = &metav1.LabelSelector{MatchLabels: map[string]string{"ns": "y"}}
+	// testNamespacesSelector only selects namespaces with the 'ns' label, so that admin policies on egress don't block DNS
+	testNamespacesSelector = &metav1.LabelSelector{
+		MatchExpressions: []metav1.LabelSelectorRequirement{
+			{
+				Key:      "ns",
+				Operator: metav1.LabelSelectorOpExists,
+			},
+		},
+	}
+)
+
+// AdminRule is an ANP or BANP rule in either direction, selecting its peers by namespace
+type AdminRule struct {
+	Action     v1alpha1.AdminNetworkPolicyRuleAction
+	Namespaces *metav1.LabelSelector
+	Ports      []v1alpha1.AdminNetworkPolicyPort
+}
+
+func (r *AdminRule) name(index int) string {
+	return fmt.Sprintf("%s-%d", strings.ToLower(string(r.Action)), index)
+}
+
+func (r *AdminRule) ports() *[]v1alpha1.AdminNetworkPolicyPort {
+	if r.Ports == nil {
+		return nil
+	}
+	return &r.Ports
+}
+
+func BuildANP(name string, priority int32, isIngress bool, rules ...*AdminRule) *v1alpha1.AdminNetworkPolicy {
+	anp := &v1alpha1.AdminNetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: v1alpha1.AdminNetworkPolicySpec{
+			Priority: priority,
+			Subject:  anpSubjectXA,
+		},
+	}
+	for i, rule := range rules {
+		if isIngress {
+			anp.Spec.Ingress = append(anp.Spec.Ingress, v1alpha1.AdminNetworkPolicyIngressRule{
+				Name:   rule.name(i),
+				Action: rule.Action,
+				From:   []v1alpha1.AdminNetworkPolicyIngressPeer{{Namespaces: rule.Namespaces}},
+				Ports:  rule.ports(),
+			})
+		} else {
+			anp.Spec.Egress = append(anp.Spec.Egress, v1alpha1.AdminNetworkPolicyEgressRule{
+				Name:   rule.name(i),
+				Action: rule.Action,
+				To:     []v1alpha1.AdminNetworkPolicyEgressPeer{{Namespaces: rule.Namespaces}},
+				Ports:  rule.ports(),
+			})
+		}
+	}
+	return anp
+}
+
+// BuildBANP builds the BANP named 'default' -- the only name a BANP may have
+func BuildBANP(isIngress bool, rules ...*AdminRule) *v1alpha1.BaselineAdminNetworkPolicy {
+	banp := &v1alpha1.BaselineAdminNetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "default"},
+		Spec:       v1alpha1.BaselineAdminNetworkPolicySpec{Subject: anpSubjectXA},
+	}
+	for i, rule := range rules {
+		if rule.Action == v1alpha1.AdminNetworkPolicyRuleActionPass {
+			panic(errors.Errorf("invalid BANP action %s", rule.Action))
+		}
+		action := v1alpha1.BaselineAdminNetworkPolicyRuleAction(rule.Action)
+		if isIngress {
+			banp.Spec.Ingress = append(banp.Spec.Ingress, v1alpha1.BaselineAdminNetworkPolicyIngressRule{
+				Name:   rule.name(i),
+				Action: action,
+				From:   []v1alpha1.AdminNetworkPolicyIngressPeer{{Namespaces: rule.Namespaces}},
+				Ports:  rule.ports(),
+			})
+		} else {
+			banp.Spec.Egress = append(banp.Spec.Egress, v1alpha1.BaselineAdminNetworkPolicyEgressRule{
+				Name:   rule.name(i),
+				Action: action,
+				To:     []v1alpha1.BaselineAdminNetworkPolicyEgressPeer{{Namespaces: rule.Namespaces}},
+				Ports:  rule.ports(),
+			})
+		}
+	}
+	return banp
+}
+
+func allowRule(namespaces *metav1.LabelSelector, ports ...v1alpha1.AdminNetworkPolicyPort) *AdminRule {
+	return &AdminRule{Action: v1alpha1.AdminNetworkPolicyRuleActionAllow, Namespaces: namespaces, Ports: ports}
+}
+
+func denyRule(namespaces *metav1.LabelSelector, ports ...v1alpha1.AdminNetworkPolicyPort) *AdminRule {
+	return &AdminRule{Action: v1alpha1.AdminNetworkPolicyRuleActionDeny, Namespaces: namespaces, Ports: ports}
+}
+
+func passRule(namespaces *metav1.LabelSelector) *AdminRule {
+	return &AdminRule{Action: v1alpha1.AdminNetworkPolicyRuleActionPass, Namespaces: namespaces}
+}
+
+// npv1Actions creates an NPv1 policy for pod x/a, plus an NPv1 policy allowing DNS if on egress
+func (t *TestCaseGenerator) npv1Actions(isIngress bool, peers *NetpolPeers) []*Action {
+	if isIngress {
+		return []*Action{CreatePolicy((&Netpol{Name: "npv1-ingress", Target: netpolTargetXA, Ingress: peers}).NetworkPolicy())}
+	}
+	actions := []*Action{CreatePolicy((&Netpol{Name: "npv1-egress", Target: netpolTargetXA, Egress: peers}).NetworkPolicy())}
+	if t.AllowDNS {
+		actions = append(actions, CreatePolicy(AllowDNSPolicy(netpolTargetXA).NetworkPolicy()))
+	}
+	return actions
+}
+
+func (t *TestCaseGenerator) ANPPriorityTestCases() []*TestCase {
+	var cases []*TestCase
+	for _, isIngress := range []bool{false, true} {
+		dir := describeDirectionality(isIngress)
+		tags := NewStringSet(dir, TagANPPriority)
+		cases = append(cases,
+			NewSingleStepTestCase(fmt.Sprintf("%s: ANP deny at higher priority than ANP allow", dir), tags, ProbeAllAvailable,
+				CreateAdminNetworkPolicy(BuildANP("allow-all", 20, isIngress, allowRule(testNamespacesSelector))),
+				CreateAdminNetworkPolicy(BuildANP("deny-all", 10, isIngress, denyRule(testNamespacesSelector)))),
+			NewSingleStepTestCase(fmt.Sprintf("%s: ANP allow at higher priority than ANP deny", dir), tags, ProbeAllAvailable,
+				CreateAdminNetworkPolicy(BuildANP("allow-all", 10, isIngress, allowRule(testNamespacesSelector))),
+				CreateAdminNetworkPolicy(BuildANP("deny-all", 20, isIngress, denyRule(testNamespacesSelector)))),
+			NewSingleStepTestCase(fmt.Sprintf("%s: first matching rule of an ANP wins", dir), tags, ProbeAllAvailable,
+				CreateAdminNetworkPolicy(BuildANP("allow-y-deny-all", 10, isIngress, allowRule(nsYMatchLabelsSelector), denyRule(testNamespacesSelector)))))
+	}
+	cases = append(cases, NewTestCase("ingress: update ANP priority",
+		NewStringSet(TagIngress, TagANPPriority),
+		NewTestStep(ProbeAllAvailable,
+			CreateAdminNetworkPolicy(BuildANP("allow-all", 10, true, allowRule(testNamespacesSelector))),
+			CreateAdminNetworkPolicy(BuildANP("deny-all", 20, true, denyRule(testNamespacesSelector)))),
+		NewTestStep(ProbeAllAvailable,
+			UpdateAdminNetworkPolicy(BuildANP("allow-all", 30, true, allowRule(testNamespacesSelector)))),
+		NewTestStep(ProbeAllAvailable,
+			DeleteAdminNetworkPolicy("deny-all"))))
+	return cases
+}
+
+func (t *TestCaseGenerator) ANPPassTestCases() []*TestCase {
+	allowY := &NetpolPeers{Rules: []*Rule{{Peers: []networkingv1.NetworkPolicyPeer{{NamespaceSelector: nsYMatchLabelsSelector}}}}}
+	allowYOnPort80 := &NetpolPeers{Rules: []*Rule{{
+		Ports: []networkingv1.NetworkPolicyPort{{Protocol: &tcp, Port: &port80}},
+		Peers: []networkingv1.NetworkPolicyPeer{{NamespaceSelector: nsYMatchLabelsSelector}},
+	}}}
+
+	var cases []*TestCase
+	for _, isIngress := range []bool{false, true} {
+		dir := describeDirectionality(isIngress)
+		cases = append(cases,
+			NewSingleStepTestCase(fmt.Sprintf("%s: ANP pass falls through to NPv1", dir),
+				NewStringSet(dir, TagANPPass),
+				ProbeAllAvailable,
+				append([]*Action{
+					CreateAdminNetworkPolicy(BuildANP("pass-y-deny-all", 10, isIngress, passRule(nsYMatchLabelsSelector), denyRule(testNamespacesSelector))),
+				}, t.npv1Actions(isIngress, allowYOnPort80)...)...),
+			NewSingleStepTestCase(fmt.Sprintf("%s: ANP pass falls through to BANP", dir),
+				NewStringSet(dir, TagANPPass, TagBANP),
+				ProbeAllAvailable,
+				CreateAdminNetworkPolicy(BuildANP("pass-y-allow-all", 10, isIngress, passRule(nsYMatchLabelsSelector), allowRule(testNamespacesSelector))),
+				CreateBaselineAdminNetworkPolicy(BuildBANP(isIngress, denyRule(testNamespacesSelector)))),
+			NewSingleStepTestCase(fmt.Sprintf("%s: NPv1 takes precedence over BANP after ANP pass", dir),
+				NewStringSet(dir, TagANPPass, TagBANP),
+				ProbeAllAvailable,
+				append([]*Action{
+					CreateAdminNetworkPolicy(BuildANP("pass-all", 10, isIngress, passRule(testNamespacesSelector))),
+					CreateBaselineAdminNetworkPolicy(BuildBANP(isIngress, allowRule(testNamespacesSelector))),
+				}, t.npv1Actions(isIngress, allowY)...)...))
+	}
+	return cases
+}
+
+func (t *TestCaseGenerator) ANPOverridesNPv1TestCases() []*TestCase {
+	var cases []*TestCase
+	for _, isIngress := range []bool{false, true} {
+		dir := describeDirectionality(isIngress)
+		tags := NewStringSet(dir, TagANPOverridesNPv1)
+		cases = append(cases,
+			NewSingleStepTestCase(fmt.Sprintf("%s: ANP deny overrides NPv1 allow", dir), tags, ProbeAllAvailable,
+				append([]*Action{
+					CreateAdminNetworkPolicy(BuildANP("deny-y", 10, isIngress, denyRule(nsYMatchLabelsSelector))),
+				}, t.npv1Actions(isIngress, ExplicitAllowAll)...)...),
+			NewSingleStepTestCase(fmt.Sprintf("%s: ANP allow overrides NPv1 deny", dir), tags, ProbeAllAvailable,
+				append([]*Action{
+					CreateAdminNetworkPolicy(BuildANP("allow-y", 10, isIngress, allowRule(nsYMatchLabelsSelector))),
+				}, t.npv1Actions(isIngress, DenyAll)...)...))
+	}
+	return cases
+}
+
+func (t *TestCaseGenerator) ANPPortRangeTestCases() []*TestCase {
+	portRanges := []*v1alpha1.PortRange{
+		{Protocol: tcp, Start: 80, End: 81},
+		{Protocol: udp, Start: 81, End: 90},
+		{Protocol: sctp, Start: 79, End: 80},
+	}
+
+	var cases []*TestCase
+	for _, isIngress := range []bool{false, true} {
+		dir := describeDirectionality(isIngress)
+		for _, portRange := range portRanges {
+			protocol := portRange.Protocol
+			tags := NewStringSet(dir, TagANPPortRange, *describeProtocol(&protocol))
+			cases = append(cases, NewSingleStepTestCase(
+				fmt.Sprintf("%s: ANP deny on port range %s %d-%d", dir, portRange.Protocol, portRange.Start, portRange.End),
+				tags,
+				ProbeAllAvailable,
+				CreateAdminNetworkPolicy(BuildANP("deny-port-range", 10, isIngress, denyRule(testNamespacesSelector, v1alpha1.AdminNetworkPolicyPort{PortRange: portRange})))))
+		}
+	}
+	return cases
+}
+
+func (t *TestCaseGenerator) ANPNamedPortTestCases() []*TestCase {
+	namedPorts := []struct {
+		Name     string
+		Protocol v1.Protocol
+	}{
+		{Name: portServe80TCP.StrVal, Protocol: tcp},
+		{Name: portServe81UDP.StrVal, Protocol: udp},
+		{Name: portServe80SCTP.StrVal, Protocol: sctp},
+	}
+
+	var cases []*TestCase
+	for _, isIngress := range []bool{false, true} {
+		dir := describeDirectionality(isIngress)
+		for _, namedPort := range namedPorts {
+			name, protocol := namedPort.Name, namedPort.Protocol
+			cases = append(cases, NewSingleStepTestCase(
+				fmt.Sprintf("%s: ANP deny on named port %s", dir, name),
+				NewStringSet(dir, TagANPNamedPort, *describeProtocol(&protocol)),
+				ProbeAllAvailable,
+				CreateAdminNetworkPolicy(BuildANP("deny-named-port", 10, isIngress, denyRule(testNamespacesSelector, v1alpha1.AdminNetworkPolicyPort{NamedPort: &name})))))
+		}
+
+		allowName := portServe81TCP.StrVal
+		cases = append(cases, NewSingleStepTestCase(
+			fmt.Sprintf("%s: ANP allow on named port %s, deny everything else", dir, allowName),
+			NewStringSet(dir, TagANPNamedPort, TagTCPProtocol),
+			ProbeAllAvailable,
+			CreateAdminNetworkPolicy(BuildANP("allow-named-port-deny-all", 10, isIngress,
+				allowRule(testNamespacesSelector, v1alpha1.AdminNetworkPolicyPort{NamedPort: &allowName}),
+				denyRule(testNamespacesSelector)))))
+	}
+	return cases
+}
+
+func (t *TestCaseGenerator) ANPTestCases() []*TestCase {
+	return flatten(
+		t.ANPPriorityTestCases(),
+		t.ANPPassTestCases(),
+		t.ANPOverridesNPv1TestCases(),
+		t.ANPPortRangeTestCases(),
+		t.ANPNamedPortTestCases())
+}
@@ -11,6 +11,14 @@ const (
 	ActionFeatureUpdatePolicy = "action: update policy"
 	ActionFeatureDeletePolicy = "action: delete policy"
 
+	ActionFeatureCreateAdminNetworkPolicy = "action: create admin network policy"
+	ActionFeatureUpdateAdminNetworkPolicy = "action: update admin network policy"
+	ActionFeatureDeleteAdminNetworkPolicy = "action: delete admin network policy"
+
+	ActionFeatureCreateBaselineAdminNetworkPolicy = "action: create baseline admin network policy"
+	ActionFeatureUpdateBaselineAdminNetworkPolicy = "action: update baseline admin network policy"
+	ActionFeatureDeleteBaselineAdminNetworkPolicy = "action: delete baseline admin network policy"
+
 	ActionFeatureCreateNamespace    = "action: create namespace"
 	ActionFeatureSetNamespaceLabels = "action: set namespace labels"
 	ActionFeatureDeleteNamespace    = "action: delete namespace"
@@ -0,0 +1,363 @@
+package generator
+
+import (
+	"fmt"
+	"math/rand"
+
+	v1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/network-policy-api/apis/v1alpha1"
+)
+
+const (
+	// fuzzLabel is added to namespaces and pods by label perturbations, and selected by some generated peers
+	fuzzLabel      = "fuzz"
+	fuzzLabelValue = "on"
+)
+
+// Fuzzer builds random, valid combinations of NPv1, ANP and BANP policies, followed by namespace and
+// pod label perturbations.  Each test case is built from a single seed, so that it can be replayed.
+type Fuzzer struct {
+	AllowDNS   bool
+	Namespaces []string
+	Pods       []string
+}
+
+func NewFuzzer(allowDNS bool, namespaces []string, pods []string) *Fuzzer {
+	return &Fuzzer{
+		AllowDNS:   allowDNS,
+		Namespaces: namespaces,
+		Pods:       pods,
+	}
+}
+
+// FuzzTestCases builds count test cases from the consecutive seeds starting at seed
+func (f *Fuzzer) FuzzTestCases(seed int64, count int) []*TestCase {
+	var cases []*TestCase
+	for i := 0; i < count; i++ {
+		cases = append(cases, f.FuzzTestCase(seed+int64(i)))
+	}
+	return cases
+}
+
+// FuzzTestCase builds the test case for a seed: the same seed always produces the same test case
+func (f *Fuzzer) FuzzTestCase(seed int64) *TestCase {
+	r := &fuzzRand{Rand: rand.New(rand.NewSource(seed)), fuzzer: f}
+	tags := NewStringSet(TagFuzz, TagCreatePolicy)
+
+	var policyActions []*Action
+	for i, n := 0, r.between(0, 2); i < n; i++ {
+		isIngress, isEgress := r.directions()
+		netpol := &Netpol{Name: fmt.Sprintf("fuzz-npv1-%d", i), Target: r.netpolTarget()}
+		if isIngress {
+			netpol.Ingress = r.netpolPeers()
+			tags.Add(TagIngress)
+		}
+		if isEgress {
+			netpol.Egress = r.netpolPeers()
+			tags.Add(TagEgress)
+			if f.AllowDNS {
+				dns := AllowDNSPolicy(netpol.Target)
+				dns.Name = fmt.Sprintf("fuzz-npv1-%d-allow-dns", i)
+				policyActions = append(policyActions, CreatePolicy(dns.NetworkPolicy()))
+			}
+		}
+		policyActions = append(policyActions, CreatePolicy(netpol.NetworkPolicy()))
+	}
+
+	// ANPs with the same priority and overlapping subjects have undefined precedence, so priorities are distinct
+	priorities := r.Perm(10)
+	for i, n := 0, r.between(0, 2); i < n; i++ {
+		isIngress, isEgress := r.directions()
+		anp := &v1alpha1.AdminNetworkPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("fuzz-anp-%d", i)},
+			Spec: v1alpha1.AdminNetworkPolicySpec{
+				Priority: int32(priorities[i]),
+				Subject:  r.adminSubject(),
+			},
+		}
+		if isIngress {
+			for j, rule := range r.adminRules(true) {
+				anp.Spec.Ingress = append(anp.Spec.Ingress, v1alpha1.AdminNetworkPolicyIngressRule{
+					Name:   rule.name(j),
+					Action: rule.Action,
+					From:   rule.ingressPeers(),
+					Ports:  rule.ports(),
+				})
+			}
+			tags.Add(TagIngress)
+		}
+		if isEgress {
+			for j, rule := range r.adminRules(true) {
+				anp.Spec.Egress = append(anp.Spec.Egress, v1alpha1.AdminNetworkPolicyEgressRule{
+					Name:   rule.name(j),
+					Action: rule.Action,
+					To:     rule.egressPeers(),
+					Ports:  rule.ports(),
+				})
+			}
+			tags.Add(TagEgress)
+		}
+		policyActions = append(policyActions, CreateAdminNetworkPolicy(anp))
+		tags.Add(TagANPPriority)
+	}
+
+	if r.Intn(2) == 0 {
+		isIngress, isEgress := r.directions()
+		banp := &v1alpha1.BaselineAdminNetworkPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "default"},
+			Spec:       v1alpha1.BaselineAdminNetworkPolicySpec{Subject: r.adminSubject()},
+		}
+		if isIngress {
+			for j, rule := range r.adminRules(false) {
+				banp.Spec.Ingress = append(banp.Spec.Ingress, v1alpha1.BaselineAdminNetworkPolicyIngressRule{
+					Name:   rule.name(j),
+					Action: v1alpha1.BaselineAdminNetworkPolicyRuleAction(rule.Action),
+					From:   rule.ingressPeers(),
+					Ports:  rule.ports(),
+				})
+			}
+			tags.Add(TagIngress)
+		}
+		if isEgress {
+			for j, rule := range r.adminRules(false) {
+				banp.Spec.Egress = append(banp.Spec.Egress, v1alpha1.BaselineAdminNetworkPolicyEgressRule{
+					Name:   rule.name(j),
+					Action: v1alpha1.BaselineAdminNetworkPolicyRuleAction(rule.Action),
+					To:     rule.baselineEgressPeers(),
+					Ports:  rule.ports(),
+				})
+			}
+			tags.Add(TagEgress)
+		}
+		policyActions = append(policyActions, CreateBaselineAdminNetworkPolicy(banp))
+		tags.Add(TagBANP)
+	}
+
+	steps := []*TestStep{NewTestStep(ProbeAllAvailable, policyActions...)}
+	for i, n := 0, r.between(0, 2); i < n; i++ {
+		ns := r.namespace()
+		if r.Intn(2) == 0 {
+			steps = append(steps, NewTestStep(ProbeAllAvailable, SetNamespaceLabels(ns, r.perturbLabels("ns", ns))))
+			tags.Add(TagSetNamespaceLabels)
+		} else {
+			pod := r.pod()
+			steps = append(steps, NewTestStep(ProbeAllAvailable, SetPodLabels(ns, pod, r.perturbLabels("pod", pod))))
+			tags.Add(TagSetPodLabels)
+		}
+	}
+
+	return NewTestCase(fmt.Sprintf("fuzz: seed %d", seed), tags, steps...)
+}
+
+// fuzzRand draws the pieces of a fuzzed test case from the namespaces and pods of the cluster
+type fuzzRand struct {
+	*rand.Rand
+	fuzzer *Fuzzer
+}
+
+// between returns a random int in [min, max]
+func (r *fuzzRand) between(min int, max int) int {
+	return min + r.Intn(max-min+1)
+}
+
+func (r *fuzzRand) namespace() string {
+	return r.fuzzer.Namespaces[r.Intn(len(r.fuzzer.Namespaces))]
+}
+
+func (r *fuzzRand) pod() string {
+	return r.fuzzer.Pods[r.Intn(len(r.fuzzer.Pods))]
+}
+
+// directions returns whether to build ingress and egress: at least one of them is true
+func (r *fuzzRand) directions() (bool, bool) {
+	switch r.Intn(3) {
+	case 0:
+		return true, false
+	case 1:
+		return false, true
+	default:
+		return true, true
+	}
+}
+
+// perturbLabels keeps the label used to identify the namespace or pod, and toggles the fuzz label
+func (r *fuzzRand) perturbLabels(key string, value string) map[string]string {
+	labels := map[string]string{key: value}
+	if r.Intn(2) == 0 {
+		labels[fuzzLabel] = fuzzLabelValue
+	}
+	return labels
+}
+
+// namespaceSelector only selects namespaces with the 'ns' or fuzz label, so that egress
+// admin policies never select -- and therefore never block -- DNS
+func (r *fuzzRand) namespaceSelector() *metav1.LabelSelector {
+	switch r.Intn(4) {
+	case 0:
+		return testNamespacesSelector
+	case 1:
+		return &metav1.LabelSelector{MatchLabels: map[string]string{"ns": r.namespace()}}
+	case 2:
+		return &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{
+			Key:      "ns",
+			Operator: metav1.LabelSelectorOpIn,
+			Values:   []string{r.namespace(), r.namespace()},
+		}}}
+	default:
+		return &metav1.LabelSelector{MatchLabels: map[string]string{fuzzLabel: fuzzLabelValue}}
+	}
+}
+
+func (r *fuzzRand) podSelector() *metav1.LabelSelector {
+	switch r.Intn(4) {
+	case 0:
+		return emptySelector
+	case 1:
+		return &metav1.LabelSelector{MatchLabels: map[string]string{"pod": r.pod()}}
+	case 2:
+		return &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{
+			Key:      "pod",
+			Operator: metav1.LabelSelectorOpNotIn,
+			Values:   []string{r.pod()},
+		}}}
+	default:
+		return &metav1.LabelSelector{MatchLabels: map[string]string{fuzzLabel: fuzzLabelValue}}
+	}
+}
+
+func (r *fuzzRand) netpolTarget() *NetpolTarget {
+	return &NetpolTarget{Namespace: r.namespace(), PodSelector: *r.podSelector()}
+}
+
+func (r *fuzzRand) netpolPeers() *NetpolPeers {
+	peers := &NetpolPeers{}
+	for i, n := 0, r.between(0, 2); i < n; i++ {
+		rule := &Rule{}
+		for j, n := 0, r.between(0, 2); j < n; j++ {
+			rule.Ports = append(rule.Ports, r.netpolPort())
+		}
+		for j, n := 0, r.between(0, 2); j < n; j++ {
+			rule.Peers = append(rule.Peers, r.netpolPeer())
+		}
+		peers.Rules = append(peers.Rules, rule)
+	}
+	return peers
+}
+
+func (r *fuzzRand) netpolPeer() networkingv1.NetworkPolicyPeer {
+	switch r.Intn(3) {
+	case 0:
+		return networkingv1.NetworkPolicyPeer{PodSelector: r.podSelector()}
+	case 1:
+		return networkingv1.NetworkPolicyPeer{NamespaceSelector: r.namespaceSelector()}
+	default:
+		return networkingv1.NetworkPolicyPeer{PodSelector: r.podSelector(), NamespaceSelector: r.namespaceSelector()}
+	}
+}
+
+func (r *fuzzRand) protocol() *v1.Protocol {
+	return []*v1.Protocol{&tcp, &udp, &sctp}[r.Intn(3)]
+}
+
+func (r *fuzzRand) netpolPort() networkingv1.NetworkPolicyPort {
+	switch r.Intn(3) {
+	case 0:
+		return networkingv1.NetworkPolicyPort{Protocol: r.protocol()}
+	case 1:
+		port := intstr.FromInt(80 + r.Intn(2))
+		return networkingv1.NetworkPolicyPort{Protocol: r.protocol(), Port: &port}
+	default:
+		namedPort := []intstr.IntOrString{portServe80TCP, portServe81TCP, portServe80UDP, portServe81UDP, portServe80SCTP, portServe81SCTP}[r.Intn(6)]
+		return networkingv1.NetworkPolicyPort{Port: &namedPort}
+	}
+}
+
+func (r *fuzzRand) namespacedPod() *v1alpha1.NamespacedPod {
+	return &v1alpha1.NamespacedPod{
+		NamespaceSelector: *r.namespaceSelector(),
+		PodSelector:       *r.podSelector(),
+	}
+}
+
+func (r *fuzzRand) adminSubject() v1alpha1.AdminNetworkPolicySubject {
+	if r.Intn(2) == 0 {
+		return v1alpha1.AdminNetworkPolicySubject{Namespaces: r.namespaceSelector()}
+	}
+	return v1alpha1.AdminNetworkPolicySubject{Pods: r.namespacedPod()}
+}
+
+// adminRules builds 1 to 3 rules; Pass is only allowed in ANPs
+func (r *fuzzRand) adminRules(allowPass bool) []*fuzzAdminRule {
+	actions := []v1alpha1.AdminNetworkPolicyRuleAction{v1alpha1.AdminNetworkPolicyRuleActionAllow, v1alpha1.AdminNetworkPolicyRuleActionDeny}
+	if allowPass {
+		actions = append(actions, v1alpha1.AdminNetworkPolicyRuleActionPass)
+	}
+	var rules []*fuzzAdminRule
+	for i, n := 0, r.between(1, 3); i < n; i++ {
+		rule := &fuzzAdminRule{AdminRule: AdminRule{Action: actions[r.Intn(len(actions))]}}
+		for j, n := 0, r.between(0, 1); j < n; j++ {
+			rule.Ports = append(rule.Ports, r.adminPort())
+		}
+		for j, n := 0, r.between(1, 2); j < n; j++ {
+			if r.Intn(2) == 0 {
+				rule.peers = append(rule.peers, fuzzAdminPeer{namespaces: r.namespaceSelector()})
+			} else {
+				rule.peers = append(rule.peers, fuzzAdminPeer{pods: r.namespacedPod()})
+			}
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+func (r *fuzzRand) adminPort() v1alpha1.AdminNetworkPolicyPort {
+	switch r.Intn(3) {
+	case 0:
+		return v1alpha1.AdminNetworkPolicyPort{PortNumber: &v1alpha1.Port{Protocol: *r.protocol(), Port: int32(80 + r.Intn(2))}}
+	case 1:
+		// ranges must have end > start
+		start := int32(79 + r.Intn(3))
+		return v1alpha1.AdminNetworkPolicyPort{PortRange: &v1alpha1.PortRange{Protocol: *r.protocol(), Start: start, End: start + 1 + int32(r.Intn(2))}}
+	default:
+		namedPort := []intstr.IntOrString{portServe80TCP, portServe81TCP, portServe80UDP, portServe81UDP, portServe80SCTP, portServe81SCTP}[r.Intn(6)].StrVal
+		return v1alpha1.AdminNetworkPolicyPort{NamedPort: &namedPort}
+	}
+}
+
+// fuzzAdminRule extends AdminRule with peers selecting pods as well as namespaces
+type fuzzAdminRule struct {
+	AdminRule
+	peers []fuzzAdminPeer
+}
+
+type fuzzAdminPeer struct {
+	namespaces *metav1.LabelSelector
+	pods       *v1alpha1.NamespacedPod
+}
+
+func (r *fuzzAdminRule) ingressPeers() []v1alpha1.AdminNetworkPolicyIngressPeer {
+	var peers []v1alpha1.AdminNetworkPolicyIngressPeer
+	for _, peer := range r.peers {
+		peers = append(peers, v1alpha1.AdminNetworkPolicyIngressPeer{Namespaces: peer.namespaces, Pods: peer.pods})
+	}
+	return peers
+}
+
+func (r *fuzzAdminRule) egressPeers() []v1alpha1.AdminNetworkPolicyEgressPeer {
+	var peers []v1alpha1.AdminNetworkPolicyEgressPeer
+	for _, peer := range r.peers {
+		peers = append(peers, v1alpha1.AdminNetworkPolicyEgressPeer{Namespaces: peer.namespaces, Pods: peer.pods})
+	}
+	return peers
+}
+
+func (r *fuzzAdminRule) baselineEgressPeers() []v1alpha1.BaselineAdminNetworkPolicyEgressPeer {
+	var peers []v1alpha1.BaselineAdminNetworkPolicyEgressPeer
+	for _, peer := range r.peers {
+		peers = append(peers, v1alpha1.BaselineAdminNetworkPolicyEgressPeer{Namespaces: peer.namespaces, Pods: peer.pods})
+	}
+	return peers
+}
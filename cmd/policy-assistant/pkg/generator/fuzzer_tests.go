@@ -0,0 +1,88 @@
+package generator
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	networkingv1 "k8s.io/api/networking/v1"
+	"sigs.k8s.io/network-policy-api/apis/v1alpha1"
+	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/matcher"
+)
+
+func RunFuzzerTests() {
+	Describe("Fuzzer", func() {
+		fuzzer := NewFuzzer(true, []string{"x", "y", "z"}, []string{"a", "b", "c"})
+
+		It("Builds the same test case from the same seed", func() {
+			Expect(fuzzer.FuzzTestCase(17)).To(Equal(fuzzer.FuzzTestCase(17)))
+			Expect(fuzzer.FuzzTestCases(100, 3)[2]).To(Equal(fuzzer.FuzzTestCase(102)))
+		})
+
+		It("Shrinks a failing test case to a minimal failing policy set", func() {
+			hasANPDeny := func(testCase *TestCase) bool {
+				for _, step := range testCase.Steps {
+					for _, action := range step.Actions {
+						if action.CreateAdminNetworkPolicy == nil {
+							continue
+						}
+						for _, rule := range action.CreateAdminNetworkPolicy.Policy.Spec.Ingress {
+							if rule.Action == v1alpha1.AdminNetworkPolicyRuleActionDeny {
+								return true
+							}
+						}
+					}
+				}
+				return false
+			}
+
+			for _, testCase := range fuzzer.FuzzTestCases(0, 200) {
+				if !hasANPDeny(testCase) {
+					continue
+				}
+				minimal := Shrink(testCase, hasANPDeny)
+				Expect(minimal.Steps).To(HaveLen(1))
+				Expect(minimal.Steps[0].Actions).To(HaveLen(1))
+				anp := minimal.Steps[0].Actions[0].CreateAdminNetworkPolicy.Policy
+				Expect(anp.Spec.Egress).To(BeEmpty())
+				Expect(anp.Spec.Ingress).To(HaveLen(1))
+				Expect(anp.Spec.Ingress[0].From).To(HaveLen(1))
+				Expect(anp.Spec.Ingress[0].Ports).To(BeNil())
+			}
+		})
+
+		It("Shrinks admin network policies without making them invalid", func() {
+			buildsAdminPolicies := func(testCase *TestCase) bool {
+				var netpols []*networkingv1.NetworkPolicy
+				var anps []*v1alpha1.AdminNetworkPolicy
+				var banp *v1alpha1.BaselineAdminNetworkPolicy
+				for _, step := range testCase.Steps {
+					for _, action := range step.Actions {
+						if action.CreatePolicy != nil {
+							netpols = append(netpols, action.CreatePolicy.Policy)
+						} else if action.CreateAdminNetworkPolicy != nil {
+							anps = append(anps, action.CreateAdminNetworkPolicy.Policy)
+						} else if action.CreateBaselineAdminNetworkPolicy != nil {
+							banp = action.CreateBaselineAdminNetworkPolicy.Policy
+						}
+					}
+				}
+				Expect(func() { matcher.BuildV1AndV2NetPols(false, netpols, anps, banp) }).NotTo(Panic())
+				return len(anps) > 0 || banp != nil
+			}
+
+			for _, testCase := range fuzzer.FuzzTestCases(0, 50) {
+				if !buildsAdminPolicies(testCase) {
+					continue
+				}
+				minimal := Shrink(testCase, buildsAdminPolicies)
+				Expect(minimal.Steps).To(HaveLen(1))
+				Expect(minimal.Steps[0].Actions).To(HaveLen(1))
+				if anp := minimal.Steps[0].Actions[0].CreateAdminNetworkPolicy; anp != nil {
+					Expect(len(anp.Policy.Spec.Ingress) + len(anp.Policy.Spec.Egress)).To(Equal(1))
+				} else {
+					banp := minimal.Steps[0].Actions[0].CreateBaselineAdminNetworkPolicy.Policy
+					Expect(len(banp.Spec.Ingress) + len(banp.Spec.Egress)).To(Equal(1))
+				}
+			}
+		})
+	})
+}
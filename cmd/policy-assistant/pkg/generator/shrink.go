@@ -0,0 +1,189 @@
+package generator
+
+import "sigs.k8s.io/network-policy-api/apis/v1alpha1"
+
+// Shrink minimizes a failing test case the way QuickCheck does: it tries smaller variants of the test case
+// -- with fewer steps, actions, rules, peers or ports -- and continues from the first one which still fails,
+// until none of the smaller variants fail.
+func Shrink(testCase *TestCase, fails func(*TestCase) bool) *TestCase {
+	for {
+		shrunk := false
+		for _, candidate := range ShrinkCandidates(testCase) {
+			if fails(candidate) {
+				testCase, shrunk = candidate, true
+				break
+			}
+		}
+		if !shrunk {
+			return testCase
+		}
+	}
+}
+
+// ShrinkCandidates returns the test cases one step smaller than testCase, starting with the largest reductions.
+// testCase is not modified.
+func ShrinkCandidates(testCase *TestCase) []*TestCase {
+	var candidates []*TestCase
+	withSteps := func(steps []*TestStep) *TestCase {
+		return NewTestCase(testCase.Description, testCase.Tags, steps...)
+	}
+	withActions := func(stepIndex int, actions []*Action) *TestCase {
+		steps := append([]*TestStep{}, testCase.Steps...)
+		steps[stepIndex] = NewTestStep(testCase.Steps[stepIndex].Probe, actions...)
+		return withSteps(steps)
+	}
+
+	if len(testCase.Steps) > 1 {
+		for i := range testCase.Steps {
+			candidates = append(candidates, withSteps(without(testCase.Steps, i)))
+		}
+	}
+	for i, step := range testCase.Steps {
+		for j := range step.Actions {
+			candidates = append(candidates, withActions(i, without(step.Actions, j)))
+		}
+	}
+	for i, step := range testCase.Steps {
+		for j, action := range step.Actions {
+			for _, smaller := range shrinkAction(action) {
+				actions := append([]*Action{}, step.Actions...)
+				actions[j] = smaller
+				candidates = append(candidates, withActions(i, actions))
+			}
+		}
+	}
+	return candidates
+}
+
+func without[T any](elems []T, index int) []T {
+	var remaining []T
+	remaining = append(remaining, elems[:index]...)
+	return append(remaining, elems[index+1:]...)
+}
+
+// shrinkAction returns the variants of a policy creation with one rule, peer or port list removed
+func shrinkAction(action *Action) []*Action {
+	var actions []*Action
+	if action.CreatePolicy != nil {
+		policy := action.CreatePolicy.Policy
+		for i := range policy.Spec.Ingress {
+			smaller := policy.DeepCopy()
+			smaller.Spec.Ingress = without(smaller.Spec.Ingress, i)
+			actions = append(actions, CreatePolicy(smaller))
+		}
+		for i := range policy.Spec.Egress {
+			smaller := policy.DeepCopy()
+			smaller.Spec.Egress = without(smaller.Spec.Egress, i)
+			actions = append(actions, CreatePolicy(smaller))
+		}
+		for i, rule := range policy.Spec.Ingress {
+			for _, rule := range shrinkNetpolRule(&Rule{Ports: rule.Ports, Peers: rule.From}) {
+				smaller := policy.DeepCopy()
+				smaller.Spec.Ingress[i] = rule.Ingress()
+				actions = append(actions, CreatePolicy(smaller))
+			}
+		}
+		for i, rule := range policy.Spec.Egress {
+			for _, rule := range shrinkNetpolRule(&Rule{Ports: rule.Ports, Peers: rule.To}) {
+				smaller := policy.DeepCopy()
+				smaller.Spec.Egress[i] = rule.Egress()
+				actions = append(actions, CreatePolicy(smaller))
+			}
+		}
+	} else if action.CreateAdminNetworkPolicy != nil {
+		policy := action.CreateAdminNetworkPolicy.Policy
+		canDropRule := len(policy.Spec.Ingress)+len(policy.Spec.Egress) > 1
+		for _, ingress := range shrinkAdminRules(policy.Spec.Ingress, canDropRule, anpIngressRule) {
+			smaller := *policy
+			smaller.Spec.Ingress = ingress
+			actions = append(actions, CreateAdminNetworkPolicy(smaller.DeepCopy()))
+		}
+		for _, egress := range shrinkAdminRules(policy.Spec.Egress, canDropRule, anpEgressRule) {
+			smaller := *policy
+			smaller.Spec.Egress = egress
+			actions = append(actions, CreateAdminNetworkPolicy(smaller.DeepCopy()))
+		}
+	} else if action.CreateBaselineAdminNetworkPolicy != nil {
+		policy := action.CreateBaselineAdminNetworkPolicy.Policy
+		canDropRule := len(policy.Spec.Ingress)+len(policy.Spec.Egress) > 1
+		for _, ingress := range shrinkAdminRules(policy.Spec.Ingress, canDropRule, banpIngressRule) {
+			smaller := *policy
+			smaller.Spec.Ingress = ingress
+			actions = append(actions, CreateBaselineAdminNetworkPolicy(smaller.DeepCopy()))
+		}
+		for _, egress := range shrinkAdminRules(policy.Spec.Egress, canDropRule, banpEgressRule) {
+			smaller := *policy
+			smaller.Spec.Egress = egress
+			actions = append(actions, CreateBaselineAdminNetworkPolicy(smaller.DeepCopy()))
+		}
+	}
+	return actions
+}
+
+// adminRule gives access to the peers and ports of an ANP or BANP rule, whose types differ
+type adminRule struct {
+	peers    int
+	dropPeer func(index int)
+	ports    **[]v1alpha1.AdminNetworkPolicyPort
+}
+
+func anpIngressRule(rule *v1alpha1.AdminNetworkPolicyIngressRule) *adminRule {
+	return &adminRule{peers: len(rule.From), dropPeer: func(i int) { rule.From = without(rule.From, i) }, ports: &rule.Ports}
+}
+
+func anpEgressRule(rule *v1alpha1.AdminNetworkPolicyEgressRule) *adminRule {
+	return &adminRule{peers: len(rule.To), dropPeer: func(i int) { rule.To = without(rule.To, i) }, ports: &rule.Ports}
+}
+
+func banpIngressRule(rule *v1alpha1.BaselineAdminNetworkPolicyIngressRule) *adminRule {
+	return &adminRule{peers: len(rule.From), dropPeer: func(i int) { rule.From = without(rule.From, i) }, ports: &rule.Ports}
+}
+
+func banpEgressRule(rule *v1alpha1.BaselineAdminNetworkPolicyEgressRule) *adminRule {
+	return &adminRule{peers: len(rule.To), dropPeer: func(i int) { rule.To = without(rule.To, i) }, ports: &rule.Ports}
+}
+
+// shrinkAdminRules returns the variants of the ingress or egress rules of an ANP or BANP with one rule, peer or
+// port list removed.  Rules are only removed if canDropRule, since an admin network policy needs at least one
+// rule, and the last peer of a rule is kept, since a rule needs at least one peer.  rules is not modified.
+func shrinkAdminRules[R any](rules []R, canDropRule bool, access func(*R) *adminRule) [][]R {
+	var variants [][]R
+	if canDropRule {
+		for i := range rules {
+			variants = append(variants, without(rules, i))
+		}
+	}
+	for i := range rules {
+		rule := access(&rules[i])
+		if rule.peers > 1 {
+			for j := 0; j < rule.peers; j++ {
+				smaller := append([]R{}, rules...)
+				access(&smaller[i]).dropPeer(j)
+				variants = append(variants, smaller)
+			}
+		}
+		if *rule.ports != nil {
+			smaller := append([]R{}, rules...)
+			*access(&smaller[i]).ports = nil
+			variants = append(variants, smaller)
+		}
+	}
+	return variants
+}
+
+// shrinkNetpolRule drops one peer or one port at a time.  The last peer or port is kept, since dropping it
+// would make the rule match all peers or all ports.
+func shrinkNetpolRule(rule *Rule) []*Rule {
+	var rules []*Rule
+	if len(rule.Peers) > 1 {
+		for i := range rule.Peers {
+			rules = append(rules, &Rule{Ports: rule.Ports, Peers: without(rule.Peers, i)})
+		}
+	}
+	if len(rule.Ports) > 1 {
+		for i := range rule.Ports {
+			rules = append(rules, &Rule{Ports: without(rule.Ports, i), Peers: rule.Peers})
+		}
+	}
+	return rules
+}
@@ -10,5 +10,7 @@ import (
 func TestGenerator(t *testing.T) {
 	RegisterFailHandler(Fail)
 	RunTestCaseGeneratorTests()
+	RunFuzzerTests()
+	RunTestCaseFileTests()
 	RunSpecs(t, "generator suite")
 }
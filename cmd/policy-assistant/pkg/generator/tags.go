@@ -1,6 +1,7 @@
 package generator
 
 import (
+	"encoding/json"
 	"github.com/mattfenwick/collections/pkg/slice"
 	"github.com/pkg/errors"
 	"golang.org/x/exp/maps"
@@ -19,6 +20,7 @@ const (
 	TagPeerIPBlock   = "peer-ipblock"
 	TagPeerPods      = "peer-pods"
 	TagMiscellaneous = "miscellaneous"
+	TagANP           = "anp"
 )
 
 const (
@@ -79,11 +81,21 @@ const (
 	TagSCTPProtocol = "sctp"
 )
 
+const (
+	TagANPPriority      = "anp-priority"
+	TagANPPass          = "anp-pass"
+	TagANPOverridesNPv1 = "anp-overrides-npv1"
+	TagANPPortRange     = "anp-port-range"
+	TagANPNamedPort     = "anp-named-port"
+	TagBANP             = "banp"
+)
+
 const (
 	TagPathological = "pathological"
 	TagConflict     = "conflict"
 	TagExample      = "example"
 	TagUpstreamE2E  = "upstream-e2e"
+	TagFuzz         = "fuzz"
 )
 
 var AllTags = map[string][]string{
@@ -143,6 +155,15 @@ var AllTags = map[string][]string{
 		TagConflict,
 		TagExample,
 		TagUpstreamE2E,
+		TagFuzz,
+	},
+	TagANP: {
+		TagANPPriority,
+		TagANPPass,
+		TagANPOverridesNPv1,
+		TagANPPortRange,
+		TagANPNamedPort,
+		TagBANP,
 	},
 }
 
@@ -192,6 +213,25 @@ func ValidateTags(tags []string) error {
 	return nil
 }
 
+// RemoveIncludedTags drops the excluded tags which were included explicitly, either themselves or through
+// one of their subordinate tags, so that e.g. including 'anp' wins over excluding it by default
+func RemoveIncludedTags(excluded []string, included []string) []string {
+	includedSet := map[string]bool{}
+	for _, tag := range included {
+		includedSet[tag] = true
+		if primary, ok := TagSubToPrimary[tag]; ok {
+			includedSet[primary] = true
+		}
+	}
+	var remaining []string
+	for _, tag := range excluded {
+		if !includedSet[tag] {
+			remaining = append(remaining, tag)
+		}
+	}
+	return remaining
+}
+
 func MustGetPrimaryTag(subordinateTag string) string {
 	primary, ok := TagSubToPrimary[subordinateTag]
 	if !ok {
@@ -247,3 +287,39 @@ func (s StringSet) ContainsAny(stringSlice []string) bool {
 	}
 	return false
 }
+
+// MarshalJSON serializes the set as a sorted list, leaving out the primary tags implied by subordinate tags
+func (s StringSet) MarshalJSON() ([]byte, error) {
+	implied := map[string]bool{}
+	for tag := range s {
+		if primary, ok := TagSubToPrimary[tag]; ok {
+			implied[primary] = true
+		}
+	}
+	tags := []string{}
+	for _, tag := range s.Keys() {
+		if !implied[tag] {
+			tags = append(tags, tag)
+		}
+	}
+	return json.Marshal(tags)
+}
+
+func (s *StringSet) UnmarshalJSON(bytes []byte) error {
+	var tags []string
+	if err := json.Unmarshal(bytes, &tags); err != nil {
+		return errors.Wrapf(err, "unable to unmarshal tags")
+	}
+	if err := ValidateTags(tags); err != nil {
+		return err
+	}
+	*s = NewStringSet()
+	for _, tag := range tags {
+		if _, ok := AllTags[tag]; ok {
+			(*s)[tag] = true
+		} else {
+			s.Add(tag)
+		}
+	}
+	return nil
+}
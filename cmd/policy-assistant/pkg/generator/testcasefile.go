@@ -0,0 +1,145 @@
+package generator
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mattfenwick/collections/pkg/file"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/utils"
+	"sigs.k8s.io/yaml"
+)
+
+// TestCaseFile is the serialized format of test cases, in YAML or JSON, so that they can be written and
+// shared without adding Go code to this package
+type TestCaseFile struct {
+	TestCases []*TestCase `json:"testCases"`
+}
+
+// ReadTestCasesFromPath reads the test cases from a file, or from every .yaml, .yml and .json file under a directory
+func ReadTestCasesFromPath(testCasePath string) ([]*TestCase, error) {
+	var testCases []*TestCase
+	err := filepath.Walk(testCasePath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return errors.Wrapf(err, "unable to walk path %s", path)
+		}
+		if info.IsDir() {
+			logrus.Tracef("not opening dir %s", path)
+			return nil
+		}
+		if path != testCasePath && !isTestCaseFileExtension(filepath.Ext(path)) {
+			logrus.Debugf("skipping file %s", path)
+			return nil
+		}
+		logrus.Debugf("walking path %s", path)
+		bytes, err := file.Read(path)
+		if err != nil {
+			return err
+		}
+
+		testCaseFile, err := utils.ParseYamlStrict[TestCaseFile](bytes)
+		if err != nil {
+			return errors.WithMessagef(err, "unable to parse test cases from %s", path)
+		}
+		for i, testCase := range testCaseFile.TestCases {
+			if err := testCase.Validate(); err != nil {
+				return errors.WithMessagef(err, "invalid test case #%d in %s", i+1, path)
+			}
+		}
+		testCases = append(testCases, testCaseFile.TestCases...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return testCases, nil
+}
+
+func isTestCaseFileExtension(extension string) bool {
+	switch strings.ToLower(extension) {
+	case ".yaml", ".yml", ".json":
+		return true
+	}
+	return false
+}
+
+// WriteTestCasesToFile writes the test cases as YAML, or as JSON if path ends with .json
+func WriteTestCasesToFile(path string, testCases []*TestCase) error {
+	testCaseFile := &TestCaseFile{TestCases: testCases}
+	var bytes []byte
+	var err error
+	if strings.ToLower(filepath.Ext(path)) == ".json" {
+		bytes, err = json.MarshalIndent(testCaseFile, "", "  ")
+	} else {
+		bytes, err = yaml.Marshal(testCaseFile)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "unable to marshal test cases")
+	}
+	return errors.Wrapf(os.WriteFile(path, bytes, 0644), "unable to write file %s", path)
+}
+
+// Validate checks the invariants which the Go constructors guarantee, but which a test case read from
+// a file may break: every probe and action must set exactly one of its fields.
+func (t *TestCase) Validate() error {
+	if len(t.Steps) == 0 {
+		return errors.Errorf("test case %s has no steps", t.Description)
+	}
+	for i, step := range t.Steps {
+		if err := step.Probe.Validate(); err != nil {
+			return errors.WithMessagef(err, "step %d", i+1)
+		}
+		for j, action := range step.Actions {
+			if err := action.Validate(); err != nil {
+				return errors.WithMessagef(err, "step %d, action %d", i+1, j+1)
+			}
+		}
+	}
+	return nil
+}
+
+func (p *ProbeConfig) Validate() error {
+	if p == nil {
+		return errors.Errorf("missing probe")
+	}
+	if p.AllAvailable == (p.PortProtocol != nil) {
+		return errors.Errorf("probe must set exactly one of allAvailable and portProtocol")
+	}
+	if _, err := ParseProbeMode(string(p.Mode)); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (a *Action) Validate() error {
+	set := 0
+	for _, isSet := range []bool{
+		a.CreatePolicy != nil && a.CreatePolicy.Policy != nil,
+		a.UpdatePolicy != nil && a.UpdatePolicy.Policy != nil,
+		a.DeletePolicy != nil,
+		a.CreateAdminNetworkPolicy != nil && a.CreateAdminNetworkPolicy.Policy != nil,
+		a.UpdateAdminNetworkPolicy != nil && a.UpdateAdminNetworkPolicy.Policy != nil,
+		a.DeleteAdminNetworkPolicy != nil,
+		a.CreateBaselineAdminNetworkPolicy != nil && a.CreateBaselineAdminNetworkPolicy.Policy != nil,
+		a.UpdateBaselineAdminNetworkPolicy != nil && a.UpdateBaselineAdminNetworkPolicy.Policy != nil,
+		a.DeleteBaselineAdminNetworkPolicy != nil,
+		a.CreateNamespace != nil,
+		a.SetNamespaceLabels != nil,
+		a.DeleteNamespace != nil,
+		a.ReadNetworkPolicies != nil,
+		a.CreatePod != nil,
+		a.SetPodLabels != nil,
+		a.DeletePod != nil,
+	} {
+		if isSet {
+			set++
+		}
+	}
+	if set != 1 {
+		return errors.Errorf("action must set exactly one field (with its policy, if any), found %d", set)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func RunTestCaseFileTests() {
+	Describe("TestCaseFile", func() {
+		gen := NewTestCaseGenerator(true, "1.2.3.4", []string{"x", "y", "z"}, []string{}, []string{})
+
+		It("Round trips the built-in test cases through YAML and JSON", func() {
+			dir, err := os.MkdirTemp("", "test-cases")
+			Expect(err).To(BeNil())
+			defer os.RemoveAll(dir)
+
+			testCases := gen.GenerateAllTestCases()
+			for _, name := range []string{"test-cases.yaml", "test-cases.json"} {
+				path := filepath.Join(dir, name)
+				Expect(WriteTestCasesToFile(path, testCases)).To(Succeed())
+				written, err := os.ReadFile(path)
+				Expect(err).To(BeNil())
+
+				read, err := ReadTestCasesFromPath(path)
+				Expect(err).To(BeNil())
+				Expect(read).To(HaveLen(len(testCases)))
+				Expect(read[0].Tags).To(Equal(testCases[0].Tags))
+
+				Expect(WriteTestCasesToFile(path, read)).To(Succeed())
+				rewritten, err := os.ReadFile(path)
+				Expect(err).To(BeNil())
+				Expect(string(rewritten)).To(Equal(string(written)))
+			}
+
+			read, err := ReadTestCasesFromPath(dir)
+			Expect(err).To(BeNil())
+			Expect(read).To(HaveLen(2 * len(testCases)))
+		})
+
+		It("Rejects invalid test cases", func() {
+			Expect((&TestCase{Description: "no steps"}).Validate()).NotTo(Succeed())
+			Expect(NewTestCase("no probe", NewStringSet(), NewTestStep(nil)).Validate()).NotTo(Succeed())
+			Expect(NewTestCase("empty action", NewStringSet(), NewTestStep(ProbeAllAvailable, &Action{})).Validate()).NotTo(Succeed())
+			Expect(NewTestCase("two actions", NewStringSet(), NewTestStep(ProbeAllAvailable, &Action{
+				DeletePod:       &DeletePodAction{Namespace: "x", Pod: "a"},
+				DeleteNamespace: &DeleteNamespaceAction{Namespace: "y"},
+			})).Validate()).NotTo(Succeed())
+			Expect(NewTestCase("valid", NewStringSet(), NewTestStep(ProbeAllAvailable, DeletePod("x", "a"))).Validate()).To(Succeed())
+		})
+	})
+}
@@ -72,7 +72,8 @@ func (t *TestCaseGenerator) GenerateAllTestCases() []*TestCase {
 		t.ActionTestCases(),
 		t.ConflictTestCases(),
 		t.NamespaceTestCases(),
-		t.UpstreamE2ETestCases())
+		t.UpstreamE2ETestCases(),
+		t.ANPTestCases())
 }
 
 func (t *TestCaseGenerator) GenerateTestCases() []*TestCase {
@@ -19,8 +19,27 @@ func RunTestCaseGeneratorTests() {
 			Expect(len(gen.PortProtocolTestCases())).To(Equal(70))
 			Expect(len(gen.ConflictTestCases())).To(Equal(16))
 			Expect(len(gen.NamespaceTestCases())).To(Equal(2))
+			Expect(len(gen.ANPTestCases())).To(Equal(31))
 
-			Expect(len(gen.GenerateTestCases())).To(Equal(230))
+			Expect(len(gen.GenerateTestCases())).To(Equal(261))
+		})
+
+		It("Selects admin policy test cases by tag", func() {
+			defaultExclude := []string{TagMultiPeer, TagANP}
+			Expect(RemoveIncludedTags(defaultExclude, []string{})).To(Equal(defaultExclude))
+			Expect(RemoveIncludedTags(defaultExclude, []string{TagANP})).To(Equal([]string{TagMultiPeer}))
+			Expect(RemoveIncludedTags(defaultExclude, []string{TagANPPass})).To(Equal([]string{TagMultiPeer}))
+
+			anps := NewTestCaseGenerator(true, "1.2.3.4", []string{"x", "y", "z"}, []string{TagANP}, RemoveIncludedTags(defaultExclude, []string{TagANP}))
+			Expect(len(anps.GenerateTestCases())).To(Equal(31))
+
+			pass := NewTestCaseGenerator(true, "1.2.3.4", []string{"x", "y", "z"}, []string{TagANPPass}, RemoveIncludedTags(defaultExclude, []string{TagANPPass}))
+			Expect(len(pass.GenerateTestCases())).To(Equal(6))
+
+			withoutANPs := NewTestCaseGenerator(true, "1.2.3.4", []string{"x", "y", "z"}, []string{TagIngress}, defaultExclude)
+			for _, testCase := range withoutANPs.GenerateTestCases() {
+				Expect(testCase.Tags).NotTo(HaveKey(TagANP))
+			}
 		})
 	})
 }
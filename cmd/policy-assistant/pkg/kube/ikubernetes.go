@@ -6,8 +6,10 @@ import (
 	"math/rand"
 
 	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 	v1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/network-policy-api/apis/v1alpha1"
 	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/utils"
@@ -104,6 +106,27 @@ func GetBaselineAdminNetworkPolicy(ctx context.Context, kubernetes IKubernetes)
 	return kubernetes.GetBaselineAdminNetworkPolicy(ctx)
 }
 
+// GetAdminNetworkPoliciesIfInstalled returns the ANPs and BANP of the cluster, or nothing if their CRDs aren't installed
+func GetAdminNetworkPoliciesIfInstalled(ctx context.Context, kubernetes IKubernetes) ([]v1alpha1.AdminNetworkPolicy, *v1alpha1.BaselineAdminNetworkPolicy, error) {
+	anps, err := kubernetes.GetAdminNetworkPolicies(ctx)
+	if kerrors.IsNotFound(errors.Cause(err)) {
+		logrus.Debugf("admin network policies not installed: %+v", err)
+		anps, err = nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	banp, err := kubernetes.GetBaselineAdminNetworkPolicy(ctx)
+	if kerrors.IsNotFound(errors.Cause(err)) {
+		logrus.Debugf("baseline admin network policies not installed: %+v", err)
+		banp, err = nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return anps, banp, nil
+}
+
 type MockNamespace struct {
 	NamespaceObject *v1.Namespace
 	Netpols         map[string]*networkingv1.NetworkPolicy
@@ -111,7 +134,13 @@ type MockNamespace struct {
 	Services        map[string]*v1.Service
 }
 
+// MockConnectivityFunc decides whether a command executed in a mock pod -- such as a probe -- succeeds.
+// err is for commands which can't be set up, such as probes of unknown hosts.
+type MockConnectivityFunc func(m *MockKubernetes, namespace string, pod string, container string, command []string) (connected bool, err error)
+
 type MockKubernetes struct {
+	// Connectivity, if set, decides which commands succeed instead of passRate
+	Connectivity                MockConnectivityFunc
 	AdminNetworkPolicies        []v1alpha1.AdminNetworkPolicy
 	AdminNetworkPolicyError     error
 	BaselineNetworkPolicy       *v1alpha1.BaselineAdminNetworkPolicy
@@ -120,6 +149,7 @@ type MockKubernetes struct {
 	NetworkPolicyError          error
 	passRate                    float64
 	podID                       int
+	serviceID                   int
 }
 
 func NewMockKubernetes(passRate float64) *MockKubernetes {
@@ -127,6 +157,7 @@ func NewMockKubernetes(passRate float64) *MockKubernetes {
 		Namespaces: map[string]*MockNamespace{},
 		passRate:   passRate,
 		podID:      1,
+		serviceID:  1,
 	}
 }
 
@@ -155,12 +186,12 @@ func (m *MockKubernetes) GetNamespace(namespace string) (*v1.Namespace, error) {
 }
 
 func (m *MockKubernetes) SetNamespaceLabels(namespace string, labels map[string]string) (*v1.Namespace, error) {
-	ns, err := m.GetNamespace(namespace)
+	nsObject, err := m.getNamespaceObject(namespace)
 	if err != nil {
 		return nil, err
 	}
-	ns.Labels = labels
-	return ns, nil
+	nsObject.NamespaceObject.Labels = labels
+	return m.GetNamespace(namespace)
 }
 
 func (m *MockKubernetes) DeleteNamespace(ns string) error {
@@ -276,6 +307,13 @@ func (m *MockKubernetes) CreateService(svc *v1.Service) (*v1.Service, error) {
 	if _, ok := nsObject.Services[svc.Name]; ok {
 		return nil, errors.Errorf("service %s/%s already present", svc.Namespace, svc.Name)
 	}
+	if svc.Spec.ClusterIP == "" {
+		if m.serviceID >= 255 {
+			panic(errors.Errorf("unable to handle more than 254 services in mock"))
+		}
+		svc.Spec.ClusterIP = fmt.Sprintf("10.96.0.%d", m.serviceID)
+		m.serviceID++
+	}
 	nsObject.Services[svc.Name] = svc
 	return svc, nil
 }
@@ -386,7 +424,16 @@ func (m *MockKubernetes) ExecuteRemoteCommand(namespace string, pod string, cont
 		return "", "", nil, errors.Errorf("container %s/%s/%s not found", namespace, pod, container)
 	}
 
-	// TODO could look at netpols, pods, etc. to determine if this resolves?
+	if m.Connectivity != nil {
+		connected, err := m.Connectivity(m, namespace, pod, container, command)
+		if err != nil {
+			return "", "", nil, err
+		}
+		if !connected {
+			return "", "", errors.Errorf("mock connection blocked"), nil
+		}
+		return "", "", nil, nil
+	}
 
 	if rand.Float64() > m.passRate {
 		return "", "", errors.Errorf("mock call randomly failed"), nil
@@ -398,32 +445,60 @@ func (m *MockKubernetes) GetAdminNetworkPolicies(ctx context.Context) ([]v1alpha
 	return m.AdminNetworkPolicies, m.AdminNetworkPolicyError
 }
 
-func (k *MockKubernetes) CreateAdminNetworkPolicy(ctx context.Context, policy *v1alpha1.AdminNetworkPolicy) (*v1alpha1.AdminNetworkPolicy, error) {
-	return nil, ErrNotImplemented
+func (m *MockKubernetes) CreateAdminNetworkPolicy(ctx context.Context, policy *v1alpha1.AdminNetworkPolicy) (*v1alpha1.AdminNetworkPolicy, error) {
+	for _, anp := range m.AdminNetworkPolicies {
+		if anp.Name == policy.Name {
+			return nil, errors.Errorf("admin network policy %s already present", policy.Name)
+		}
+	}
+	m.AdminNetworkPolicies = append(m.AdminNetworkPolicies, *policy)
+	return policy, nil
 }
 
-func (k *MockKubernetes) UpdateAdminNetworkPolicy(ctx context.Context, policy *v1alpha1.AdminNetworkPolicy) (*v1alpha1.AdminNetworkPolicy, error) {
-	return nil, ErrNotImplemented
+func (m *MockKubernetes) UpdateAdminNetworkPolicy(ctx context.Context, policy *v1alpha1.AdminNetworkPolicy) (*v1alpha1.AdminNetworkPolicy, error) {
+	for i, anp := range m.AdminNetworkPolicies {
+		if anp.Name == policy.Name {
+			m.AdminNetworkPolicies[i] = *policy
+			return policy, nil
+		}
+	}
+	return nil, errors.Errorf("admin network policy %s not found", policy.Name)
 }
 
-func (k *MockKubernetes) DeleteAdminNetworkPolicy(ctx context.Context, name string) error {
-	//TODO: implement
-	return ErrNotImplemented
+func (m *MockKubernetes) DeleteAdminNetworkPolicy(ctx context.Context, name string) error {
+	for i, anp := range m.AdminNetworkPolicies {
+		if anp.Name == name {
+			m.AdminNetworkPolicies = append(m.AdminNetworkPolicies[:i], m.AdminNetworkPolicies[i+1:]...)
+			return nil
+		}
+	}
+	return errors.Errorf("admin network policy %s not found", name)
 }
 
 func (m *MockKubernetes) GetBaselineAdminNetworkPolicy(ctx context.Context) (*v1alpha1.BaselineAdminNetworkPolicy, error) {
 	return m.BaselineNetworkPolicy, m.BaseAdminNetworkPolicyError
 }
 
-func (k *MockKubernetes) CreateBaselineAdminNetworkPolicy(ctx context.Context, policy *v1alpha1.BaselineAdminNetworkPolicy) (*v1alpha1.BaselineAdminNetworkPolicy, error) {
-	return nil, ErrNotImplemented
+func (m *MockKubernetes) CreateBaselineAdminNetworkPolicy(ctx context.Context, policy *v1alpha1.BaselineAdminNetworkPolicy) (*v1alpha1.BaselineAdminNetworkPolicy, error) {
+	if m.BaselineNetworkPolicy != nil {
+		return nil, errors.Errorf("baseline admin network policy %s already present", m.BaselineNetworkPolicy.Name)
+	}
+	m.BaselineNetworkPolicy = policy
+	return policy, nil
 }
 
-func (k *MockKubernetes) UpdateBaselineAdminNetworkPolicy(ctx context.Context, policy *v1alpha1.BaselineAdminNetworkPolicy) (*v1alpha1.BaselineAdminNetworkPolicy, error) {
-	return nil, ErrNotImplemented
+func (m *MockKubernetes) UpdateBaselineAdminNetworkPolicy(ctx context.Context, policy *v1alpha1.BaselineAdminNetworkPolicy) (*v1alpha1.BaselineAdminNetworkPolicy, error) {
+	if m.BaselineNetworkPolicy == nil || m.BaselineNetworkPolicy.Name != policy.Name {
+		return nil, errors.Errorf("baseline admin network policy %s not found", policy.Name)
+	}
+	m.BaselineNetworkPolicy = policy
+	return policy, nil
 }
 
-func (k *MockKubernetes) DeleteBaselineAdminNetworkPolicy(ctx context.Context, name string) error {
-	//TODO: implement
-	return ErrNotImplemented
+func (m *MockKubernetes) DeleteBaselineAdminNetworkPolicy(ctx context.Context, name string) error {
+	if m.BaselineNetworkPolicy == nil || m.BaselineNetworkPolicy.Name != name {
+		return errors.Errorf("baseline admin network policy %s not found", name)
+	}
+	m.BaselineNetworkPolicy = nil
+	return nil
 }
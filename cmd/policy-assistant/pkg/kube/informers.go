@@ -0,0 +1,286 @@
+package kube
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	networkinglisters "k8s.io/client-go/listers/networking/v1"
+	"k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/network-policy-api/apis/v1alpha1"
+	"sigs.k8s.io/network-policy-api/pkg/client/clientset/versioned"
+	policyinformers "sigs.k8s.io/network-policy-api/pkg/client/informers/externalversions"
+	policylisters "sigs.k8s.io/network-policy-api/pkg/client/listers/apis/v1alpha1"
+)
+
+type WatchEventKind string
+
+const (
+	WatchEventNetworkPolicy              WatchEventKind = "NetworkPolicy"
+	WatchEventAdminNetworkPolicy         WatchEventKind = "AdminNetworkPolicy"
+	WatchEventBaselineAdminNetworkPolicy WatchEventKind = "BaselineAdminNetworkPolicy"
+	WatchEventPod                        WatchEventKind = "Pod"
+	WatchEventNamespace                  WatchEventKind = "Namespace"
+)
+
+// WatchEvent is a change to a resource which can affect reachability
+type WatchEvent struct {
+	Kind      WatchEventKind
+	Namespace string
+	Name      string
+	Deleted   bool
+}
+
+func (e *WatchEvent) IsPolicy() bool {
+	return e.Kind != WatchEventPod && e.Kind != WatchEventNamespace
+}
+
+// ClusterSnapshot is the state of the informer caches at one point in time
+type ClusterSnapshot struct {
+	Policies   []*networkingv1.NetworkPolicy
+	ANPs       []*v1alpha1.AdminNetworkPolicy
+	BANP       *v1alpha1.BaselineAdminNetworkPolicy
+	Pods       []v1.Pod
+	Namespaces []v1.Namespace
+}
+
+// Informers caches the policies, pods and namespaces of a cluster, and reports every change to them which
+// can affect reachability.  Pod and namespace updates which don't change labels -- or a pod's IP -- are
+// ignored, so that status churn doesn't trigger re-evaluation.
+type Informers struct {
+	namespaces map[string]bool
+
+	coreFactories   []informers.SharedInformerFactory
+	policyFactory   policyinformers.SharedInformerFactory
+	podListers      []corelisters.PodLister
+	netpolListers   []networkinglisters.NetworkPolicyLister
+	namespaceLister corelisters.NamespaceLister
+	anpLister       policylisters.AdminNetworkPolicyLister
+	banpLister      policylisters.BaselineAdminNetworkPolicyLister
+
+	handlers []func(event *WatchEvent)
+}
+
+// NewInformers watches pods and network policies in namespaces -- or in every namespace, if namespaces is
+// empty or contains v1.NamespaceAll -- along with all namespaces, and ANPs and BANPs if included
+func NewInformers(k *Kubernetes, namespaces []string, includeANPs bool, includeBANPs bool, resync time.Duration) (*Informers, error) {
+	i := &Informers{}
+	if len(namespaces) == 0 {
+		namespaces = []string{v1.NamespaceAll}
+	}
+	for _, ns := range namespaces {
+		if ns == v1.NamespaceAll {
+			namespaces, i.namespaces = []string{v1.NamespaceAll}, nil
+			break
+		}
+		if i.namespaces == nil {
+			i.namespaces = map[string]bool{}
+		}
+		i.namespaces[ns] = true
+	}
+
+	clusterFactory := informers.NewSharedInformerFactory(k.ClientSet, resync)
+	i.coreFactories = append(i.coreFactories, clusterFactory)
+	namespaceInformer := clusterFactory.Core().V1().Namespaces()
+	i.namespaceLister = namespaceInformer.Lister()
+	i.addHandler(WatchEventNamespace, namespaceInformer.Informer(), namespaceChanged)
+
+	for _, ns := range namespaces {
+		factory := clusterFactory
+		if ns != v1.NamespaceAll {
+			factory = informers.NewSharedInformerFactoryWithOptions(k.ClientSet, resync, informers.WithNamespace(ns))
+			i.coreFactories = append(i.coreFactories, factory)
+		}
+		podInformer := factory.Core().V1().Pods()
+		i.podListers = append(i.podListers, podInformer.Lister())
+		i.addHandler(WatchEventPod, podInformer.Informer(), podChanged)
+
+		netpolInformer := factory.Networking().V1().NetworkPolicies()
+		i.netpolListers = append(i.netpolListers, netpolInformer.Lister())
+		i.addHandler(WatchEventNetworkPolicy, netpolInformer.Informer(), resourceVersionChanged)
+	}
+
+	if includeANPs || includeBANPs {
+		policyClient, err := versioned.NewForConfig(k.RestConfig)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to instantiate network policy api client set")
+		}
+		i.policyFactory = policyinformers.NewSharedInformerFactory(policyClient, resync)
+		if includeANPs {
+			anpInformer := i.policyFactory.Policy().V1alpha1().AdminNetworkPolicies()
+			i.anpLister = anpInformer.Lister()
+			i.addHandler(WatchEventAdminNetworkPolicy, anpInformer.Informer(), resourceVersionChanged)
+		}
+		if includeBANPs {
+			banpInformer := i.policyFactory.Policy().V1alpha1().BaselineAdminNetworkPolicies()
+			i.banpLister = banpInformer.Lister()
+			i.addHandler(WatchEventBaselineAdminNetworkPolicy, banpInformer.Informer(), resourceVersionChanged)
+		}
+	}
+
+	return i, nil
+}
+
+// AddEventHandler registers a handler for changes.  Handlers are called from informer goroutines, including
+// for the initial list of every resource.
+func (i *Informers) AddEventHandler(handler func(event *WatchEvent)) {
+	i.handlers = append(i.handlers, handler)
+}
+
+// Start starts the informers, and waits for their caches to fill
+func (i *Informers) Start(stop <-chan struct{}) error {
+	for _, factory := range i.coreFactories {
+		factory.Start(stop)
+		if err := checkSynced(factory.WaitForCacheSync(stop)); err != nil {
+			return err
+		}
+	}
+	if i.policyFactory != nil {
+		i.policyFactory.Start(stop)
+		if err := checkSynced(i.policyFactory.WaitForCacheSync(stop)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkSynced(synced map[reflect.Type]bool) error {
+	for informerType, ok := range synced {
+		if !ok {
+			return errors.Errorf("unable to sync informer cache for %s", informerType)
+		}
+	}
+	return nil
+}
+
+// Snapshot lists the cached resources
+func (i *Informers) Snapshot() (*ClusterSnapshot, error) {
+	snapshot := &ClusterSnapshot{}
+
+	namespaces, err := i.namespaceLister.List(labels.Everything())
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to list namespaces")
+	}
+	for _, ns := range namespaces {
+		if i.isWatched(ns.Name) {
+			snapshot.Namespaces = append(snapshot.Namespaces, *ns)
+		}
+	}
+
+	for _, lister := range i.podListers {
+		pods, err := lister.List(labels.Everything())
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to list pods")
+		}
+		for _, pod := range pods {
+			snapshot.Pods = append(snapshot.Pods, *pod)
+		}
+	}
+
+	for _, lister := range i.netpolListers {
+		netpols, err := lister.List(labels.Everything())
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to list network policies")
+		}
+		snapshot.Policies = append(snapshot.Policies, netpols...)
+	}
+
+	if i.anpLister != nil {
+		snapshot.ANPs, err = i.anpLister.List(labels.Everything())
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to list admin network policies")
+		}
+	}
+
+	if i.banpLister != nil {
+		banps, err := i.banpLister.List(labels.Everything())
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to list baseline admin network policies")
+		}
+		// there can only be one BANP by definition
+		for _, banp := range banps {
+			if snapshot.BANP != nil {
+				logrus.Warnf("ignoring baseline admin network policy %s: only one is allowed", banp.Name)
+				continue
+			}
+			snapshot.BANP = banp
+		}
+	}
+
+	return snapshot, nil
+}
+
+func (i *Informers) isWatched(namespace string) bool {
+	return i.namespaces == nil || i.namespaces[namespace]
+}
+
+func (i *Informers) addHandler(kind WatchEventKind, informer cache.SharedIndexInformer, changed func(oldObj interface{}, newObj interface{}) bool) {
+	notify := func(obj interface{}, deleted bool) {
+		if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+			obj = tombstone.Obj
+		}
+		accessor, err := meta.Accessor(obj)
+		if err != nil {
+			logrus.Errorf("unable to handle %s event: %+v", kind, err)
+			return
+		}
+		name := accessor.GetName()
+		if kind == WatchEventNamespace && !i.isWatched(name) {
+			return
+		}
+		event := &WatchEvent{Kind: kind, Namespace: accessor.GetNamespace(), Name: name, Deleted: deleted}
+		for _, handler := range i.handlers {
+			handler(event)
+		}
+	}
+
+	_, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			notify(obj, false)
+		},
+		UpdateFunc: func(oldObj interface{}, newObj interface{}) {
+			if changed(oldObj, newObj) {
+				notify(newObj, false)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			notify(obj, true)
+		},
+	})
+	// only fails if the informer was already stopped
+	if err != nil {
+		logrus.Errorf("unable to add %s event handler: %+v", kind, err)
+	}
+}
+
+// resourceVersionChanged ignores resyncs, which redeliver unchanged objects
+func resourceVersionChanged(oldObj interface{}, newObj interface{}) bool {
+	oldMeta, oldErr := meta.Accessor(oldObj)
+	newMeta, newErr := meta.Accessor(newObj)
+	return oldErr != nil || newErr != nil || oldMeta.GetResourceVersion() != newMeta.GetResourceVersion()
+}
+
+func podChanged(oldObj interface{}, newObj interface{}) bool {
+	oldPod, oldOk := oldObj.(*v1.Pod)
+	newPod, newOk := newObj.(*v1.Pod)
+	if !oldOk || !newOk {
+		return true
+	}
+	return !labels.Equals(oldPod.Labels, newPod.Labels) || oldPod.Status.PodIP != newPod.Status.PodIP
+}
+
+func namespaceChanged(oldObj interface{}, newObj interface{}) bool {
+	oldNs, oldOk := oldObj.(*v1.Namespace)
+	newNs, newOk := newObj.(*v1.Namespace)
+	if !oldOk || !newOk {
+		return true
+	}
+	return !labels.Equals(oldNs.Labels, newNs.Labels)
+}
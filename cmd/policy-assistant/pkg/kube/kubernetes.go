@@ -25,7 +25,7 @@ var ErrNotImplemented = errors.New("Not implemented")
 
 type Kubernetes struct {
 	ClientSet      *kubernetes.Clientset
-	alphaClientSet *v1alpha1.PolicyV1alpha1Client
+	alphaClientSet v1alpha1.PolicyV1alpha1Interface
 	RestConfig     *rest.Config
 }
 
@@ -121,16 +121,27 @@ func (k *Kubernetes) GetAdminNetworkPolicies(ctx context.Context) ([]v1alpha12.A
 }
 
 func (k *Kubernetes) CreateAdminNetworkPolicy(ctx context.Context, policy *v1alpha12.AdminNetworkPolicy) (*v1alpha12.AdminNetworkPolicy, error) {
-	return nil, ErrNotImplemented
+	logrus.Debugf("creating admin network policy %s", policy.Name)
+	anp, err := k.alphaClientSet.AdminNetworkPolicies().Create(ctx, policy, metav1.CreateOptions{})
+	return anp, errors.Wrapf(err, "unable to create admin network policy %s", policy.Name)
 }
 
 func (k *Kubernetes) UpdateAdminNetworkPolicy(ctx context.Context, policy *v1alpha12.AdminNetworkPolicy) (*v1alpha12.AdminNetworkPolicy, error) {
-	return nil, ErrNotImplemented
+	logrus.Debugf("updating admin network policy %s", policy.Name)
+	// updates need the resourceVersion of the live object, which the policies of test cases don't have
+	live, err := k.alphaClientSet.AdminNetworkPolicies().Get(ctx, policy.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to get admin network policy %s", policy.Name)
+	}
+	policy = policy.DeepCopy()
+	policy.ResourceVersion = live.ResourceVersion
+	anp, err := k.alphaClientSet.AdminNetworkPolicies().Update(ctx, policy, metav1.UpdateOptions{})
+	return anp, errors.Wrapf(err, "unable to update admin network policy %s", policy.Name)
 }
 
 func (k *Kubernetes) DeleteAdminNetworkPolicy(ctx context.Context, name string) error {
-	//TODO: implement
-	return ErrNotImplemented
+	logrus.Debugf("deleting admin network policy %s", name)
+	return errors.Wrapf(k.alphaClientSet.AdminNetworkPolicies().Delete(ctx, name, metav1.DeleteOptions{}), "unable to delete admin network policy %s", name)
 }
 
 func (k *Kubernetes) GetBaselineAdminNetworkPolicy(ctx context.Context) (*v1alpha12.BaselineAdminNetworkPolicy, error) {
@@ -146,16 +157,26 @@ func (k *Kubernetes) GetBaselineAdminNetworkPolicy(ctx context.Context) (*v1alph
 }
 
 func (k *Kubernetes) CreateBaselineAdminNetworkPolicy(ctx context.Context, policy *v1alpha12.BaselineAdminNetworkPolicy) (*v1alpha12.BaselineAdminNetworkPolicy, error) {
-	return nil, ErrNotImplemented
+	logrus.Debugf("creating baseline admin network policy %s", policy.Name)
+	banp, err := k.alphaClientSet.BaselineAdminNetworkPolicies().Create(ctx, policy, metav1.CreateOptions{})
+	return banp, errors.Wrapf(err, "unable to create baseline admin network policy %s", policy.Name)
 }
 
 func (k *Kubernetes) UpdateBaselineAdminNetworkPolicy(ctx context.Context, policy *v1alpha12.BaselineAdminNetworkPolicy) (*v1alpha12.BaselineAdminNetworkPolicy, error) {
-	return nil, ErrNotImplemented
+	logrus.Debugf("updating baseline admin network policy %s", policy.Name)
+	live, err := k.alphaClientSet.BaselineAdminNetworkPolicies().Get(ctx, policy.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to get baseline admin network policy %s", policy.Name)
+	}
+	policy = policy.DeepCopy()
+	policy.ResourceVersion = live.ResourceVersion
+	banp, err := k.alphaClientSet.BaselineAdminNetworkPolicies().Update(ctx, policy, metav1.UpdateOptions{})
+	return banp, errors.Wrapf(err, "unable to update baseline admin network policy %s", policy.Name)
 }
 
 func (k *Kubernetes) DeleteBaselineAdminNetworkPolicy(ctx context.Context, name string) error {
-	//TODO: implement
-	return ErrNotImplemented
+	logrus.Debugf("deleting baseline admin network policy %s", name)
+	return errors.Wrapf(k.alphaClientSet.BaselineAdminNetworkPolicies().Delete(ctx, name, metav1.DeleteOptions{}), "unable to delete baseline admin network policy %s", name)
 }
 
 func (k *Kubernetes) UpdateNetworkPolicy(policy *networkingv1.NetworkPolicy) (*networkingv1.NetworkPolicy, error) {
@@ -208,6 +229,11 @@ func (k *Kubernetes) GetReplicaSet(namespace string, name string) (*appsv1.Repli
 	return replicaSet, errors.Wrapf(err, "unable to get replicaSet %s/%s", namespace, name)
 }
 
+func (k *Kubernetes) GetNode(name string) (*v1.Node, error) {
+	node, err := k.ClientSet.CoreV1().Nodes().Get(context.TODO(), name, metav1.GetOptions{})
+	return node, errors.Wrapf(err, "unable to get node %s", name)
+}
+
 func (k *Kubernetes) GetService(namespace string, name string) (*v1.Service, error) {
 	service, err := k.ClientSet.CoreV1().Services(namespace).Get(context.TODO(), name, metav1.GetOptions{})
 	return service, errors.Wrapf(err, "unable to get service %s/%s", namespace, name)
@@ -0,0 +1,68 @@
+package kube
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stesting "k8s.io/client-go/testing"
+	"sigs.k8s.io/network-policy-api/apis/v1alpha1"
+	"sigs.k8s.io/network-policy-api/pkg/client/clientset/versioned/fake"
+)
+
+func RunKubernetesTests() {
+	Describe("Kubernetes admin network policies", func() {
+		anp := &v1alpha1.AdminNetworkPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "anp", ResourceVersion: "7"},
+			Spec:       v1alpha1.AdminNetworkPolicySpec{Priority: 1},
+		}
+		banp := &v1alpha1.BaselineAdminNetworkPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "default", ResourceVersion: "8"},
+		}
+		updatedObject := func(clientset *fake.Clientset) interface{} {
+			var updated interface{}
+			for _, action := range clientset.Actions() {
+				if update, ok := action.(k8stesting.UpdateAction); ok {
+					updated = update.GetObject()
+				}
+			}
+			return updated
+		}
+
+		It("Should send the live resourceVersion when updating an ANP", func() {
+			clientset := fake.NewSimpleClientset(anp)
+			k := &Kubernetes{alphaClientSet: clientset.PolicyV1alpha1()}
+
+			update := &v1alpha1.AdminNetworkPolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: "anp"},
+				Spec:       v1alpha1.AdminNetworkPolicySpec{Priority: 2},
+			}
+			_, err := k.UpdateAdminNetworkPolicy(context.TODO(), update)
+			Expect(err).NotTo(HaveOccurred())
+
+			sent := updatedObject(clientset).(*v1alpha1.AdminNetworkPolicy)
+			Expect(sent.ResourceVersion).To(Equal("7"))
+			Expect(sent.Spec.Priority).To(Equal(int32(2)))
+			Expect(update.ResourceVersion).To(BeEmpty())
+		})
+
+		It("Should send the live resourceVersion when updating a BANP", func() {
+			clientset := fake.NewSimpleClientset(banp)
+			k := &Kubernetes{alphaClientSet: clientset.PolicyV1alpha1()}
+
+			_, err := k.UpdateBaselineAdminNetworkPolicy(context.TODO(), &v1alpha1.BaselineAdminNetworkPolicy{ObjectMeta: metav1.ObjectMeta{Name: "default"}})
+			Expect(err).NotTo(HaveOccurred())
+
+			sent := updatedObject(clientset).(*v1alpha1.BaselineAdminNetworkPolicy)
+			Expect(sent.ResourceVersion).To(Equal("8"))
+		})
+
+		It("Should fail to update an ANP which doesn't exist", func() {
+			k := &Kubernetes{alphaClientSet: fake.NewSimpleClientset().PolicyV1alpha1()}
+
+			_, err := k.UpdateAdminNetworkPolicy(context.TODO(), anp)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/mattfenwick/collections/pkg/slice"
+	"github.com/pkg/errors"
 	"golang.org/x/exp/maps"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/utils"
@@ -68,8 +69,9 @@ func IsMatchExpressionMatchForLabels(labels map[string]string, exp metav1.LabelS
 func IsLabelsMatchLabelSelector(labels map[string]string, labelSelector metav1.LabelSelector) bool {
 	// From the docs: "The requirements are ANDed."
 	//   Therefore, all MatchLabels must be matched.
+	//   A missing key is not a match, even for an empty value (e.g. "node-role.kubernetes.io/control-plane: ''").
 	for key, val := range labelSelector.MatchLabels {
-		if labels[key] != val {
+		if actual, ok := labels[key]; !ok || actual != val {
 			return false
 		}
 	}
@@ -87,6 +89,79 @@ func IsLabelsMatchLabelSelector(labels map[string]string, labelSelector metav1.L
 	return true
 }
 
+// AreLabelSelectorsDisjoint returns true if no set of labels can match both label selectors.
+// Since each requirement only constrains a single key, this is the case iff the combined
+// requirements on some key can't be satisfied.  An error is returned for invalid operators.
+func AreLabelSelectorsDisjoint(a metav1.LabelSelector, b metav1.LabelSelector) (bool, error) {
+	type keyRequirement struct {
+		mustExist    bool
+		mustNotExist bool
+		// allowed is nil if any value is allowed
+		allowed  map[string]bool
+		excluded map[string]bool
+	}
+	requirements := map[string]*keyRequirement{}
+	get := func(key string) *keyRequirement {
+		if _, ok := requirements[key]; !ok {
+			requirements[key] = &keyRequirement{excluded: map[string]bool{}}
+		}
+		return requirements[key]
+	}
+	allow := func(r *keyRequirement, values []string) {
+		allowed := map[string]bool{}
+		for _, v := range values {
+			if r.allowed == nil || r.allowed[v] {
+				allowed[v] = true
+			}
+		}
+		r.allowed = allowed
+		r.mustExist = true
+	}
+
+	for _, selector := range []metav1.LabelSelector{a, b} {
+		for key, val := range selector.MatchLabels {
+			allow(get(key), []string{val})
+		}
+		for _, exp := range selector.MatchExpressions {
+			r := get(exp.Key)
+			switch exp.Operator {
+			case metav1.LabelSelectorOpIn:
+				allow(r, exp.Values)
+			case metav1.LabelSelectorOpNotIn:
+				r.mustExist = true
+				for _, v := range exp.Values {
+					r.excluded[v] = true
+				}
+			case metav1.LabelSelectorOpExists:
+				r.mustExist = true
+			case metav1.LabelSelectorOpDoesNotExist:
+				r.mustNotExist = true
+			default:
+				return false, errors.Errorf("invalid operator %s for label selector key %s", exp.Operator, exp.Key)
+			}
+		}
+	}
+
+	for _, r := range requirements {
+		if r.mustExist && r.mustNotExist {
+			return true, nil
+		}
+		if r.allowed != nil {
+			satisfiable := false
+			for v := range r.allowed {
+				if !r.excluded[v] {
+					satisfiable = true
+					break
+				}
+			}
+			if !satisfiable {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
 func IsLabelSelectorEmpty(l metav1.LabelSelector) bool {
 	return len(l.MatchLabels) == 0 && len(l.MatchExpressions) == 0
 }
@@ -13,5 +13,43 @@ func RunLabelSelectorTests() {
 				MatchLabels: map[string]string{"pod": "b"},
 			})).To(BeFalse())
 		})
+
+		It("Should not match a missing key against an empty value", func() {
+			Expect(IsLabelsMatchLabelSelector(map[string]string{"kubernetes.io/hostname": "worker"}, metav1.LabelSelector{
+				MatchLabels: map[string]string{"node-role.kubernetes.io/control-plane": ""},
+			})).To(BeFalse())
+		})
+
+		It("Should find disjoint label selectors", func() {
+			app := func(values ...string) metav1.LabelSelector {
+				return metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+					{Key: "app", Operator: metav1.LabelSelectorOpIn, Values: values},
+				}}
+			}
+			disjoint := func(a metav1.LabelSelector, b metav1.LabelSelector) bool {
+				isDisjoint, err := AreLabelSelectorsDisjoint(a, b)
+				Expect(err).To(Succeed())
+				return isDisjoint
+			}
+			Expect(disjoint(metav1.LabelSelector{}, metav1.LabelSelector{})).To(BeFalse())
+			Expect(disjoint(metav1.LabelSelector{MatchLabels: map[string]string{"app": "a"}}, metav1.LabelSelector{MatchLabels: map[string]string{"app": "b"}})).To(BeTrue())
+			Expect(disjoint(metav1.LabelSelector{MatchLabels: map[string]string{"app": "a"}}, metav1.LabelSelector{MatchLabels: map[string]string{"tier": "b"}})).To(BeFalse())
+			Expect(disjoint(app("a", "b"), app("b", "c"))).To(BeFalse())
+			Expect(disjoint(app("a", "b"), app("c"))).To(BeTrue())
+			Expect(disjoint(app("a"), metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+				{Key: "app", Operator: metav1.LabelSelectorOpNotIn, Values: []string{"a"}},
+			}})).To(BeTrue())
+			Expect(disjoint(metav1.LabelSelector{}, metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+				{Key: "app", Operator: metav1.LabelSelectorOpExists},
+				{Key: "app", Operator: metav1.LabelSelectorOpDoesNotExist},
+			}})).To(BeTrue())
+		})
+
+		It("Should reject invalid operators when looking for disjoint label selectors", func() {
+			_, err := AreLabelSelectorsDisjoint(metav1.LabelSelector{}, metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+				{Key: "app", Operator: "Equals", Values: []string{"a"}},
+			}})
+			Expect(err).ToNot(Succeed())
+		})
 	})
 }
@@ -10,6 +10,7 @@ import (
 func TestModel(t *testing.T) {
 	RegisterFailHandler(Fail)
 	RunIPAddressTests()
+	RunKubernetesTests()
 	RunLabelSelectorTests()
 	RunReadNetworkPolicyTests()
 	RunSpecs(t, "network policy matcher suite")
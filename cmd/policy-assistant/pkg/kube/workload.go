@@ -0,0 +1,65 @@
+package kube
+
+import (
+	"fmt"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+)
+
+// Workload is a pod, replicaset, deployment, daemonset or statefulset; Kind is lower case
+type Workload struct {
+	Namespace string
+	Kind      string
+	Name      string
+}
+
+// String is the "<namespace>/<workloadType>/<workloadName>" form of workload traffic
+func (w Workload) String() string {
+	return fmt.Sprintf("%s/%s/%s", w.Namespace, w.Kind, w.Name)
+}
+
+// ReplicaSetDeployments maps replicasets, by "<namespace>/<name>", to the name of the deployment owning them
+func ReplicaSetDeployments(replicaSets []appsv1.ReplicaSet) map[string]string {
+	deployments := map[string]string{}
+	for _, rs := range replicaSets {
+		if len(rs.OwnerReferences) > 0 && rs.OwnerReferences[0].Kind == "Deployment" {
+			deployments[rs.Namespace+"/"+rs.Name] = rs.OwnerReferences[0].Name
+		}
+	}
+	return deployments
+}
+
+// PodOwnerWorkloads returns the workloads a pod belongs to, from the pod itself out to its outermost owner: the
+// replicaset, daemonset or statefulset owning it, and the deployment owning that replicaset, as found in
+// replicaSetDeployments.  Other owners, such as jobs, aren't workloads.
+func PodOwnerWorkloads(pod *v1.Pod, replicaSetDeployments map[string]string) []Workload {
+	workloads := []Workload{{Namespace: pod.Namespace, Kind: "pod", Name: pod.Name}}
+	if len(pod.OwnerReferences) == 0 {
+		return workloads
+	}
+	owner := pod.OwnerReferences[0]
+	switch owner.Kind {
+	case "ReplicaSet", "DaemonSet", "StatefulSet":
+		workloads = append(workloads, Workload{Namespace: pod.Namespace, Kind: strings.ToLower(owner.Kind), Name: owner.Name})
+	}
+	if owner.Kind == "ReplicaSet" {
+		if deployment, ok := replicaSetDeployments[pod.Namespace+"/"+owner.Name]; ok {
+			workloads = append(workloads, Workload{Namespace: pod.Namespace, Kind: "deployment", Name: deployment})
+		}
+	}
+	return workloads
+}
+
+// PodWorkloads maps each pod, by "<namespace>/<name>", to the outermost workload owning it, in the
+// "<namespace>/<workloadType>/<workloadName>" form of workload traffic: see PodOwnerWorkloads.
+func PodWorkloads(pods []v1.Pod, replicaSets []appsv1.ReplicaSet) map[string]string {
+	replicaSetDeployments := ReplicaSetDeployments(replicaSets)
+	workloads := map[string]string{}
+	for i := range pods {
+		owners := PodOwnerWorkloads(&pods[i], replicaSetDeployments)
+		workloads[pods[i].Namespace+"/"+pods[i].Name] = owners[len(owners)-1].String()
+	}
+	return workloads
+}
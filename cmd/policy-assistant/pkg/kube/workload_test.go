@@ -0,0 +1,68 @@
+package kube
+
+import (
+	"reflect"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPodWorkloads(t *testing.T) {
+	owned := func(name string, kind string, owner string) v1.Pod {
+		pod := v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "x", Name: name}}
+		if kind != "" {
+			pod.OwnerReferences = []metav1.OwnerReference{{Kind: kind, Name: owner}}
+		}
+		return pod
+	}
+	pods := []v1.Pod{
+		owned("web-5d8f-abcde", "ReplicaSet", "web-5d8f"),
+		owned("batch-xyz", "ReplicaSet", "batch"),
+		owned("agent-12345", "DaemonSet", "agent"),
+		owned("db-0", "StatefulSet", "db"),
+		owned("job-abc", "Job", "job"),
+		owned("standalone", "", ""),
+	}
+	replicaSets := []appsv1.ReplicaSet{
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "x", Name: "web-5d8f", OwnerReferences: []metav1.OwnerReference{{Kind: "Deployment", Name: "web"}}}},
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "x", Name: "batch"}},
+	}
+
+	expected := map[string]string{
+		"x/web-5d8f-abcde": "x/deployment/web",
+		"x/batch-xyz":      "x/replicaset/batch",
+		"x/agent-12345":    "x/daemonset/agent",
+		"x/db-0":           "x/statefulset/db",
+		"x/job-abc":        "x/pod/job-abc",
+		"x/standalone":     "x/pod/standalone",
+	}
+	if actual := PodWorkloads(pods, replicaSets); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %+v, got %+v", expected, actual)
+	}
+}
+
+func TestPodOwnerWorkloads(t *testing.T) {
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Namespace:       "x",
+		Name:            "web-5d8f-abcde",
+		OwnerReferences: []metav1.OwnerReference{{Kind: "ReplicaSet", Name: "web-5d8f"}},
+	}}
+	replicaSetDeployments := ReplicaSetDeployments([]appsv1.ReplicaSet{
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "x", Name: "web-5d8f", OwnerReferences: []metav1.OwnerReference{{Kind: "Deployment", Name: "web"}}}},
+	})
+
+	expected := []Workload{
+		{Namespace: "x", Kind: "pod", Name: "web-5d8f-abcde"},
+		{Namespace: "x", Kind: "replicaset", Name: "web-5d8f"},
+		{Namespace: "x", Kind: "deployment", Name: "web"},
+	}
+	if actual := PodOwnerWorkloads(pod, replicaSetDeployments); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %+v, got %+v", expected, actual)
+	}
+	// without its replicaset, the pod's deployment is unknown
+	if actual := PodOwnerWorkloads(pod, nil); !reflect.DeepEqual(actual, expected[:2]) {
+		t.Errorf("expected %+v, got %+v", expected[:2], actual)
+	}
+}
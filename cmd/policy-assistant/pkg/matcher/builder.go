@@ -1,11 +1,10 @@
 package matcher
 
 import (
-	"fmt"
-
 	"github.com/pkg/errors"
 	v1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"sigs.k8s.io/network-policy-api/apis/v1alpha1"
 	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/kube"
@@ -232,7 +231,7 @@ func BuildTargetANP(anp *v1alpha1.AdminNetworkPolicy) (*Target, *Target) {
 
 		for _, r := range anp.Spec.Ingress {
 			v := AdminActionToVerdict(r.Action)
-			matchers := BuildPeerMatcherAdmin(r.From, r.Ports)
+			matchers := BuildIngressPeerMatcherAdmin(r.From, r.Ports)
 			for _, m := range matchers {
 				matcherAdmin := NewPeerMatcherANP(m, v, int(anp.Spec.Priority), anp.Name, r.Name)
 				ingress.Peers = append(ingress.Peers, matcherAdmin)
@@ -248,7 +247,14 @@ func BuildTargetANP(anp *v1alpha1.AdminNetworkPolicy) (*Target, *Target) {
 
 		for _, r := range anp.Spec.Egress {
 			v := AdminActionToVerdict(r.Action)
-			matchers := BuildPeerMatcherAdmin(r.To, r.Ports)
+			if v != Allow {
+				for _, peer := range r.To {
+					if len(peer.DomainNames) > 0 {
+						panic(errors.Errorf("invalid AdminNetworkPolicy egress rule %s: DomainNames peers are only supported for Allow rules", r.Name))
+					}
+				}
+			}
+			matchers := BuildEgressPeerMatcherAdmin(r.To, r.Ports)
 			for _, m := range matchers {
 				matcherAdmin := NewPeerMatcherANP(m, v, int(anp.Spec.Priority), anp.Name, r.Name)
 				egress.Peers = append(egress.Peers, matcherAdmin)
@@ -275,7 +281,7 @@ func BuildTargetBANP(banp *v1alpha1.BaselineAdminNetworkPolicy) (*Target, *Targe
 
 		for _, r := range banp.Spec.Ingress {
 			v := BaselineAdminActionToVerdict(r.Action)
-			matchers := BuildPeerMatcherAdmin(r.From, r.Ports)
+			matchers := BuildIngressPeerMatcherAdmin(r.From, r.Ports)
 			for _, m := range matchers {
 				matcherAdmin := NewPeerMatcherBANP(m, v, banp.Name, r.Name)
 				ingress.Peers = append(ingress.Peers, matcherAdmin)
@@ -291,7 +297,7 @@ func BuildTargetBANP(banp *v1alpha1.BaselineAdminNetworkPolicy) (*Target, *Targe
 
 		for _, r := range banp.Spec.Egress {
 			v := BaselineAdminActionToVerdict(r.Action)
-			matchers := BuildPeerMatcherAdmin(r.To, r.Ports)
+			matchers := BuildEgressPeerMatcherAdmin(banpEgressPeersToANP(r.To), r.Ports)
 			for _, m := range matchers {
 				matcherAdmin := NewPeerMatcherBANP(m, v, banp.Name, r.Name)
 				egress.Peers = append(egress.Peers, matcherAdmin)
@@ -302,84 +308,88 @@ func BuildTargetBANP(banp *v1alpha1.BaselineAdminNetworkPolicy) (*Target, *Targe
 	return ingress, egress
 }
 
-func BuildPeerMatcherAdmin(peers []v1alpha1.AdminNetworkPolicyPeer, ports *[]v1alpha1.AdminNetworkPolicyPort) []*PodPeerMatcher {
+// banpEgressPeersToANP converts BANP egress peers, which are a subset of ANP egress peers (no DomainNames)
+func banpEgressPeersToANP(banpPeers []v1alpha1.BaselineAdminNetworkPolicyEgressPeer) []v1alpha1.AdminNetworkPolicyEgressPeer {
+	var peers []v1alpha1.AdminNetworkPolicyEgressPeer
+	for _, peer := range banpPeers {
+		peers = append(peers, v1alpha1.AdminNetworkPolicyEgressPeer{
+			Namespaces: peer.Namespaces,
+			Pods:       peer.Pods,
+			Nodes:      peer.Nodes,
+			Networks:   peer.Networks,
+		})
+	}
+	return peers
+}
+
+func BuildIngressPeerMatcherAdmin(peers []v1alpha1.AdminNetworkPolicyIngressPeer, ports *[]v1alpha1.AdminNetworkPolicyPort) []PeerMatcher {
 	if len(peers) == 0 {
-		panic(errors.Errorf("invalid admin to/from field: must have at least one peer"))
+		panic(errors.Errorf("invalid admin from field: must have at least one peer"))
 	}
 
-	// 1. build port matcher
-	var portMatcher PortMatcher
-	if ports == nil {
-		portMatcher = BuildPortMatcherAdmin(nil)
-	} else {
-		portMatcher = BuildPortMatcherAdmin(*ports)
-	}
+	portMatcher := buildPortMatcherAdminFromPointer(ports)
 
-	// 2. build Peers
-	var peerMatchers []*PodPeerMatcher
+	var peerMatchers []PeerMatcher
 	for _, peer := range peers {
 		if (peer.Namespaces == nil && peer.Pods == nil) || (peer.Namespaces != nil && peer.Pods != nil) {
-			panic(errors.Errorf("invalid admin peer: must have exactly one of Namespaces or Pods"))
+			panic(errors.Errorf("invalid admin ingress peer: must have exactly one of Namespaces or Pods"))
 		}
+		peerMatchers = append(peerMatchers, BuildPodPeerMatcherAdmin(peer.Namespaces, peer.Pods, portMatcher))
+	}
 
-		var ns v1alpha1.NamespacedPeer
-		var podMatcher PodMatcher
-		if peer.Pods != nil {
-			ns = peer.Pods.Namespaces
+	return peerMatchers
+}
 
-			// TODO account for Tenancy or Pod same/not-same labels when these become features
-			podSel := peer.Pods.PodSelector
-			if kube.IsLabelSelectorEmpty(podSel) {
-				podMatcher = &AllPodMatcher{}
-			} else {
-				podMatcher = &LabelSelectorPodMatcher{Selector: podSel}
-			}
-		} else {
-			// peer.Namespaces is non-nil
-			ns = *peer.Namespaces
-			podMatcher = &AllPodMatcher{}
-		}
+func BuildEgressPeerMatcherAdmin(peers []v1alpha1.AdminNetworkPolicyEgressPeer, ports *[]v1alpha1.AdminNetworkPolicyPort) []PeerMatcher {
+	if len(peers) == 0 {
+		panic(errors.Errorf("invalid admin to field: must have at least one peer"))
+	}
 
+	portMatcher := buildPortMatcherAdminFromPointer(ports)
+
+	var peerMatchers []PeerMatcher
+	for _, peer := range peers {
 		nonNilCount := 0
-		if ns.NamespaceSelector != nil {
+		if peer.Namespaces != nil {
+			nonNilCount++
+		}
+		if peer.Pods != nil {
+			nonNilCount++
+		}
+		if peer.Nodes != nil {
 			nonNilCount++
 		}
-		if ns.SameLabels != nil {
-			fmt.Println("WARN: SameLabels is deprecated and will be removed after v0.1.1 (alpha) of sigs.k8s.io/network-policy-api. Tenancy will replace this concept.")
+		if len(peer.Networks) > 0 {
 			nonNilCount++
 		}
-		if ns.NotSameLabels != nil {
-			fmt.Println("WARN: NotSameLabels is deprecated and will be removed after v0.1.1 (alpha) of sigs.k8s.io/network-policy-api. Tenancy will replace this concept.")
+		if len(peer.DomainNames) > 0 {
 			nonNilCount++
 		}
 		if nonNilCount != 1 {
-			panic(errors.Errorf("invalid admin peer: must have exactly one of NamespaceSelector, SameLabels, or NotSameLabels"))
+			panic(errors.Errorf("invalid admin egress peer: must have exactly one of Namespaces, Pods, Nodes, Networks, or DomainNames"))
 		}
 
-		var nsMatcher NamespaceMatcher
-		if ns.NamespaceSelector != nil {
-			if kube.IsLabelSelectorEmpty(*ns.NamespaceSelector) {
-				nsMatcher = &AllNamespaceMatcher{}
-			} else {
-				nsMatcher = &LabelSelectorNamespaceMatcher{
-					Selector: *ns.NamespaceSelector,
-				}
+		var m PeerMatcher
+		if peer.Nodes != nil {
+			m = &NodePeerMatcher{Selector: *peer.Nodes, Port: portMatcher}
+		} else if len(peer.Networks) > 0 {
+			var cidrs []string
+			for _, cidr := range peer.Networks {
+				cidrs = append(cidrs, string(cidr))
 			}
-		} else if ns.SameLabels != nil {
-			nsMatcher = &SameLabelsNamespaceMatcher{
-				labels: ns.SameLabels,
+			networks, err := NewNetworksPeerMatcher(cidrs, portMatcher)
+			if err != nil {
+				panic(errors.Wrapf(err, "invalid admin egress peer"))
 			}
-		} else {
-			// ns.NotSameLabels is non-nil
-			nsMatcher = &NotSameLabelsNamespaceMatcher{
-				labels: ns.NotSameLabels,
+			m = networks
+		} else if len(peer.DomainNames) > 0 {
+			domainNames := &DomainNamePeerMatcher{Port: portMatcher}
+			for _, name := range peer.DomainNames {
+				domainNames.DomainNames = append(domainNames.DomainNames, string(name))
 			}
-		}
-
-		m := &PodPeerMatcher{
-			Namespace: nsMatcher,
-			Pod:       podMatcher,
-			Port:      portMatcher,
+			m = domainNames
+		} else {
+			m = BuildPodPeerMatcherAdmin(peer.Namespaces, peer.Pods, portMatcher)
 		}
 		peerMatchers = append(peerMatchers, m)
 	}
@@ -387,6 +397,46 @@ func BuildPeerMatcherAdmin(peers []v1alpha1.AdminNetworkPolicyPeer, ports *[]v1a
 	return peerMatchers
 }
 
+// BuildPodPeerMatcherAdmin builds the matcher for a Namespaces or Pods admin peer.
+// Exactly one of namespaces and pods is expected to be non-nil.
+func BuildPodPeerMatcherAdmin(namespaces *metav1.LabelSelector, pods *v1alpha1.NamespacedPod, portMatcher PortMatcher) *PodPeerMatcher {
+	var nsSelector metav1.LabelSelector
+	var podMatcher PodMatcher
+	if pods != nil {
+		nsSelector = pods.NamespaceSelector
+
+		podSel := pods.PodSelector
+		if kube.IsLabelSelectorEmpty(podSel) {
+			podMatcher = &AllPodMatcher{}
+		} else {
+			podMatcher = &LabelSelectorPodMatcher{Selector: podSel}
+		}
+	} else {
+		nsSelector = *namespaces
+		podMatcher = &AllPodMatcher{}
+	}
+
+	var nsMatcher NamespaceMatcher
+	if kube.IsLabelSelectorEmpty(nsSelector) {
+		nsMatcher = &AllNamespaceMatcher{}
+	} else {
+		nsMatcher = &LabelSelectorNamespaceMatcher{Selector: nsSelector}
+	}
+
+	return &PodPeerMatcher{
+		Namespace: nsMatcher,
+		Pod:       podMatcher,
+		Port:      portMatcher,
+	}
+}
+
+func buildPortMatcherAdminFromPointer(ports *[]v1alpha1.AdminNetworkPolicyPort) PortMatcher {
+	if ports == nil {
+		return BuildPortMatcherAdmin(nil)
+	}
+	return BuildPortMatcherAdmin(*ports)
+}
+
 func BuildPortMatcherAdmin(ports []v1alpha1.AdminNetworkPolicyPort) PortMatcher {
 	if len(ports) == 0 {
 		return &AllPortMatcher{}
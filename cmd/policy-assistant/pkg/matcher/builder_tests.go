@@ -8,6 +8,7 @@ import (
 	networkingv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/network-policy-api/apis/v1alpha1"
 	"sigs.k8s.io/network-policy-api/policy-assistant/examples"
 	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/kube/netpol"
 )
@@ -351,4 +352,61 @@ func RunBuilderTests() {
 			Expect(firstRule.SourceRules).To(HaveLen(2))
 		})
 	})
+
+	Describe("PeerMatcher from admin egress peers", func() {
+		It("builds one matcher per peer type", func() {
+			matchers := BuildEgressPeerMatcherAdmin([]v1alpha1.AdminNetworkPolicyEgressPeer{
+				{Namespaces: &metav1.LabelSelector{}},
+				{Pods: &v1alpha1.NamespacedPod{
+					NamespaceSelector: metav1.LabelSelector{MatchLabels: map[string]string{"ns": "x"}},
+					PodSelector:       metav1.LabelSelector{MatchLabels: map[string]string{"pod": "a"}},
+				}},
+				{Nodes: &metav1.LabelSelector{MatchLabels: map[string]string{"node-role.kubernetes.io/control-plane": ""}}},
+				{Networks: []v1alpha1.CIDR{"10.0.0.0/8"}},
+				{DomainNames: []v1alpha1.DomainName{"*.kubernetes.io"}},
+			}, nil)
+
+			Expect(matchers).To(Equal([]PeerMatcher{
+				&PodPeerMatcher{Namespace: &AllNamespaceMatcher{}, Pod: &AllPodMatcher{}, Port: &AllPortMatcher{}},
+				&PodPeerMatcher{
+					Namespace: &LabelSelectorNamespaceMatcher{Selector: metav1.LabelSelector{MatchLabels: map[string]string{"ns": "x"}}},
+					Pod:       &LabelSelectorPodMatcher{Selector: metav1.LabelSelector{MatchLabels: map[string]string{"pod": "a"}}},
+					Port:      &AllPortMatcher{},
+				},
+				&NodePeerMatcher{Selector: metav1.LabelSelector{MatchLabels: map[string]string{"node-role.kubernetes.io/control-plane": ""}}, Port: &AllPortMatcher{}},
+				&NetworksPeerMatcher{Networks: []string{"10.0.0.0/8"}, Port: &AllPortMatcher{}},
+				&DomainNamePeerMatcher{DomainNames: []string{"*.kubernetes.io"}, Port: &AllPortMatcher{}},
+			}))
+		})
+
+		It("rejects peers with more than one peer type", func() {
+			Expect(func() {
+				BuildEgressPeerMatcherAdmin([]v1alpha1.AdminNetworkPolicyEgressPeer{
+					{Namespaces: &metav1.LabelSelector{}, Networks: []v1alpha1.CIDR{"10.0.0.0/8"}},
+				}, nil)
+			}).To(Panic())
+		})
+
+		It("rejects invalid Networks CIDRs", func() {
+			Expect(func() {
+				BuildEgressPeerMatcherAdmin([]v1alpha1.AdminNetworkPolicyEgressPeer{
+					{Networks: []v1alpha1.CIDR{"10.0.0.0/8", "10.0.0.300/32"}},
+				}, nil)
+			}).To(Panic())
+		})
+
+		It("rejects DomainNames peers in non-Allow rules", func() {
+			Expect(func() {
+				BuildTargetANP(&v1alpha1.AdminNetworkPolicy{
+					Spec: v1alpha1.AdminNetworkPolicySpec{
+						Subject: v1alpha1.AdminNetworkPolicySubject{Namespaces: &metav1.LabelSelector{}},
+						Egress: []v1alpha1.AdminNetworkPolicyEgressRule{{
+							Action: v1alpha1.AdminNetworkPolicyRuleActionDeny,
+							To:     []v1alpha1.AdminNetworkPolicyEgressPeer{{DomainNames: []v1alpha1.DomainName{"kubernetes.io"}}},
+						}},
+					},
+				})
+			}).To(Panic())
+		})
+	})
 }
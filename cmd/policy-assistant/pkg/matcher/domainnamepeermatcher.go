@@ -0,0 +1,70 @@
+package matcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/mattfenwick/collections/pkg/slice"
+	v1 "k8s.io/api/core/v1"
+)
+
+// DomainNamePeerMatcher matches traffic to external peers by domain name.
+// It models the DomainNames peer of ANP egress rules (see NPEP-133), which is only supported for Allow rules.
+// The domain names of a peer are its DomainName, plus whatever its IP resolves to through the Policy's Resolver.
+type DomainNamePeerMatcher struct {
+	DomainNames []string
+	Port        PortMatcher
+}
+
+// PrimaryKey returns a content-based, deterministic key based on the domain names.
+func (d *DomainNamePeerMatcher) PrimaryKey() string {
+	return fmt.Sprintf(`{"type": "domain-names", "domainNames": "%s"}`, strings.Join(slice.Sort(d.DomainNames), ", "))
+}
+
+func (d *DomainNamePeerMatcher) Matches(_, peer *TrafficPeer, portInt int, portName string, protocol v1.Protocol) bool {
+	return d.MatchingDomainName(peer) != "" && d.Port.Matches(portInt, portName, protocol)
+}
+
+// MatchingDomainName returns the first domain name of the peer matching one of the DomainNames,
+// or the empty string if there is none.
+func (d *DomainNamePeerMatcher) MatchingDomainName(peer *TrafficPeer) string {
+	// DomainNames only select peers outside the cluster
+	if !peer.IsExternal() {
+		return ""
+	}
+	for _, name := range peer.AllDomainNames() {
+		for _, pattern := range d.DomainNames {
+			if IsDomainNameMatch(pattern, name) {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+func (d *DomainNamePeerMatcher) MarshalJSON() (b []byte, e error) {
+	return json.Marshal(map[string]interface{}{
+		"Type":        "domain names",
+		"DomainNames": d.DomainNames,
+		"Port":        d.Port,
+	})
+}
+
+// IsDomainNameMatch matches a domain name against a DomainName pattern, following the ANP spec:
+// - "kubernetes.io" only matches "kubernetes.io"
+// - "*.kubernetes.io" matches one or more entire labels in front of "kubernetes.io",
+// e.g. "blog.kubernetes.io" and "latest.blog.kubernetes.io", but not "kubernetes.io"
+//
+// Matching is case-insensitive and ignores a trailing dot.
+func IsDomainNameMatch(pattern string, name string) bool {
+	pattern, name = normalizeDomainName(pattern), normalizeDomainName(name)
+	if suffix, isWildcard := strings.CutPrefix(pattern, "*"); isWildcard {
+		return len(name) > len(suffix) && strings.HasSuffix(name, suffix) && strings.HasPrefix(suffix, ".")
+	}
+	return pattern == name
+}
+
+func normalizeDomainName(name string) string {
+	return strings.TrimSuffix(strings.ToLower(name), ".")
+}
@@ -83,16 +83,12 @@ func (s *SliceBuilder) TargetsTableLines(targets []*Target, isIngress bool) {
 		rules := strings.Join(sourceRulesStrings, "\n")
 		s.Prefix = []string{ruleType, target.TargetString(), rules}
 
-		if len(target.Peers) == 1 {
-			if _, ok := target.Peers[0].(*NoMatcher); ok {
-				s.Append("no peers", "NPv1:\n   Allow any peers", "none")
-				continue
-			}
-		}
-
 		peers := groupAnbAndBanp(target.Peers)
 		for _, p := range slice.SortOn(func(p PeerMatcher) string { return json.MustMarshalToString(p) }, peers) {
 			switch t := p.(type) {
+			case *NoMatcher:
+				// may be combined with peers from other policies, see CombineTargetsIgnoringPrimaryKey
+				s.Append("no peers", "NPv1:\n   Allow any peers", "none")
 			case *AllPeersMatcher:
 				s.Append("all pods, all ips", "NPv1:\n   Allow any peers", "all ports, all protocols")
 			case *PortsForAllPeersMatcher:
@@ -196,11 +192,11 @@ func groupAnbAndBanp(p []PeerMatcher) []PeerMatcher {
 	for _, v := range p {
 		switch t := v.(type) {
 		case *PeerMatcherAdmin:
-			k := t.Port.GetPrimaryKey() + t.Pod.PrimaryKey() + t.Namespace.PrimaryKey()
+			k, subject, port := resolveAdminPeer(t)
 			if _, ok := groups[k]; !ok {
 				groups[k] = &peerProtocolGroup{
-					port:     strings.Join(PortMatcherTableLines(t.PodPeerMatcher.Port, t.effectFromMatch.PolicyKind), "\n"),
-					subject:  resolveSubject(t.PodPeerMatcher),
+					port:     strings.Join(PortMatcherTableLines(port, t.effectFromMatch.PolicyKind), "\n"),
+					subject:  subject,
 					policies: map[string]*anpGroup{},
 				}
 			}
@@ -237,6 +233,22 @@ func groupAnbAndBanp(p []PeerMatcher) []PeerMatcher {
 	return result
 }
 
+// resolveAdminPeer returns the grouping key, the peer description and the port matcher of an ANP/BANP peer
+func resolveAdminPeer(t *PeerMatcherAdmin) (string, string, PortMatcher) {
+	switch m := t.PeerMatcher.(type) {
+	case *PodPeerMatcher:
+		return m.Port.GetPrimaryKey() + m.Pod.PrimaryKey() + m.Namespace.PrimaryKey(), resolveSubject(m), m.Port
+	case *NodePeerMatcher:
+		return m.Port.GetPrimaryKey() + m.PrimaryKey(), fmt.Sprintf("Node:\n   %s", strings.TrimSpace(kube.LabelSelectorTableLines(m.Selector))), m.Port
+	case *NetworksPeerMatcher:
+		return m.Port.GetPrimaryKey() + m.PrimaryKey(), fmt.Sprintf("Networks:\n   %s", strings.Join(slice.Sort(m.Networks), "\n   ")), m.Port
+	case *DomainNamePeerMatcher:
+		return m.Port.GetPrimaryKey() + m.PrimaryKey(), fmt.Sprintf("Domain names:\n   %s", strings.Join(slice.Sort(m.DomainNames), "\n   ")), m.Port
+	default:
+		panic(errors.Errorf("invalid admin PeerMatcher type %T", m))
+	}
+}
+
 func resolveSubject(nsPodMatcher *PodPeerMatcher) string {
 	var namespaces string
 	var pods string
@@ -245,10 +257,6 @@ func resolveSubject(nsPodMatcher *PodPeerMatcher) string {
 		namespaces = "all"
 	case *LabelSelectorNamespaceMatcher:
 		namespaces = kube.LabelSelectorTableLines(ns.Selector)
-	case *SameLabelsNamespaceMatcher:
-		namespaces = fmt.Sprintf("Same labels - %s", strings.Join(ns.labels, ", "))
-	case *NotSameLabelsNamespaceMatcher:
-		namespaces = fmt.Sprintf("Not Same labels - %s", strings.Join(ns.labels, ", "))
 	case *ExactNamespaceMatcher:
 		namespaces = ns.Namespace
 	default:
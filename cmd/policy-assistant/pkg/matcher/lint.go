@@ -0,0 +1,595 @@
+package matcher
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/mattfenwick/collections/pkg/json"
+	"github.com/olekukonko/tablewriter"
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/network-policy-api/apis/v1alpha1"
+	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/kube"
+)
+
+type LintSeverity string
+
+const (
+	LintError   LintSeverity = "error"
+	LintWarning LintSeverity = "warning"
+	LintInfo    LintSeverity = "info"
+)
+
+var lintSeverityOrder = map[LintSeverity]int{
+	LintError:   0,
+	LintWarning: 1,
+	LintInfo:    2,
+}
+
+// LintFinding is a problem with a policy, or with one of its rules
+type LintFinding struct {
+	Severity LintSeverity
+	Policy   NetPolID
+	// Rule is only set for findings about a single ANP/BANP rule
+	Rule    string `json:",omitempty"`
+	Message string
+}
+
+// Lint finds problems with policies:
+// - invalid policies, which the other checks leave out
+// - ANP/BANP rules which are fully shadowed by higher precedence rules
+// - ANPs with the same priority and overlapping subjects, whose precedence the spec leaves undefined
+// - NPv1 policies which have no effect after simplification, given the other NPv1 policies
+// - BANP rules which are never reached because NPv1 policies select all of their subjects
+//
+// The checks are conservative: a problem is only reported if it's certain, so not every problem is found.
+func Lint(netpols []*networkingv1.NetworkPolicy, anps []*v1alpha1.AdminNetworkPolicy, banp *v1alpha1.BaselineAdminNetworkPolicy) []*LintFinding {
+	findings := []*LintFinding{}
+	netpols, anps, banp, invalidFindings := lintInvalidPolicies(netpols, anps, banp)
+	findings = append(findings, invalidFindings...)
+	findings = append(findings, lintShadowedAdminRules(anps, banp)...)
+	findings = append(findings, lintSamePriorityANPs(anps)...)
+	findings = append(findings, lintNoOpNetworkPolicies(netpols)...)
+	findings = append(findings, lintUnreachableBANPRules(netpols, banp)...)
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if a.Severity != b.Severity {
+			return lintSeverityOrder[a.Severity] < lintSeverityOrder[b.Severity]
+		}
+		return a.Policy < b.Policy
+	})
+	return findings
+}
+
+func LintFindingsTable(findings []*LintFinding) string {
+	tableString := &strings.Builder{}
+	table := tablewriter.NewWriter(tableString)
+	table.SetAutoWrapText(false)
+	table.SetRowLine(true)
+	table.SetHeader([]string{"Severity", "Policy", "Rule", "Message"})
+
+	for _, f := range findings {
+		table.Append([]string{string(f.Severity), string(f.Policy), f.Rule, f.Message})
+	}
+
+	table.Render()
+	return tableString.String()
+}
+
+// lintInvalidPolicies reports the policies which the builders reject, and returns the remaining ones: the other
+// checks build policies, which panics on invalid ones
+func lintInvalidPolicies(netpols []*networkingv1.NetworkPolicy, anps []*v1alpha1.AdminNetworkPolicy, banp *v1alpha1.BaselineAdminNetworkPolicy) ([]*networkingv1.NetworkPolicy, []*v1alpha1.AdminNetworkPolicy, *v1alpha1.BaselineAdminNetworkPolicy, []*LintFinding) {
+	var findings []*LintFinding
+	isValid := func(policy NetPolID, build func() error) bool {
+		if err := recoverBuildError(build); err != nil {
+			findings = append(findings, &LintFinding{Severity: LintError, Policy: policy, Message: fmt.Sprintf("invalid policy: %s", err.Error())})
+			return false
+		}
+		return true
+	}
+
+	var validNetpols []*networkingv1.NetworkPolicy
+	for _, netpol := range netpols {
+		if isValid(netPolID(netpol), func() error {
+			BuildTarget(netpol)
+			return nil
+		}) {
+			validNetpols = append(validNetpols, netpol)
+		}
+	}
+	var validANPs []*v1alpha1.AdminNetworkPolicy
+	for _, anp := range anps {
+		if isValid(netPolID(anp), func() error {
+			BuildTargetANP(anp)
+			return validateAdminSubject(&anp.Spec.Subject)
+		}) {
+			validANPs = append(validANPs, anp)
+		}
+	}
+	if banp != nil && !isValid(netPolID(banp), func() error {
+		BuildTargetBANP(banp)
+		return validateAdminSubject(&banp.Spec.Subject)
+	}) {
+		banp = nil
+	}
+	return validNetpols, validANPs, banp, findings
+}
+
+// recoverBuildError returns the error of build, or what it panicked with
+func recoverBuildError(build func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if recovered, ok := r.(error); ok {
+				err = recovered
+			} else {
+				err = errors.Errorf("%+v", r)
+			}
+		}
+	}()
+	return build()
+}
+
+// validateAdminSubject checks the selectors of a subject, which the builders take as they are
+func validateAdminSubject(subject *v1alpha1.AdminNetworkPolicySubject) error {
+	nsSelector, podSelector := adminSubjectSelectors(subject)
+	for _, selector := range []metav1.LabelSelector{nsSelector, podSelector} {
+		if _, err := metav1.LabelSelectorAsSelector(&selector); err != nil {
+			return errors.Wrapf(err, "invalid subject")
+		}
+	}
+	return nil
+}
+
+// adminRule is an ANP or BANP rule along with everything needed to determine its precedence
+type adminRule struct {
+	Policy   NetPolID
+	Kind     PolicyKind
+	Priority int
+	// Index is the position of the rule in its policy
+	Index   int
+	Name    string
+	Verdict Verdict
+	Subject *v1alpha1.AdminNetworkPolicySubject
+	Peers   []PeerMatcher
+}
+
+func (r *adminRule) String() string {
+	if r.Kind == AdminNetworkPolicy {
+		return fmt.Sprintf("%s pri=%d (%s)", r.Policy, r.Priority, r.Name)
+	}
+	return fmt.Sprintf("%s (%s)", r.Policy, r.Name)
+}
+
+// precedes returns true if traffic matching r never gets to other
+func (r *adminRule) precedes(other *adminRule) bool {
+	switch {
+	case r.Kind == AdminNetworkPolicy && other.Kind == AdminNetworkPolicy:
+		return r.Priority < other.Priority || (r.Policy == other.Policy && r.Index < other.Index)
+	case r.Kind == AdminNetworkPolicy && other.Kind == BaselineAdminNetworkPolicy:
+		// passed traffic may still get to the BANP
+		return r.Verdict != Pass
+	case r.Kind == BaselineAdminNetworkPolicy && other.Kind == BaselineAdminNetworkPolicy:
+		return r.Policy == other.Policy && r.Index < other.Index
+	default:
+		return false
+	}
+}
+
+func buildAdminRules(anps []*v1alpha1.AdminNetworkPolicy, banp *v1alpha1.BaselineAdminNetworkPolicy, isIngress bool) []*adminRule {
+	var rules []*adminRule
+	for _, anp := range anps {
+		newRule := func(index int, name string, action v1alpha1.AdminNetworkPolicyRuleAction, peers []PeerMatcher) *adminRule {
+			return &adminRule{
+				Policy:   netPolID(anp),
+				Kind:     AdminNetworkPolicy,
+				Priority: int(anp.Spec.Priority),
+				Index:    index,
+				Name:     name,
+				Verdict:  AdminActionToVerdict(action),
+				Subject:  &anp.Spec.Subject,
+				Peers:    peers,
+			}
+		}
+		if isIngress {
+			for i, r := range anp.Spec.Ingress {
+				rules = append(rules, newRule(i, r.Name, r.Action, BuildIngressPeerMatcherAdmin(r.From, r.Ports)))
+			}
+		} else {
+			for i, r := range anp.Spec.Egress {
+				rules = append(rules, newRule(i, r.Name, r.Action, BuildEgressPeerMatcherAdmin(r.To, r.Ports)))
+			}
+		}
+	}
+
+	if banp != nil {
+		newRule := func(index int, name string, action v1alpha1.BaselineAdminNetworkPolicyRuleAction, peers []PeerMatcher) *adminRule {
+			return &adminRule{
+				Policy:  netPolID(banp),
+				Kind:    BaselineAdminNetworkPolicy,
+				Index:   index,
+				Name:    name,
+				Verdict: BaselineAdminActionToVerdict(action),
+				Subject: &banp.Spec.Subject,
+				Peers:   peers,
+			}
+		}
+		if isIngress {
+			for i, r := range banp.Spec.Ingress {
+				rules = append(rules, newRule(i, r.Name, r.Action, BuildIngressPeerMatcherAdmin(r.From, r.Ports)))
+			}
+		} else {
+			for i, r := range banp.Spec.Egress {
+				rules = append(rules, newRule(i, r.Name, r.Action, BuildEgressPeerMatcherAdmin(banpEgressPeersToANP(r.To), r.Ports)))
+			}
+		}
+	}
+
+	return rules
+}
+
+func lintShadowedAdminRules(anps []*v1alpha1.AdminNetworkPolicy, banp *v1alpha1.BaselineAdminNetworkPolicy) []*LintFinding {
+	var findings []*LintFinding
+	for _, isIngress := range []bool{true, false} {
+		direction := "egress"
+		if isIngress {
+			direction = "ingress"
+		}
+
+		// in order of precedence, so that a rule is reported as shadowed by the first rules to match its traffic
+		rules := buildAdminRules(anps, banp, isIngress)
+		sort.SliceStable(rules, func(i, j int) bool {
+			a, b := rules[i], rules[j]
+			if a.Kind != b.Kind {
+				return a.Kind == AdminNetworkPolicy
+			}
+			if a.Priority != b.Priority {
+				return a.Priority < b.Priority
+			}
+			if a.Policy != b.Policy {
+				return a.Policy < b.Policy
+			}
+			return a.Index < b.Index
+		})
+		for _, rule := range rules {
+			shadowedBy := shadowingRules(rule, rules)
+			if len(shadowedBy) == 0 {
+				continue
+			}
+
+			sameVerdict := true
+			var names []string
+			for _, s := range shadowedBy {
+				sameVerdict = sameVerdict && s.Verdict == rule.Verdict
+				names = append(names, s.String())
+			}
+			if sameVerdict {
+				findings = append(findings, &LintFinding{
+					Severity: LintInfo,
+					Policy:   rule.Policy,
+					Rule:     rule.Name,
+					Message:  fmt.Sprintf("%s rule is redundant: all of its traffic is already matched with the same action (%s) by %s", direction, rule.Verdict, strings.Join(names, ", ")),
+				})
+			} else {
+				findings = append(findings, &LintFinding{
+					Severity: LintWarning,
+					Policy:   rule.Policy,
+					Rule:     rule.Name,
+					Message:  fmt.Sprintf("%s rule is shadowed: all of its traffic is matched first by %s", direction, strings.Join(names, ", ")),
+				})
+			}
+		}
+	}
+	return findings
+}
+
+// shadowingRules returns the rules which take precedence over rule for all of its traffic,
+// or nil if some of its traffic isn't matched by a higher precedence rule.
+func shadowingRules(rule *adminRule, rules []*adminRule) []*adminRule {
+	var shadowedBy []*adminRule
+	seen := map[*adminRule]bool{}
+	for _, peer := range rule.Peers {
+		found := false
+		for _, other := range rules {
+			if !other.precedes(rule) || !isSubjectCovered(other.Subject, rule.Subject) {
+				continue
+			}
+			for _, otherPeer := range other.Peers {
+				if isPeerCovered(otherPeer, peer) {
+					found = true
+					if !seen[other] {
+						seen[other] = true
+						shadowedBy = append(shadowedBy, other)
+					}
+					break
+				}
+			}
+			if found {
+				break
+			}
+		}
+		if !found {
+			return nil
+		}
+	}
+	return shadowedBy
+}
+
+// adminSubjectSelectors returns the namespace and pod selectors of an ANP/BANP subject
+func adminSubjectSelectors(subject *v1alpha1.AdminNetworkPolicySubject) (metav1.LabelSelector, metav1.LabelSelector) {
+	if subject.Namespaces != nil {
+		return *subject.Namespaces, metav1.LabelSelector{}
+	}
+	if subject.Pods != nil {
+		return subject.Pods.NamespaceSelector, subject.Pods.PodSelector
+	}
+	return metav1.LabelSelector{}, metav1.LabelSelector{}
+}
+
+// isSelectorCovered returns true if outer certainly selects everything that inner selects
+func isSelectorCovered(outer metav1.LabelSelector, inner metav1.LabelSelector) bool {
+	return kube.IsLabelSelectorEmpty(outer) || kube.SerializeLabelSelector(outer) == kube.SerializeLabelSelector(inner)
+}
+
+func isSubjectCovered(outer *v1alpha1.AdminNetworkPolicySubject, inner *v1alpha1.AdminNetworkPolicySubject) bool {
+	outerNamespaces, outerPods := adminSubjectSelectors(outer)
+	innerNamespaces, innerPods := adminSubjectSelectors(inner)
+	return isSelectorCovered(outerNamespaces, innerNamespaces) && isSelectorCovered(outerPods, innerPods)
+}
+
+// isPeerCovered returns true if outer certainly matches all traffic that inner matches
+func isPeerCovered(outer PeerMatcher, inner PeerMatcher) bool {
+	switch o := outer.(type) {
+	case *PodPeerMatcher:
+		i, ok := inner.(*PodPeerMatcher)
+		if !ok {
+			return false
+		}
+		_, allNamespaces := o.Namespace.(*AllNamespaceMatcher)
+		_, allPods := o.Pod.(*AllPodMatcher)
+		return (allNamespaces || o.Namespace.PrimaryKey() == i.Namespace.PrimaryKey()) &&
+			(allPods || o.Pod.PrimaryKey() == i.Pod.PrimaryKey()) &&
+			isPortCovered(o.Port, i.Port)
+	case *NodePeerMatcher:
+		i, ok := inner.(*NodePeerMatcher)
+		return ok && isSelectorCovered(o.Selector, i.Selector) && isPortCovered(o.Port, i.Port)
+	case *NetworksPeerMatcher:
+		i, ok := inner.(*NetworksPeerMatcher)
+		if !ok || !isPortCovered(o.Port, i.Port) {
+			return false
+		}
+		for _, innerNetwork := range i.Networks {
+			covered := false
+			for _, outerNetwork := range o.Networks {
+				if isCIDRCovered(outerNetwork, innerNetwork) {
+					covered = true
+					break
+				}
+			}
+			if !covered {
+				return false
+			}
+		}
+		return true
+	case *DomainNamePeerMatcher:
+		i, ok := inner.(*DomainNamePeerMatcher)
+		if !ok || !isPortCovered(o.Port, i.Port) {
+			return false
+		}
+		for _, innerName := range i.DomainNames {
+			covered := false
+			for _, outerName := range o.DomainNames {
+				if normalizeDomainName(outerName) == normalizeDomainName(innerName) || IsDomainNameMatch(outerName, innerName) {
+					covered = true
+					break
+				}
+			}
+			if !covered {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+func isPortCovered(outer PortMatcher, inner PortMatcher) bool {
+	isEmpty, _ := SubtractPortMatchers(inner, outer)
+	return isEmpty
+}
+
+func isCIDRCovered(outer string, inner string) bool {
+	_, outerNet, err := net.ParseCIDR(outer)
+	if err != nil {
+		return false
+	}
+	_, innerNet, err := net.ParseCIDR(inner)
+	if err != nil {
+		return false
+	}
+	outerOnes, outerBits := outerNet.Mask.Size()
+	innerOnes, innerBits := innerNet.Mask.Size()
+	return outerBits == innerBits && outerOnes <= innerOnes && outerNet.Contains(innerNet.IP)
+}
+
+func lintSamePriorityANPs(anps []*v1alpha1.AdminNetworkPolicy) []*LintFinding {
+	var findings []*LintFinding
+	for i, a := range anps {
+		for _, b := range anps[i+1:] {
+			if a.Spec.Priority != b.Spec.Priority {
+				continue
+			}
+			aNamespaces, aPods := adminSubjectSelectors(&a.Spec.Subject)
+			bNamespaces, bPods := adminSubjectSelectors(&b.Spec.Subject)
+			namespacesDisjoint, err := kube.AreLabelSelectorsDisjoint(aNamespaces, bNamespaces)
+			if err != nil {
+				findings = append(findings, &LintFinding{Severity: LintError, Policy: netPolID(b), Message: fmt.Sprintf("unable to compare subject with %s: %s", netPolID(a), err.Error())})
+				continue
+			}
+			podsDisjoint, err := kube.AreLabelSelectorsDisjoint(aPods, bPods)
+			if err != nil {
+				findings = append(findings, &LintFinding{Severity: LintError, Policy: netPolID(b), Message: fmt.Sprintf("unable to compare subject with %s: %s", netPolID(a), err.Error())})
+				continue
+			}
+			if namespacesDisjoint || podsDisjoint {
+				continue
+			}
+			findings = append(findings, &LintFinding{
+				Severity: LintError,
+				Policy:   netPolID(b),
+				Message:  fmt.Sprintf("has the same priority (%d) as %s and their subjects overlap: their precedence is undefined", b.Spec.Priority, netPolID(a)),
+			})
+		}
+	}
+	return findings
+}
+
+// lintNoOpNetworkPolicies builds each policy once.  Since removing a policy only changes the targets it contributes
+// to, it has no effect if each of those targets has the same simplified peers with or without it.  Policies without
+// effect are left out when checking the others, so that of identical policies, only the later ones are reported.
+func lintNoOpNetworkPolicies(netpols []*networkingv1.NetworkPolicy) []*LintFinding {
+	type contribution struct {
+		index  int
+		target *Target
+	}
+	// direction and target primary key -> the policies contributing to the target
+	contributions := map[string][]*contribution{}
+	targetKeys := make([][]string, len(netpols))
+	for i, netpol := range netpols {
+		ingress, egress := BuildTarget(netpol)
+		for direction, target := range map[string]*Target{"ingress": ingress, "egress": egress} {
+			if target == nil {
+				continue
+			}
+			key := direction + "/" + target.GetPrimaryKey()
+			contributions[key] = append(contributions[key], &contribution{index: i, target: target})
+			targetKeys[i] = append(targetKeys[i], key)
+		}
+	}
+
+	noEffect := map[int]bool{}
+	simplifiedPeers := func(key string, without int) (string, bool) {
+		var peers []PeerMatcher
+		found := false
+		for _, c := range contributions[key] {
+			if c.index != without && !noEffect[c.index] {
+				peers = append(peers, c.target.Peers...)
+				found = true
+			}
+		}
+		return json.MustMarshalToString(Simplify(peers)), found
+	}
+
+	var findings []*LintFinding
+	for i := len(netpols) - 1; i >= 0; i-- {
+		hasEffect := false
+		for _, key := range targetKeys[i] {
+			with, _ := simplifiedPeers(key, -1)
+			without, hasOthers := simplifiedPeers(key, i)
+			if !hasOthers || with != without {
+				hasEffect = true
+				break
+			}
+		}
+		if hasEffect {
+			continue
+		}
+		noEffect[i] = true
+		findings = append(findings, &LintFinding{
+			Severity: LintWarning,
+			Policy:   netPolID(netpols[i]),
+			Message:  "has no effect: after simplification, the other NetworkPolicies already select the same pods and allow the same traffic",
+		})
+	}
+	return findings
+}
+
+func lintUnreachableBANPRules(netpols []*networkingv1.NetworkPolicy, banp *v1alpha1.BaselineAdminNetworkPolicy) []*LintFinding {
+	if banp == nil {
+		return nil
+	}
+	nsSelector, podSelector := adminSubjectSelectors(&banp.Spec.Subject)
+	namespaces, ok := exactNamespaces(nsSelector)
+	if !ok || len(namespaces) == 0 {
+		return nil
+	}
+
+	v1Policy := BuildNetworkPolicies(false, netpols)
+	isCoveredByNetworkPolicies := func(targets map[string]*Target) bool {
+		for _, ns := range namespaces {
+			covered := false
+			for _, target := range targets {
+				subject, ok := target.SubjectMatcher.(*SubjectV1)
+				if ok && subject.namespace == ns && isSelectorCovered(subject.podSelector, podSelector) {
+					covered = true
+					break
+				}
+			}
+			if !covered {
+				return false
+			}
+		}
+		return true
+	}
+
+	var findings []*LintFinding
+	newFinding := func(direction string, ruleName string) *LintFinding {
+		return &LintFinding{
+			Severity: LintWarning,
+			Policy:   netPolID(banp),
+			Rule:     ruleName,
+			Message:  fmt.Sprintf("%s rule is never reached: NetworkPolicies select all pods of the subject (namespaces %s)", direction, strings.Join(namespaces, ", ")),
+		}
+	}
+	if isCoveredByNetworkPolicies(v1Policy.Ingress) {
+		for _, r := range banp.Spec.Ingress {
+			findings = append(findings, newFinding("ingress", r.Name))
+		}
+	}
+	if isCoveredByNetworkPolicies(v1Policy.Egress) {
+		for _, r := range banp.Spec.Egress {
+			findings = append(findings, newFinding("egress", r.Name))
+		}
+	}
+	return findings
+}
+
+// exactNamespaces returns the names of the namespaces a selector is restricted to through the
+// 'kubernetes.io/metadata.name' label.  The boolean is false if the selector doesn't restrict names.
+func exactNamespaces(selector metav1.LabelSelector) ([]string, bool) {
+	var names []string
+	restricted := false
+	intersect := func(values []string) {
+		if !restricted {
+			names = append([]string{}, values...)
+			restricted = true
+			return
+		}
+		var kept []string
+		for _, name := range names {
+			for _, v := range values {
+				if name == v {
+					kept = append(kept, name)
+					break
+				}
+			}
+		}
+		names = kept
+	}
+
+	if name, ok := selector.MatchLabels[v1.LabelMetadataName]; ok {
+		intersect([]string{name})
+	}
+	for _, exp := range selector.MatchExpressions {
+		if exp.Key == v1.LabelMetadataName && exp.Operator == metav1.LabelSelectorOpIn {
+			intersect(exp.Values)
+		}
+	}
+	sort.Strings(names)
+	return names, restricted
+}
@@ -0,0 +1,259 @@
+package matcher
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	networkingv1 "k8s.io/api/networking/v1"
+	"sigs.k8s.io/network-policy-api/apis/v1alpha1"
+	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/utils"
+)
+
+func RunLintTests() {
+	parseANP := func(s string) *v1alpha1.AdminNetworkPolicy {
+		anp, err := utils.ParseYaml[v1alpha1.AdminNetworkPolicy]([]byte(s))
+		utils.DoOrDie(err)
+		return anp
+	}
+	parseNetpol := func(s string) *networkingv1.NetworkPolicy {
+		netpol, err := utils.ParseYaml[networkingv1.NetworkPolicy]([]byte(s))
+		utils.DoOrDie(err)
+		return netpol
+	}
+	// findings are compared without their messages
+	withoutMessages := func(findings []*LintFinding) []*LintFinding {
+		var result []*LintFinding
+		for _, f := range findings {
+			result = append(result, &LintFinding{Severity: f.Severity, Policy: f.Policy, Rule: f.Rule})
+		}
+		return result
+	}
+
+	allowFromX := parseANP(`
+metadata:
+  name: allow-from-x
+spec:
+  priority: 10
+  subject:
+    namespaces: {}
+  ingress:
+  - name: allow-x
+    action: Allow
+    from:
+    - namespaces:
+        matchLabels:
+          ns: x
+  - name: deny-all
+    action: Deny
+    from:
+    - namespaces: {}`)
+	denyToDemo := parseANP(`
+metadata:
+  name: deny-to-demo
+spec:
+  priority: 20
+  subject:
+    namespaces:
+      matchLabels:
+        kubernetes.io/metadata.name: demo
+  ingress:
+  - name: deny-x
+    action: Deny
+    from:
+    - pods:
+        namespaceSelector:
+          matchLabels:
+            ns: x
+        podSelector: {}
+  - name: deny-80
+    action: Deny
+    from:
+    - namespaces: {}
+    ports:
+    - portNumber:
+        port: 80
+        protocol: TCP`)
+	allowToDemoA := parseANP(`
+metadata:
+  name: allow-to-demo-a
+spec:
+  priority: 20
+  subject:
+    pods:
+      namespaceSelector: {}
+      podSelector:
+        matchLabels:
+          app: a
+  egress:
+  - name: allow-all
+    action: Allow
+    to:
+    - networks:
+      - 0.0.0.0/0`)
+	allowToOtherB := parseANP(`
+metadata:
+  name: allow-to-other-b
+spec:
+  priority: 20
+  subject:
+    pods:
+      namespaceSelector: {}
+      podSelector:
+        matchLabels:
+          app: b
+  egress:
+  - name: allow-networks
+    action: Allow
+    to:
+    - networks:
+      - 10.0.0.0/8
+  - name: deny-subnet
+    action: Deny
+    to:
+    - networks:
+      - 10.1.0.0/16`)
+
+	Describe("Lint", func() {
+		It("should find shadowed and redundant ANP rules", func() {
+			Expect(withoutMessages(Lint(nil, []*v1alpha1.AdminNetworkPolicy{allowFromX, denyToDemo}, nil))).To(Equal([]*LintFinding{
+				{Severity: LintWarning, Policy: "[ANP] default/deny-to-demo", Rule: "deny-x"},
+				{Severity: LintInfo, Policy: "[ANP] default/deny-to-demo", Rule: "deny-80"},
+			}))
+		})
+
+		It("should find rules shadowed by earlier rules of the same ANP", func() {
+			Expect(withoutMessages(Lint(nil, []*v1alpha1.AdminNetworkPolicy{allowToOtherB}, nil))).To(Equal([]*LintFinding{
+				{Severity: LintWarning, Policy: "[ANP] default/allow-to-other-b", Rule: "deny-subnet"},
+			}))
+		})
+
+		It("should find ANPs with the same priority and overlapping subjects", func() {
+			findings := Lint(nil, []*v1alpha1.AdminNetworkPolicy{denyToDemo, allowToDemoA, allowToOtherB}, nil)
+			Expect(withoutMessages(findings)).To(Equal([]*LintFinding{
+				{Severity: LintError, Policy: "[ANP] default/allow-to-demo-a"},
+				{Severity: LintError, Policy: "[ANP] default/allow-to-other-b"},
+				{Severity: LintWarning, Policy: "[ANP] default/allow-to-other-b", Rule: "deny-subnet"},
+			}))
+			Expect(findings[0].Message).To(ContainSubstring("[ANP] default/deny-to-demo"))
+			Expect(findings[1].Message).To(ContainSubstring("[ANP] default/deny-to-demo"))
+		})
+
+		It("should find NPv1 policies without effect", func() {
+			allowAll := parseNetpol(`
+metadata:
+  name: allow-all
+  namespace: demo
+spec:
+  podSelector: {}
+  ingress:
+  - {}
+  policyTypes:
+  - Ingress`)
+			allow80 := parseNetpol(`
+metadata:
+  name: allow-80
+  namespace: demo
+spec:
+  podSelector: {}
+  ingress:
+  - ports:
+    - port: 80
+  policyTypes:
+  - Ingress`)
+			Expect(withoutMessages(Lint([]*networkingv1.NetworkPolicy{allowAll, allow80}, nil, nil))).To(Equal([]*LintFinding{
+				{Severity: LintWarning, Policy: "[NPv1] demo/allow-80"},
+			}))
+			Expect(Lint([]*networkingv1.NetworkPolicy{allow80}, nil, nil)).To(BeEmpty())
+
+			allow80Again := allow80.DeepCopy()
+			allow80Again.Name = "allow-80-again"
+			Expect(withoutMessages(Lint([]*networkingv1.NetworkPolicy{allow80, allow80Again}, nil, nil))).To(Equal([]*LintFinding{
+				{Severity: LintWarning, Policy: "[NPv1] demo/allow-80-again"},
+			}))
+		})
+
+		It("should report invalid policies instead of panicking", func() {
+			noTypes := parseNetpol(`
+metadata:
+  name: no-types
+  namespace: demo
+spec:
+  podSelector: {}`)
+			twoPeerTypes := parseANP(`
+metadata:
+  name: two-peer-types
+spec:
+  priority: 30
+  subject:
+    namespaces: {}
+  egress:
+  - name: deny-all
+    action: Deny
+    to:
+    - namespaces: {}
+      networks: [10.0.0.0/8]`)
+			invalidSubject := parseANP(`
+metadata:
+  name: invalid-subject
+spec:
+  priority: 40
+  subject:
+    namespaces:
+      matchExpressions:
+      - key: ns
+        operator: Equals
+        values: [x]
+  ingress:
+  - name: deny-all
+    action: Deny
+    from:
+    - namespaces: {}`)
+
+			var findings []*LintFinding
+			Expect(func() {
+				findings = Lint([]*networkingv1.NetworkPolicy{noTypes}, []*v1alpha1.AdminNetworkPolicy{allowFromX, denyToDemo, twoPeerTypes, invalidSubject}, nil)
+			}).ToNot(Panic())
+			Expect(withoutMessages(findings)).To(Equal([]*LintFinding{
+				{Severity: LintError, Policy: "[ANP] default/invalid-subject"},
+				{Severity: LintError, Policy: "[ANP] default/two-peer-types"},
+				{Severity: LintError, Policy: "[NPv1] demo/no-types"},
+				{Severity: LintWarning, Policy: "[ANP] default/deny-to-demo", Rule: "deny-x"},
+				{Severity: LintInfo, Policy: "[ANP] default/deny-to-demo", Rule: "deny-80"},
+			}))
+			Expect(findings[2].Message).To(ContainSubstring("need at least 1 type"))
+		})
+
+		It("should find BANP rules which are never reached", func() {
+			banp, err := utils.ParseYaml[v1alpha1.BaselineAdminNetworkPolicy]([]byte(`
+metadata:
+  name: default
+spec:
+  subject:
+    namespaces:
+      matchExpressions:
+      - key: kubernetes.io/metadata.name
+        operator: In
+        values: [demo, other]
+  ingress:
+  - name: baseline-deny
+    action: Deny
+    from:
+    - namespaces: {}`))
+			Expect(err).To(Succeed())
+			denyAll := func(ns string) *networkingv1.NetworkPolicy {
+				return parseNetpol(`
+metadata:
+  name: deny-all
+  namespace: ` + ns + `
+spec:
+  podSelector: {}
+  policyTypes:
+  - Ingress`)
+			}
+
+			Expect(Lint([]*networkingv1.NetworkPolicy{denyAll("demo")}, nil, banp)).To(BeEmpty())
+			Expect(withoutMessages(Lint([]*networkingv1.NetworkPolicy{denyAll("demo"), denyAll("other")}, nil, banp))).To(Equal([]*LintFinding{
+				{Severity: LintWarning, Policy: "[BANP] default/default", Rule: "baseline-deny"},
+			}))
+		})
+	})
+}
@@ -0,0 +1,68 @@
+package matcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/mattfenwick/collections/pkg/slice"
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+)
+
+// NetworksPeerMatcher matches traffic to a set of CIDR blocks.
+// It models the Networks peer of ANP and BANP egress rules.
+// Unlike IPPeerMatcher, it has no excepts, and it matches in-cluster peers (e.g. Pods) by IP as well as external ones.
+type NetworksPeerMatcher struct {
+	Networks []string
+	Port     PortMatcher
+}
+
+// NewNetworksPeerMatcher validates the CIDRs, so that matching never has to deal with invalid ones
+func NewNetworksPeerMatcher(networks []string, port PortMatcher) (*NetworksPeerMatcher, error) {
+	for _, cidr := range networks {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			return nil, errors.Wrapf(err, "invalid Networks CIDR '%s'", cidr)
+		}
+	}
+	return &NetworksPeerMatcher{Networks: networks, Port: port}, nil
+}
+
+// PrimaryKey returns a content-based, deterministic key based on the CIDRs.
+func (n *NetworksPeerMatcher) PrimaryKey() string {
+	return fmt.Sprintf(`{"type": "networks", "cidrs": "%s"}`, strings.Join(slice.Sort(n.Networks), ", "))
+}
+
+func (n *NetworksPeerMatcher) Matches(_, peer *TrafficPeer, portInt int, portName string, protocol v1.Protocol) bool {
+	// a peer without an IP (e.g. a Pod described only by labels) can't be selected by CIDR
+	if peer.IP == "" {
+		return false
+	}
+
+	return n.MatchesIP(peer.IP) && n.Port.Matches(portInt, portName, protocol)
+}
+
+// MatchesIP returns true if the IP is in any of the CIDRs.  Something which isn't an IP is in none of them.
+func (n *NetworksPeerMatcher) MatchesIP(ip string) bool {
+	trafficIP := net.ParseIP(ip)
+	if trafficIP == nil {
+		return false
+	}
+	for _, cidr := range n.Networks {
+		// invalid CIDRs are rejected by NewNetworksPeerMatcher, and otherwise match nothing
+		_, cidrNet, err := net.ParseCIDR(cidr)
+		if err == nil && cidrNet.Contains(trafficIP) {
+			return true
+		}
+	}
+	return false
+}
+
+func (n *NetworksPeerMatcher) MarshalJSON() (b []byte, e error) {
+	return json.Marshal(map[string]interface{}{
+		"Type":     "networks",
+		"Networks": n.Networks,
+		"Port":     n.Port,
+	})
+}
@@ -0,0 +1,36 @@
+package matcher
+
+import (
+	"encoding/json"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/kube"
+)
+
+// NodePeerMatcher matches traffic to cluster Nodes by node labels.
+// It is only relevant to ANP and BANP egress rules (see NPEP-126).
+// Pods are never matched: only peers with NodeLabels set are considered Nodes.
+type NodePeerMatcher struct {
+	Selector metav1.LabelSelector
+	Port     PortMatcher
+}
+
+func (n *NodePeerMatcher) PrimaryKey() string {
+	return fmt.Sprintf(`{"type": "nodes", "selector": "%s"}`, kube.SerializeLabelSelector(n.Selector))
+}
+
+func (n *NodePeerMatcher) Matches(_, peer *TrafficPeer, portInt int, portName string, protocol v1.Protocol) bool {
+	return peer.IsNode() &&
+		kube.IsLabelsMatchLabelSelector(peer.NodeLabels, n.Selector) &&
+		n.Port.Matches(portInt, portName, protocol)
+}
+
+func (n *NodePeerMatcher) MarshalJSON() (b []byte, e error) {
+	return json.Marshal(map[string]interface{}{
+		"Type":     "matching nodes by label",
+		"Selector": n.Selector,
+		"Port":     n.Port,
+	})
+}
@@ -23,8 +23,11 @@ These are the original PeerMatcher implementations made for v1 NetPol:
 All PeerMatcher implementations (except AllPeersMatcher and NoMatcher) use a PortMatcher.
 If the traffic doesn't match the port matcher, then Matches() will be false.
 
-Now we also have PeerMatcherAdmin, a wrapper for PodPeerMatcher to model ANP and BANP.
-We also made NamespaceMatcher objects for SameLabels and NotSameLabels.
+Now we also have PeerMatcherAdmin, a wrapper to model ANP and BANP rules around:
+- PodPeerMatcher
+- NodePeerMatcher
+- NetworksPeerMatcher
+- DomainNamePeerMatcher
 */
 type PeerMatcher interface {
 	Matches(subject, peer *TrafficPeer, portInt int, portName string, protocol v1.Protocol) bool
@@ -37,6 +40,12 @@ func (p *NoMatcher) Matches(subject, peer *TrafficPeer, portInt int, portName st
 	return false
 }
 
+func (p *NoMatcher) MarshalJSON() (b []byte, e error) {
+	return json.Marshal(map[string]interface{}{
+		"Type": "no peers",
+	})
+}
+
 // AllPeerMatcher matches all pod to pod traffic.
 type AllPeersMatcher struct{}
 
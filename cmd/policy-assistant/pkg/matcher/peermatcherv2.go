@@ -1,6 +1,7 @@
 package matcher
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 
@@ -9,21 +10,24 @@ import (
 )
 
 // PeerMatcherAdmin models an ANP or BANP rule, incorporating an ANP/BANP action and an ANP priority.
-// NOTE: we only use the PodPeerMatcher out of all the PeerMatcher imlementations.
-// This is because ANP and BANP only deal with Pod to Pod traffic, and do not deal with external IPs.
+// It wraps the PeerMatcher for a single peer of the rule:
+// - PodPeerMatcher for Namespaces and Pods peers
+// - NodePeerMatcher for Nodes peers (egress only)
+// - NetworksPeerMatcher for Networks peers (egress only)
+// - DomainNamePeerMatcher for DomainNames peers (ANP egress only)
 type PeerMatcherAdmin struct {
-	*PodPeerMatcher
+	PeerMatcher
 	PolicyName      string
 	RuleName        string
 	effectFromMatch Effect
 }
 
 // NewPeerMatcherANP creates a PeerMatcherAdmin for an ANP rule
-func NewPeerMatcherANP(peer *PodPeerMatcher, v Verdict, priority int, policyName, ruleName string) *PeerMatcherAdmin {
+func NewPeerMatcherANP(peer PeerMatcher, v Verdict, priority int, policyName, ruleName string) *PeerMatcherAdmin {
 	return &PeerMatcherAdmin{
-		PodPeerMatcher: peer,
-		PolicyName:     policyName,
-		RuleName:       ruleName,
+		PeerMatcher: peer,
+		PolicyName:  policyName,
+		RuleName:    ruleName,
 		effectFromMatch: Effect{
 			RuleName:   ruleName,
 			PolicyKind: AdminNetworkPolicy,
@@ -34,11 +38,11 @@ func NewPeerMatcherANP(peer *PodPeerMatcher, v Verdict, priority int, policyName
 }
 
 // NewPeerMatcherBANP creates a new PeerMatcherAdmin for a BANP rule
-func NewPeerMatcherBANP(peer *PodPeerMatcher, v Verdict, policyName, ruleName string) *PeerMatcherAdmin {
+func NewPeerMatcherBANP(peer PeerMatcher, v Verdict, policyName, ruleName string) *PeerMatcherAdmin {
 	return &PeerMatcherAdmin{
-		PodPeerMatcher: peer,
-		PolicyName:     policyName,
-		RuleName:       ruleName,
+		PeerMatcher: peer,
+		PolicyName:  policyName,
+		RuleName:    ruleName,
 		effectFromMatch: Effect{
 			RuleName:   ruleName,
 			PolicyKind: BaselineAdminNetworkPolicy,
@@ -47,6 +51,16 @@ func NewPeerMatcherBANP(peer *PodPeerMatcher, v Verdict, policyName, ruleName st
 	}
 }
 
+func (p *PeerMatcherAdmin) MarshalJSON() (b []byte, e error) {
+	return json.Marshal(map[string]interface{}{
+		"Type":       p.effectFromMatch.PolicyKind,
+		"PolicyName": p.PolicyName,
+		"RuleName":   p.RuleName,
+		"Effect":     p.effectFromMatch,
+		"Peer":       p.PeerMatcher,
+	})
+}
+
 // Effect models the effect of one or more v1/v2 NetPol rules on a peer
 type Effect struct {
 	RuleName string
@@ -1,11 +1,13 @@
 package matcher
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 
 	"github.com/mattfenwick/collections/pkg/slice"
 	"github.com/olekukonko/tablewriter"
+	"github.com/sirupsen/logrus"
 	"golang.org/x/exp/maps"
 )
 
@@ -22,10 +24,20 @@ const (
 type Policy struct {
 	Ingress map[string]*Target
 	Egress  map[string]*Target
+	// Resolver is optional: if set, it is used to resolve between IPs and domain names of peers for DomainNames rules
+	Resolver Resolver
+
+	ingressIndex *targetIndex
+	egressIndex  *targetIndex
 }
 
 func NewPolicy() *Policy {
-	return &Policy{Ingress: map[string]*Target{}, Egress: map[string]*Target{}}
+	return &Policy{
+		Ingress:      map[string]*Target{},
+		Egress:       map[string]*Target{},
+		ingressIndex: newTargetIndex(),
+		egressIndex:  newTargetIndex(),
+	}
 }
 
 func NewPolicyWithTargets(ingress []*Target, egress []*Target) *Policy {
@@ -35,6 +47,14 @@ func NewPolicyWithTargets(ingress []*Target, egress []*Target) *Policy {
 	return np
 }
 
+func (p *Policy) MarshalJSON() (b []byte, e error) {
+	ingress, egress := p.SortedTargets()
+	return json.Marshal(map[string]interface{}{
+		"Ingress": ingress,
+		"Egress":  egress,
+	})
+}
+
 func (p *Policy) SortedTargets() ([]*Target, []*Target) {
 	key := func(t *Target) string { return t.GetPrimaryKey() }
 	ingress := slice.SortOn(key, maps.Values(p.Ingress))
@@ -55,10 +75,11 @@ func (p *Policy) AddTarget(isIngress bool, target *Target) {
 
 	pk := target.GetPrimaryKey()
 	var dict map[string]*Target
+	var index *targetIndex
 	if isIngress {
-		dict = p.Ingress
+		dict, index = p.Ingress, p.ingressIndex
 	} else {
-		dict = p.Egress
+		dict, index = p.Egress, p.egressIndex
 	}
 	if prev, ok := dict[pk]; ok {
 		combined := prev.Combine(target)
@@ -66,22 +87,58 @@ func (p *Policy) AddTarget(isIngress bool, target *Target) {
 	} else {
 		dict[pk] = target
 	}
+	index.set(pk, dict[pk])
+}
+
+// SetTarget replaces the target with the primary key pk -- rather than combining with it, as AddTarget does --
+// or removes it if target is nil
+func (p *Policy) SetTarget(isIngress bool, pk string, target *Target) {
+	dict := p.Egress
+	if isIngress {
+		dict = p.Ingress
+	}
+	if target == nil {
+		delete(dict, pk)
+		p.targetIndex(isIngress).remove(pk)
+		return
+	}
+	dict[pk] = target
+	p.targetIndex(isIngress).set(pk, target)
 }
 
+// TargetsApplyingToPod returns the targets whose subjects match the pod, in the order they were added
 func (p *Policy) TargetsApplyingToPod(isIngress bool, subject *InternalPeer) []*Target {
 	var targets []*Target
-	var dict map[string]*Target
+	for _, entry := range p.targetIndex(isIngress).matching(subject) {
+		targets = append(targets, entry.target)
+	}
+	return targets
+}
+
+func (p *Policy) targetIndex(isIngress bool) *targetIndex {
 	if isIngress {
-		dict = p.Ingress
-	} else {
-		dict = p.Egress
+		return p.ingressIndex
 	}
-	for _, target := range dict {
-		if target.Matches(subject) {
-			targets = append(targets, target)
+	return p.egressIndex
+}
+
+// MatchesPeersByIP returns true if any rule selects peers by IP, through an IPBlock or Networks peer.
+// Otherwise, verdicts between pods only depend on their namespaces and labels.
+func (p *Policy) MatchesPeersByIP() bool {
+	for _, dict := range []map[string]*Target{p.Ingress, p.Egress} {
+		for _, target := range dict {
+			for _, peer := range target.Peers {
+				if admin, ok := peer.(*PeerMatcherAdmin); ok {
+					peer = admin.PeerMatcher
+				}
+				switch peer.(type) {
+				case *IPPeerMatcher, *NetworksPeerMatcher:
+					return true
+				}
+			}
 		}
 	}
-	return targets
+	return false
 }
 
 // DirectionResult contains information about each rule of each v1/v2 NetPol on traffic in a single direction (ingress or egress)
@@ -157,6 +214,23 @@ func (d DirectionResult) Flow() string {
 	return strings.Join(flows, " -> ")
 }
 
+// DecidingPolicyKind returns the PolicyKind whose rules decided the verdict, or false if no rule did and
+// the traffic was allowed by default: no policies target it, or ANPs passed or didn't match and then BANPs
+// didn't match.
+func (d DirectionResult) DecidingPolicyKind() (PolicyKind, bool) {
+	anp, npv1, banp := d.Resolve()
+	if anp != nil && (anp.Verdict == Allow || anp.Verdict == Deny) {
+		return AdminNetworkPolicy, true
+	}
+	if npv1 != nil {
+		return NetworkPolicyV1, true
+	}
+	if banp != nil && banp.Verdict != None {
+		return BaselineAdminNetworkPolicy, true
+	}
+	return "", false
+}
+
 // Resolve returns the final Effect on traffic for ANP, v1 NetPol, and BANP respectively.
 // A nil Effect indicates that there are none of that PolicyKind
 // or e.g. ANP allowed traffic before reaching v1 NetPol and BANP.
@@ -242,22 +316,51 @@ type AllowedResult struct {
 func (ar *AllowedResult) Table() string {
 	tableString := &strings.Builder{}
 	table := tablewriter.NewWriter(tableString)
+	table.SetAutoWrapText(false)
 	table.SetRowLine(true)
-	table.SetAutoMergeCells(true)
-	table.SetHeader([]string{"Type", "Action", "Target"})
-	// NOTE: broken. This function only used by 1) "policy-assistant analyze explain --mode=query-target" and 2) a trace-level log in "policy-assistant generate"
-	// Broken since we're changing the DirectionResult struct to use Effects instead of Allowing/DenyingTargets
-	// addTargetsToTable(table, "Ingress", "Allow", ar.Ingress.AllowingTargets)
-	// addTargetsToTable(table, "Ingress", "Deny", ar.Ingress.DenyingTargets)
-	table.Append([]string{"", "", ""})
-	// addTargetsToTable(table, "Egress", "Allow", ar.Egress.AllowingTargets)
-	// addTargetsToTable(table, "Egress", "Deny", ar.Egress.DenyingTargets)
+	table.SetHeader([]string{"Type", "Effects", "Flow"})
+	table.Append([]string{"Ingress", ar.Ingress.effectsString(), ar.Ingress.Flow()})
+	table.Append([]string{"Egress", ar.Egress.effectsString(), ar.Egress.Flow()})
 	table.SetFooter([]string{"Is allowed?", fmt.Sprintf("%t", ar.IsAllowed()), ""})
 
 	table.Render()
 	return tableString.String()
 }
 
+func (d DirectionResult) effectsString() string {
+	if len(d) == 0 {
+		return "none"
+	}
+	lines := make([]string, len(d))
+	for i, e := range d {
+		if e.PolicyKind == AdminNetworkPolicy {
+			lines[i] = fmt.Sprintf("[%s] pri=%d (%s): %s", e.PolicyKind, e.Priority, e.RuleName, e.Verdict)
+		} else {
+			lines[i] = fmt.Sprintf("[%s] (%s): %s", e.PolicyKind, e.RuleName, e.Verdict)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (ar *AllowedResult) MarshalJSON() (b []byte, e error) {
+	direction := func(d DirectionResult) map[string]interface{} {
+		effects := d
+		if effects == nil {
+			effects = DirectionResult{}
+		}
+		return map[string]interface{}{
+			"Allowed": d.IsAllowed(),
+			"Flow":    d.Flow(),
+			"Effects": effects,
+		}
+	}
+	return json.Marshal(map[string]interface{}{
+		"Verdict": ar.Verdict(),
+		"Ingress": direction(ar.Ingress),
+		"Egress":  direction(ar.Egress),
+	})
+}
+
 func (ar *AllowedResult) IsAllowed() bool {
 	return ar.Ingress.IsAllowed() && ar.Egress.IsAllowed()
 }
@@ -297,7 +400,9 @@ func (p *Policy) IsIngressOrEgressAllowed(traffic *Traffic, isIngress bool) Dire
 		return nil
 	}
 
-	matchingTargets := p.TargetsApplyingToPod(isIngress, subject.Internal)
+	peer = p.resolvePeer(peer)
+
+	matchingTargets := p.targetIndex(isIngress).matching(subject.Internal)
 
 	// 2. No targets match => automatic allow
 	if len(matchingTargets) == 0 {
@@ -307,21 +412,9 @@ func (p *Policy) IsIngressOrEgressAllowed(traffic *Traffic, isIngress bool) Dire
 	// 3. Check if any matching targets allow this traffic
 	effects := make([]Effect, 0)
 	for _, target := range matchingTargets {
-		for _, m := range target.Peers {
-			// assume m is an NPv1 rule to start
-			names := make([]string, len(target.SourceRules))
-			for i, r := range target.SourceRules {
-				names[i] = string(r)
-			}
-			e := NewV1Effect(true, names)
-
-			// check if m is a PeerMatcherAdmin
-			matcherAdmin, ok := m.(*PeerMatcherAdmin)
-			if ok {
-				e = matcherAdmin.effectFromMatch
-			}
-
-			if !m.Matches(subject, peer, traffic.ResolvedPort, traffic.ResolvedPortName, traffic.Protocol) {
+		for _, targetPeer := range target.peers {
+			e := targetPeer.effect
+			if !targetPeer.matcher.Matches(subject, peer, traffic.ResolvedPort, traffic.ResolvedPortName, traffic.Protocol) {
 				e.Verdict = None
 			}
 
@@ -332,6 +425,32 @@ func (p *Policy) IsIngressOrEgressAllowed(traffic *Traffic, isIngress bool) Dire
 	return effects
 }
 
+// resolvePeer fills in the IP and domain names of an external peer through the Resolver.
+// The original peer is not modified.
+func (p *Policy) resolvePeer(peer *TrafficPeer) *TrafficPeer {
+	if p.Resolver == nil || !peer.IsExternal() {
+		return peer
+	}
+
+	resolved := *peer
+	if resolved.IP == "" && resolved.DomainName != "" {
+		ips, err := p.Resolver.LookupHost(resolved.DomainName)
+		if err != nil {
+			logrus.Warnf("unable to resolve domain name %s: %+v", resolved.DomainName, err)
+		} else if len(ips) > 0 {
+			resolved.IP = ips[0]
+		}
+	}
+	if resolved.IP != "" {
+		names, err := p.Resolver.LookupAddr(resolved.IP)
+		if err != nil {
+			logrus.Warnf("unable to resolve ip %s: %+v", resolved.IP, err)
+		}
+		resolved.ResolvedDomainNames = names
+	}
+	return &resolved
+}
+
 func (p *Policy) Simplify() {
 	for _, ingress := range p.Ingress {
 		ingress.Simplify()
@@ -339,4 +458,6 @@ func (p *Policy) Simplify() {
 	for _, egress := range p.Egress {
 		egress.Simplify()
 	}
+	p.ingressIndex.refresh()
+	p.egressIndex.refresh()
 }
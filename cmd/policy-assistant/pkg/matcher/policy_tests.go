@@ -1,10 +1,13 @@
 package matcher
 
 import (
+	"github.com/mattfenwick/collections/pkg/json"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	v1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/network-policy-api/apis/v1alpha1"
 	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/utils"
 )
 
@@ -216,4 +219,246 @@ spec:
 			}).IsAllowed()).To(BeTrue())
 		})
 	})
+
+	Describe("ANP egress to nodes", func() {
+		denyControlPlane := &Target{
+			SubjectMatcher: NewSubjectAdmin(&v1alpha1.AdminNetworkPolicySubject{Namespaces: &metav1.LabelSelector{}}),
+			SourceRules:    []NetPolID{"[ANP] default/deny-control-plane"},
+			Peers: []PeerMatcher{
+				NewPeerMatcherANP(&NodePeerMatcher{
+					Selector: metav1.LabelSelector{MatchLabels: map[string]string{"node-role.kubernetes.io/control-plane": ""}},
+					Port:     &AllPortMatcher{},
+				}, Deny, 10, "deny-control-plane", "deny-to-control-plane"),
+			},
+		}
+		policy := NewPolicyWithTargets(nil, []*Target{denyControlPlane})
+
+		source := &TrafficPeer{
+			Internal: &InternalPeer{
+				PodLabels:       map[string]string{"pod": "a"},
+				NamespaceLabels: map[string]string{"ns": "x"},
+				Namespace:       "x",
+			},
+			IP: "10.0.0.4",
+		}
+		trafficTo := func(destination *TrafficPeer) *Traffic {
+			return &Traffic{Source: source, Destination: destination, ResolvedPort: 6443, Protocol: v1.ProtocolTCP}
+		}
+
+		It("should deny egress to matching nodes", func() {
+			result := policy.IsTrafficAllowed(trafficTo(&TrafficPeer{
+				IP:         "172.18.0.2",
+				NodeLabels: map[string]string{"node-role.kubernetes.io/control-plane": ""},
+			}))
+			Expect(result.IsAllowed()).To(BeFalse())
+			Expect(result.Egress.Flow()).To(Equal("[ANP] Deny (deny-to-control-plane)"))
+		})
+
+		It("should allow egress to non-matching nodes", func() {
+			Expect(policy.IsTrafficAllowed(trafficTo(&TrafficPeer{
+				IP:         "172.18.0.3",
+				NodeLabels: map[string]string{"kubernetes.io/hostname": "worker"},
+			})).IsAllowed()).To(BeTrue())
+		})
+
+		It("should not match pods or external ips", func() {
+			Expect(policy.IsTrafficAllowed(trafficTo(&TrafficPeer{
+				Internal: &InternalPeer{Namespace: "y"},
+				IP:       "10.0.0.5",
+			})).IsAllowed()).To(BeTrue())
+			Expect(policy.IsTrafficAllowed(trafficTo(&TrafficPeer{IP: "172.18.0.2"})).IsAllowed()).To(BeTrue())
+		})
+
+		It("should explain node peers", func() {
+			Expect(policy.ExplainTable()).To(ContainSubstring("node-role.kubernetes.io/control-plane ="))
+		})
+	})
+
+	Describe("ANP/BANP egress to networks", func() {
+		allNamespaces := &v1alpha1.AdminNetworkPolicySubject{Namespaces: &metav1.LabelSelector{}}
+		networks := func(cidrs ...string) *NetworksPeerMatcher {
+			return &NetworksPeerMatcher{Networks: cidrs, Port: &AllPortMatcher{}}
+		}
+		policy := NewPolicyWithTargets(nil, []*Target{
+			{
+				SubjectMatcher: NewSubjectAdmin(allNamespaces),
+				SourceRules:    []NetPolID{"[ANP] default/northbound", "[BANP] default/default"},
+				Peers: []PeerMatcher{
+					NewPeerMatcherANP(networks("192.168.0.0/16"), Pass, 5, "pass-internal", "pass-192"),
+					NewPeerMatcherANP(networks("10.0.0.0/8"), Allow, 10, "allow-cluster", "allow-pod-cidr"),
+					NewPeerMatcherANP(networks("0.0.0.0/0", "::/0"), Deny, 20, "northbound", "deny-northbound"),
+					NewPeerMatcherBANP(networks("192.168.0.0/16"), Deny, "default", "deny-192"),
+				},
+			},
+		})
+
+		source := &TrafficPeer{
+			Internal: &InternalPeer{Namespace: "x"},
+			IP:       "10.0.0.4",
+		}
+		trafficTo := func(destination *TrafficPeer) *Traffic {
+			return &Traffic{Source: source, Destination: destination, ResolvedPort: 443, Protocol: v1.ProtocolTCP}
+		}
+
+		It("should deny external ips", func() {
+			result := policy.IsTrafficAllowed(trafficTo(&TrafficPeer{IP: "8.8.8.8"}))
+			Expect(result.IsAllowed()).To(BeFalse())
+			Expect(result.Egress.Flow()).To(Equal("[ANP] Deny (deny-northbound)"))
+
+			Expect(policy.IsTrafficAllowed(trafficTo(&TrafficPeer{IP: "2001:4860:4860::8888"})).IsAllowed()).To(BeFalse())
+		})
+
+		It("should match in-cluster pods by ip, respecting priority", func() {
+			result := policy.IsTrafficAllowed(trafficTo(&TrafficPeer{
+				Internal: &InternalPeer{Namespace: "y"},
+				IP:       "10.0.0.5",
+			}))
+			Expect(result.IsAllowed()).To(BeTrue())
+			Expect(result.Egress.Flow()).To(Equal("[ANP] Allow (allow-pod-cidr)"))
+		})
+
+		It("should not match peers without an ip", func() {
+			Expect(policy.IsTrafficAllowed(trafficTo(&TrafficPeer{
+				Internal: &InternalPeer{Namespace: "y"},
+			})).IsAllowed()).To(BeTrue())
+		})
+
+		It("should not match peers whose ip can't be parsed", func() {
+			Expect(networks("0.0.0.0/0").MatchesIP("not-an-ip")).To(BeFalse())
+			Expect(policy.IsTrafficAllowed(trafficTo(&TrafficPeer{
+				Internal: &InternalPeer{Namespace: "y"},
+				IP:       "10.0.0.300",
+			})).IsAllowed()).To(BeTrue())
+		})
+
+		It("should pass to BANP", func() {
+			result := policy.IsTrafficAllowed(trafficTo(&TrafficPeer{IP: "192.168.1.1"}))
+			Expect(result.IsAllowed()).To(BeFalse())
+			Expect(result.Egress.Flow()).To(Equal("[ANP] Pass (pass-192) -> [BANP] Deny (deny-192)"))
+		})
+	})
+
+	Describe("ANP egress to domain names", func() {
+		allNamespaces := &v1alpha1.AdminNetworkPolicySubject{Namespaces: &metav1.LabelSelector{}}
+		policy := NewPolicyWithTargets(nil, []*Target{
+			{
+				SubjectMatcher: NewSubjectAdmin(allNamespaces),
+				SourceRules:    []NetPolID{"[ANP] default/allow-k8s-io", "[ANP] default/northbound"},
+				Peers: []PeerMatcher{
+					NewPeerMatcherANP(&DomainNamePeerMatcher{DomainNames: []string{"kubernetes.io", "*.kubernetes.io"}, Port: &AllPortMatcher{}}, Allow, 10, "allow-k8s-io", "allow-k8s-io"),
+					NewPeerMatcherANP(&NetworksPeerMatcher{Networks: []string{"0.0.0.0/0"}, Port: &AllPortMatcher{}}, Deny, 20, "northbound", "deny-northbound"),
+				},
+			},
+		})
+		resolver := NewStaticResolver()
+		resolver.Add("blog.kubernetes.io", "147.75.40.148")
+		resolver.Add("example.com", "93.184.216.34")
+
+		source := &TrafficPeer{Internal: &InternalPeer{Namespace: "x"}, IP: "10.0.0.4"}
+		trafficTo := func(destination *TrafficPeer) *Traffic {
+			return &Traffic{Source: source, Destination: destination, ResolvedPort: 443, Protocol: v1.ProtocolTCP}
+		}
+
+		It("should match the domain name of the peer", func() {
+			result := policy.IsTrafficAllowed(trafficTo(&TrafficPeer{DomainName: "kubernetes.io", IP: "147.75.40.147"}))
+			Expect(result.IsAllowed()).To(BeTrue())
+			Expect(result.Egress.Flow()).To(Equal("[ANP] Allow (allow-k8s-io)"))
+
+			Expect(policy.IsTrafficAllowed(trafficTo(&TrafficPeer{DomainName: "Latest.Blog.Kubernetes.IO."})).IsAllowed()).To(BeTrue())
+			Expect(policy.IsTrafficAllowed(trafficTo(&TrafficPeer{DomainName: "example.com", IP: "93.184.216.34"})).IsAllowed()).To(BeFalse())
+		})
+
+		It("should not match in-cluster peers", func() {
+			Expect(policy.IsTrafficAllowed(trafficTo(&TrafficPeer{
+				Internal:   &InternalPeer{Namespace: "y"},
+				DomainName: "kubernetes.io",
+				IP:         "10.0.0.5",
+			})).Egress.Flow()).To(Equal("[ANP] Deny (deny-northbound)"))
+		})
+
+		It("should resolve ips and domain names through the resolver", func() {
+			withResolver := *policy
+			withResolver.Resolver = resolver
+
+			Expect(policy.IsTrafficAllowed(trafficTo(&TrafficPeer{IP: "147.75.40.148"})).IsAllowed()).To(BeFalse())
+			Expect(withResolver.IsTrafficAllowed(trafficTo(&TrafficPeer{IP: "147.75.40.148"})).IsAllowed()).To(BeTrue())
+
+			// the ip is looked up for the domain name, so that Networks rules apply
+			result := withResolver.IsTrafficAllowed(trafficTo(&TrafficPeer{DomainName: "example.com"}))
+			Expect(result.IsAllowed()).To(BeFalse())
+			Expect(result.Egress.Flow()).To(Equal("[ANP] Deny (deny-northbound)"))
+		})
+	})
+
+	Describe("Admin policies of a target", func() {
+		allNamespaces := &v1alpha1.AdminNetworkPolicySubject{Namespaces: &metav1.LabelSelector{}}
+		anpTarget := &Target{
+			SubjectMatcher: NewSubjectAdmin(allNamespaces),
+			SourceRules:    []NetPolID{"[ANP] default/pass-all", "[ANP] default/allow-all", "[BANP] default/default"},
+			Peers: []PeerMatcher{
+				NewPeerMatcherANP(AllPeersPorts, Pass, 30, "pass-all", "pass"),
+				NewPeerMatcherBANP(AllPeersPorts, Deny, "default", "deny"),
+				NewPeerMatcherANP(AllPeersPorts, Allow, 5, "allow-all", "allow"),
+				NewPeerMatcherANP(AllPeersPorts, Allow, 5, "allow-all", "allow-again"),
+			},
+		}
+		v1Target := &Target{
+			SubjectMatcher: NewSubjectV1("x", metav1.LabelSelector{}),
+			SourceRules:    []NetPolID{"[NPv1] x/allow-all"},
+			Peers:          []PeerMatcher{AllPeersPorts},
+		}
+
+		It("should list the admin policies of a target by priority", func() {
+			Expect(anpTarget.AdminPolicies()).To(Equal([]AdminPolicy{
+				{Kind: AdminNetworkPolicy, Name: "allow-all", Priority: 5},
+				{Kind: AdminNetworkPolicy, Name: "pass-all", Priority: 30},
+				{Kind: BaselineAdminNetworkPolicy, Name: "default"},
+			}))
+			Expect(v1Target.AdminPolicies()).To(BeEmpty())
+		})
+	})
+
+	Describe("Serialization", func() {
+		It("should include the verdict and effects per direction", func() {
+			result := &AllowedResult{
+				Ingress: DirectionResult{
+					{RuleName: "pass-81", PolicyKind: AdminNetworkPolicy, Priority: 2, Verdict: Pass},
+					NewV1Effect(true, []string{"[NPv1] x/allow-81"}),
+				},
+			}
+			Expect(json.MustMarshalToString(result)).To(MatchJSON(`{
+				"Verdict": "Allowed",
+				"Ingress": {
+					"Allowed": true,
+					"Flow": "[ANP] Pass (pass-81) -> [NPv1] Allow (x/allow-81)",
+					"Effects": [
+						{"RuleName": "pass-81", "PolicyKind": "ANP", "Priority": 2, "Verdict": "Pass"},
+						{"RuleName": "x/allow-81", "PolicyKind": "NPv1", "Priority": 0, "Verdict": "Allow"}
+					]
+				},
+				"Egress": {"Allowed": true, "Flow": "", "Effects": []}
+			}`))
+		})
+
+		It("should serialize policies as sorted targets", func() {
+			policy := NewPolicyWithTargets([]*Target{
+				{
+					SubjectMatcher: NewSubjectV1("x", metav1.LabelSelector{}),
+					SourceRules:    []NetPolID{"[NPv1] x/deny-all"},
+					Peers:          []PeerMatcher{&NoMatcher{}},
+				},
+			}, nil)
+			policy.Resolver = NewStaticResolver()
+			Expect(json.MustMarshalToString(policy)).To(MatchJSON(`{
+				"Ingress": [
+					{
+						"SubjectMatcher": {"Type": "NPv1", "Namespace": "x", "PodSelector": {}},
+						"SourceRules": ["[NPv1] x/deny-all"],
+						"Peers": [{"Type": "no peers"}]
+					}
+				],
+				"Egress": []
+			}`))
+		})
+	})
 }
@@ -0,0 +1,184 @@
+package matcher
+
+import (
+	"github.com/mattfenwick/collections/pkg/slice"
+	"github.com/pkg/errors"
+	"golang.org/x/exp/maps"
+	networkingv1 "k8s.io/api/networking/v1"
+	"sigs.k8s.io/network-policy-api/apis/v1alpha1"
+)
+
+// PolicySet keeps a Policy up to date as network policies, ANPs and BANPs are set and removed one at a time:
+// a change only rebuilds the targets whose primary key the changed policy had or has, instead of every target.
+// Policies are identified by keys chosen by the caller.
+type PolicySet struct {
+	Policy   *Policy
+	simplify bool
+	// the targets built from each policy, by key
+	sources map[string]*policySource
+	// the keys of the policies contributing to each target, by primary key
+	ingressSources map[string]map[string]bool
+	egressSources  map[string]map[string]bool
+}
+
+type policySource struct {
+	ingress *Target
+	egress  *Target
+	// priority is only set for ANPs
+	priority *int32
+}
+
+func (s *policySource) target(isIngress bool) *Target {
+	if s == nil {
+		return nil
+	}
+	if isIngress {
+		return s.ingress
+	}
+	return s.egress
+}
+
+func NewPolicySet(simplify bool) *PolicySet {
+	return &PolicySet{
+		Policy:         NewPolicy(),
+		simplify:       simplify,
+		sources:        map[string]*policySource{},
+		ingressSources: map[string]map[string]bool{},
+		egressSources:  map[string]map[string]bool{},
+	}
+}
+
+// SetNetworkPolicy adds or replaces the network policy with the key, or removes it if netpol is nil
+func (s *PolicySet) SetNetworkPolicy(key string, netpol *networkingv1.NetworkPolicy) *ChangedSubjects {
+	if netpol == nil {
+		return s.set(key, nil)
+	}
+	ingress, egress := BuildTarget(netpol)
+	return s.set(key, &policySource{ingress: ingress, egress: egress})
+}
+
+// SetANP adds or replaces the ANP with the key, or removes it if anp is nil.  As BuildV1AndV2NetPols does, it
+// panics if another ANP has the same priority; the set is then unchanged.
+func (s *PolicySet) SetANP(key string, anp *v1alpha1.AdminNetworkPolicy) *ChangedSubjects {
+	if anp == nil {
+		return s.set(key, nil)
+	}
+	priority := anp.Spec.Priority
+	for other, source := range s.sources {
+		if other != key && source.priority != nil && *source.priority == priority {
+			panic(errors.Errorf("duplicate priorities are undefined. priority: %d", priority))
+		}
+	}
+	ingress, egress := BuildTargetANP(anp)
+	return s.set(key, &policySource{ingress: ingress, egress: egress, priority: &priority})
+}
+
+// SetBANP adds or replaces the BANP with the key, or removes it if banp is nil
+func (s *PolicySet) SetBANP(key string, banp *v1alpha1.BaselineAdminNetworkPolicy) *ChangedSubjects {
+	if banp == nil {
+		return s.set(key, nil)
+	}
+	ingress, egress := BuildTargetBANP(banp)
+	return s.set(key, &policySource{ingress: ingress, egress: egress})
+}
+
+func (s *PolicySet) set(key string, source *policySource) *ChangedSubjects {
+	previous := s.sources[key]
+	if source == nil {
+		delete(s.sources, key)
+	} else {
+		s.sources[key] = source
+	}
+	return &ChangedSubjects{
+		Ingress: s.update(true, key, previous.target(true), source.target(true)),
+		Egress:  s.update(false, key, previous.target(false), source.target(false)),
+	}
+}
+
+// update moves the policy's contribution to the targets of one direction from its previous target to its
+// current one, and rebuilds the targets concerned.  It returns their subjects.
+func (s *PolicySet) update(isIngress bool, key string, previous *Target, current *Target) []SubjectMatcher {
+	sources := s.egressSources
+	if isIngress {
+		sources = s.ingressSources
+	}
+
+	var subjects []SubjectMatcher
+	var previousPK string
+	if previous != nil {
+		previousPK = previous.GetPrimaryKey()
+		delete(sources[previousPK], key)
+		s.rebuild(isIngress, sources, previousPK)
+		subjects = append(subjects, previous.SubjectMatcher)
+	}
+	if current != nil {
+		pk := current.GetPrimaryKey()
+		if sources[pk] == nil {
+			sources[pk] = map[string]bool{}
+		}
+		sources[pk][key] = true
+		s.rebuild(isIngress, sources, pk)
+		if previous == nil || pk != previousPK {
+			subjects = append(subjects, current.SubjectMatcher)
+		}
+	}
+	return subjects
+}
+
+// rebuild combines the targets of the policies contributing to the primary key, in the order of their keys
+func (s *PolicySet) rebuild(isIngress bool, sources map[string]map[string]bool, pk string) {
+	if len(sources[pk]) == 0 {
+		delete(sources, pk)
+		s.Policy.SetTarget(isIngress, pk, nil)
+		return
+	}
+
+	var combined *Target
+	for _, key := range slice.Sort(maps.Keys(sources[pk])) {
+		target := s.sources[key].target(isIngress)
+		if combined == nil {
+			// copy the slices, which combining appends to
+			combined = &Target{
+				SubjectMatcher: target.SubjectMatcher,
+				SourceRules:    append([]NetPolID{}, target.SourceRules...),
+				Peers:          append([]PeerMatcher{}, target.Peers...),
+			}
+		} else {
+			combined = combined.Combine(target)
+		}
+	}
+	if s.simplify {
+		combined.Simplify()
+	}
+	s.Policy.SetTarget(isIngress, pk, combined)
+}
+
+// ChangedSubjects are the subjects of the targets which changes to a PolicySet rebuilt: only traffic to the
+// pods matching an Ingress subject, or from those matching an Egress subject, may have a different verdict
+type ChangedSubjects struct {
+	Ingress []SubjectMatcher
+	Egress  []SubjectMatcher
+}
+
+// Append adds the subjects of other
+func (c *ChangedSubjects) Append(other *ChangedSubjects) {
+	c.Ingress = append(c.Ingress, other.Ingress...)
+	c.Egress = append(c.Egress, other.Egress...)
+}
+
+// Affects returns true if the verdict of the traffic may have changed
+func (c *ChangedSubjects) Affects(traffic *Traffic) bool {
+	return anySubjectMatches(c.Ingress, traffic.Destination) || anySubjectMatches(c.Egress, traffic.Source)
+}
+
+func anySubjectMatches(subjects []SubjectMatcher, peer *TrafficPeer) bool {
+	if peer == nil || peer.Internal == nil {
+		return false
+	}
+	for _, subject := range subjects {
+		if subject.Matches(peer.Internal) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,155 @@
+package matcher
+
+import (
+	"github.com/mattfenwick/collections/pkg/slice"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"golang.org/x/exp/maps"
+	v1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/network-policy-api/apis/v1alpha1"
+)
+
+func RunPolicySetTests() {
+	Describe("PolicySet", func() {
+		netpol := func(namespace string, name string, pod string, fromPod string) *networkingv1.NetworkPolicy {
+			policy := &networkingv1.NetworkPolicy{
+				ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+				Spec: networkingv1.NetworkPolicySpec{
+					PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{"pod": pod}},
+					PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+				},
+			}
+			if fromPod != "" {
+				policy.Spec.Ingress = []networkingv1.NetworkPolicyIngressRule{{
+					From: []networkingv1.NetworkPolicyPeer{{PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"pod": fromPod}}}},
+				}}
+			}
+			return policy
+		}
+		anp := func(name string, priority int32, ns string, action v1alpha1.AdminNetworkPolicyRuleAction) *v1alpha1.AdminNetworkPolicy {
+			return &v1alpha1.AdminNetworkPolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: name},
+				Spec: v1alpha1.AdminNetworkPolicySpec{
+					Priority: priority,
+					Subject:  v1alpha1.AdminNetworkPolicySubject{Namespaces: &metav1.LabelSelector{MatchLabels: map[string]string{"ns": ns}}},
+					Egress: []v1alpha1.AdminNetworkPolicyEgressRule{{
+						Name:   name + "-rule",
+						Action: action,
+						To:     []v1alpha1.AdminNetworkPolicyEgressPeer{{Namespaces: &metav1.LabelSelector{}}},
+					}},
+				},
+			}
+		}
+
+		var peers []*InternalPeer
+		for _, ns := range []string{"x", "y"} {
+			for _, pod := range []string{"a", "b", "c"} {
+				peers = append(peers, &InternalPeer{
+					Namespace:       ns,
+					NamespaceLabels: map[string]string{"ns": ns},
+					PodLabels:       map[string]string{"pod": pod},
+				})
+			}
+		}
+		verdicts := func(policies *Policy) []bool {
+			var allowed []bool
+			for _, from := range peers {
+				for _, to := range peers {
+					allowed = append(allowed, policies.IsTrafficAllowed(&Traffic{
+						Source:       &TrafficPeer{Internal: from},
+						Destination:  &TrafficPeer{Internal: to},
+						ResolvedPort: 80,
+						Protocol:     v1.ProtocolTCP,
+					}).IsAllowed())
+				}
+			}
+			return allowed
+		}
+
+		It("should match building every policy after each change", func() {
+			set := NewPolicySet(true)
+			netpols := map[string]*networkingv1.NetworkPolicy{}
+			anps := map[string]*v1alpha1.AdminNetworkPolicy{}
+			expectSameVerdicts := func() {
+				Expect(verdicts(set.Policy)).To(Equal(verdicts(BuildV1AndV2NetPols(true, policyValues(netpols), policyValues(anps), nil))))
+				Expect(set.Policy.Ingress).To(HaveLen(len(set.ingressSources)))
+				Expect(set.Policy.Egress).To(HaveLen(len(set.egressSources)))
+			}
+			setNetpol := func(key string, policy *networkingv1.NetworkPolicy) {
+				set.SetNetworkPolicy(key, policy)
+				if policy == nil {
+					delete(netpols, key)
+				} else {
+					netpols[key] = policy
+				}
+				expectSameVerdicts()
+			}
+			setANP := func(key string, policy *v1alpha1.AdminNetworkPolicy) {
+				set.SetANP(key, policy)
+				if policy == nil {
+					delete(anps, key)
+				} else {
+					anps[key] = policy
+				}
+				expectSameVerdicts()
+			}
+
+			setNetpol("x/deny-a", netpol("x", "deny-a", "a", ""))
+			setNetpol("x/allow-b-to-a", netpol("x", "allow-b-to-a", "a", "b"))
+			setNetpol("y/deny-c", netpol("y", "deny-c", "c", ""))
+			setANP("deny-y", anp("deny-y", 10, "y", v1alpha1.AdminNetworkPolicyRuleActionDeny))
+			setANP("allow-y", anp("allow-y", 5, "y", v1alpha1.AdminNetworkPolicyRuleActionAllow))
+			// a change of subject moves the policy's rules to another target
+			setNetpol("x/deny-a", netpol("x", "deny-a", "b", ""))
+			setANP("deny-y", anp("deny-y", 10, "x", v1alpha1.AdminNetworkPolicyRuleActionDeny))
+			setNetpol("x/allow-b-to-a", nil)
+			setANP("allow-y", nil)
+			setNetpol("y/deny-c", nil)
+			setNetpol("x/deny-a", nil)
+			setANP("deny-y", nil)
+			Expect(set.Policy.Ingress).To(BeEmpty())
+			Expect(set.Policy.Egress).To(BeEmpty())
+		})
+
+		It("should report the subjects of the targets it rebuilt", func() {
+			set := NewPolicySet(false)
+			set.SetNetworkPolicy("x/deny-a", netpol("x", "deny-a", "a", ""))
+			changed := set.SetNetworkPolicy("x/deny-a", netpol("x", "deny-a", "b", ""))
+			Expect(changed.Ingress).To(HaveLen(2))
+			Expect(changed.Egress).To(BeEmpty())
+
+			changed.Append(set.SetANP("deny-y", anp("deny-y", 10, "y", v1alpha1.AdminNetworkPolicyRuleActionDeny)))
+			affected := 0
+			for _, from := range peers {
+				for _, to := range peers {
+					if changed.Affects(&Traffic{Source: &TrafficPeer{Internal: from}, Destination: &TrafficPeer{Internal: to}}) {
+						affected++
+					}
+				}
+			}
+			// to x/a and x/b, and from the pods of y
+			Expect(affected).To(Equal(2*6 + 3*4))
+		})
+
+		It("should reject ANPs with the priority of another", func() {
+			set := NewPolicySet(false)
+			set.SetANP("deny-y", anp("deny-y", 10, "y", v1alpha1.AdminNetworkPolicyRuleActionDeny))
+			Expect(func() {
+				set.SetANP("allow-y", anp("allow-y", 10, "y", v1alpha1.AdminNetworkPolicyRuleActionAllow))
+			}).To(Panic())
+			Expect(set.sources).To(HaveLen(1))
+			// an ANP may keep its own priority
+			set.SetANP("deny-y", anp("deny-y", 10, "x", v1alpha1.AdminNetworkPolicyRuleActionDeny))
+		})
+	})
+}
+
+func policyValues[T any](policies map[string]*T) []*T {
+	var values []*T
+	for _, key := range slice.Sort(maps.Keys(policies)) {
+		values = append(values, policies[key])
+	}
+	return values
+}
@@ -0,0 +1,79 @@
+package matcher
+
+import (
+	"bufio"
+	"bytes"
+	"net"
+	"strings"
+
+	"github.com/mattfenwick/collections/pkg/file"
+	"github.com/pkg/errors"
+)
+
+// Resolver maps between domain names and IPs, so that DomainNames rules can be evaluated
+// for traffic which only specifies one of the two.
+type Resolver interface {
+	// LookupHost returns the IPs of a domain name
+	LookupHost(name string) ([]string, error)
+	// LookupAddr returns the domain names of an IP
+	LookupAddr(ip string) ([]string, error)
+}
+
+// StaticResolver is a Resolver backed by fixed mappings, e.g. from a hosts file.
+// It never fails: unknown names and IPs resolve to nothing.
+type StaticResolver struct {
+	hosts map[string][]string
+	addrs map[string][]string
+}
+
+func NewStaticResolver() *StaticResolver {
+	return &StaticResolver{hosts: map[string][]string{}, addrs: map[string][]string{}}
+}
+
+// NewStaticResolverFromHostsFile reads a file in the /etc/hosts format:
+// one IP per line followed by its domain names, with '#' starting a comment.
+func NewStaticResolverFromHostsFile(path string) (*StaticResolver, error) {
+	content, err := file.Read(path)
+	if err != nil {
+		return nil, err
+	}
+	resolver, err := ParseHostsFile(content)
+	return resolver, errors.WithMessagef(err, "unable to parse hosts file %s", path)
+}
+
+func ParseHostsFile(content []byte) (*StaticResolver, error) {
+	resolver := NewStaticResolver()
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) == 1 {
+			return nil, errors.Errorf("line %d: no domain names for %s", lineNumber, fields[0])
+		}
+		if net.ParseIP(fields[0]) == nil {
+			return nil, errors.Errorf("line %d: unable to parse IP '%s'", lineNumber, fields[0])
+		}
+		for _, name := range fields[1:] {
+			resolver.Add(name, fields[0])
+		}
+	}
+	return resolver, errors.Wrapf(scanner.Err(), "unable to read hosts")
+}
+
+// Add maps a domain name to an IP, in both directions
+func (s *StaticResolver) Add(name string, ip string) {
+	name = normalizeDomainName(name)
+	s.hosts[name] = append(s.hosts[name], ip)
+	s.addrs[ip] = append(s.addrs[ip], name)
+}
+
+func (s *StaticResolver) LookupHost(name string) ([]string, error) {
+	return s.hosts[normalizeDomainName(name)], nil
+}
+
+func (s *StaticResolver) LookupAddr(ip string) ([]string, error) {
+	return s.addrs[ip], nil
+}
@@ -0,0 +1,45 @@
+package matcher
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func RunResolverTests() {
+	Describe("IsDomainNameMatch", func() {
+		It("should match exact domain names", func() {
+			Expect(IsDomainNameMatch("kubernetes.io", "kubernetes.io")).To(BeTrue())
+			Expect(IsDomainNameMatch("kubernetes.io", "KUBERNETES.io.")).To(BeTrue())
+			Expect(IsDomainNameMatch("kubernetes.io", "blog.kubernetes.io")).To(BeFalse())
+		})
+
+		It("should match wildcards against one or more labels", func() {
+			Expect(IsDomainNameMatch("*.kubernetes.io", "blog.kubernetes.io")).To(BeTrue())
+			Expect(IsDomainNameMatch("*.kubernetes.io", "latest.blog.kubernetes.io")).To(BeTrue())
+			Expect(IsDomainNameMatch("*.kubernetes.io", "kubernetes.io")).To(BeFalse())
+			Expect(IsDomainNameMatch("*.kubernetes.io", "notkubernetes.io")).To(BeFalse())
+		})
+	})
+
+	Describe("ParseHostsFile", func() {
+		It("should parse ips and domain names, skipping comments", func() {
+			resolver, err := ParseHostsFile([]byte(`
+# comment
+93.184.216.34   example.com www.example.com # trailing comment
+2606:2800:220:1:248:1893:25c8:1946 example.com
+`))
+			Expect(err).To(BeNil())
+			Expect(resolver.LookupHost("www.example.com")).To(Equal([]string{"93.184.216.34"}))
+			Expect(resolver.LookupHost("Example.com")).To(Equal([]string{"93.184.216.34", "2606:2800:220:1:248:1893:25c8:1946"}))
+			Expect(resolver.LookupAddr("93.184.216.34")).To(Equal([]string{"example.com", "www.example.com"}))
+			Expect(resolver.LookupHost("kubernetes.io")).To(BeEmpty())
+		})
+
+		It("should reject invalid lines", func() {
+			_, err := ParseHostsFile([]byte("example.com 93.184.216.34"))
+			Expect(err).ToNot(BeNil())
+			_, err = ParseHostsFile([]byte("93.184.216.34"))
+			Expect(err).ToNot(BeNil())
+		})
+	})
+}
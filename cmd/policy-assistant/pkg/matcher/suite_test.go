@@ -10,7 +10,11 @@ import (
 func TestMatcher(t *testing.T) {
 	RegisterFailHandler(Fail)
 	RunBuilderTests()
+	RunLintTests()
 	RunPolicyTests()
+	RunPolicySetTests()
+	RunResolverTests()
 	RunSimplifierTests()
+	RunTargetIndexTests()
 	RunSpecs(t, "network policy matcher suite")
 }
@@ -1,7 +1,9 @@
 package matcher
 
 import (
+	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -86,16 +88,57 @@ func CombineTargetsIgnoringPrimaryKey(namespace string, podSelector metav1.Label
 	}
 	target := &Target{
 		SubjectMatcher: NewSubjectV1(namespace, podSelector),
-		Peers:          targets[0].Peers,
-		SourceRules:    targets[0].SourceRules,
 	}
-	for _, t := range targets[1:] {
+	// copy rather than alias the first target's slices, which would otherwise get appended to
+	for _, t := range targets {
 		target.Peers = append(target.Peers, t.Peers...)
 		target.SourceRules = append(target.SourceRules, t.SourceRules...)
 	}
 	return target
 }
 
+// AdminPolicy identifies an ANP or BANP which contributes rules to a Target
+type AdminPolicy struct {
+	Kind PolicyKind
+	Name string
+	// Priority is only set for ANPs
+	Priority int
+}
+
+// AdminPolicies returns the ANPs and BANPs which contribute rules to the Target:
+// ANPs first, ordered by priority and then by name, followed by BANPs.
+func (t *Target) AdminPolicies() []AdminPolicy {
+	seen := map[AdminPolicy]bool{}
+	var policies []AdminPolicy
+	for _, peer := range t.Peers {
+		admin, ok := peer.(*PeerMatcherAdmin)
+		if !ok {
+			continue
+		}
+		policy := AdminPolicy{Kind: admin.effectFromMatch.PolicyKind, Name: admin.PolicyName, Priority: admin.effectFromMatch.Priority}
+		if !seen[policy] {
+			seen[policy] = true
+			policies = append(policies, policy)
+		}
+	}
+	SortAdminPolicies(policies)
+	return policies
+}
+
+// SortAdminPolicies sorts ANPs by priority and then by name, followed by BANPs
+func SortAdminPolicies(policies []AdminPolicy) {
+	sort.SliceStable(policies, func(i, j int) bool {
+		a, b := policies[i], policies[j]
+		if a.Kind != b.Kind {
+			return a.Kind == AdminNetworkPolicy
+		}
+		if a.Priority != b.Priority {
+			return a.Priority < b.Priority
+		}
+		return a.Name < b.Name
+	})
+}
+
 // SubjectMatcher defines which Pods a ANP, BANP, or v1 NetPol applies to
 type SubjectMatcher interface {
 	// Matches returns true if the candidate satisfies the subject selector
@@ -125,6 +168,14 @@ func (s *SubjectV1) Matches(candidate *InternalPeer) bool {
 	return s.namespace == candidate.Namespace && kube.IsLabelsMatchLabelSelector(candidate.PodLabels, s.podSelector)
 }
 
+func (s *SubjectV1) MarshalJSON() (b []byte, e error) {
+	return json.Marshal(map[string]interface{}{
+		"Type":        NetworkPolicyV1,
+		"Namespace":   s.namespace,
+		"PodSelector": s.podSelector,
+	})
+}
+
 func (s *SubjectV1) TargetString() string {
 	pods := kube.LabelSelectorTableLines(s.podSelector)
 	if pods == "all" {
@@ -172,6 +223,13 @@ func (s *SubjectAdmin) Matches(candidate *InternalPeer) bool {
 		kube.IsLabelsMatchLabelSelector(candidate.PodLabels, s.subject.Pods.PodSelector)
 }
 
+func (s *SubjectAdmin) MarshalJSON() (b []byte, e error) {
+	return json.Marshal(map[string]interface{}{
+		"Type":    "admin",
+		"Subject": s.subject,
+	})
+}
+
 func (s *SubjectAdmin) TargetString() string {
 	if s.subject.Namespaces != nil {
 		namespace := kube.LabelSelectorTableLines(*s.subject.Namespaces)
@@ -0,0 +1,230 @@
+package matcher
+
+import (
+	"sort"
+
+	"github.com/mattfenwick/collections/pkg/slice"
+	"golang.org/x/exp/maps"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// targetIndex finds the Targets of one direction which may apply to a pod, without checking every Target:
+// v1 targets are keyed by namespace, and admin targets by a label requirement of their subject.
+// Candidates are then checked against their full subject, so the index only needs to never miss a Target.
+type targetIndex struct {
+	entries map[string]*indexedTarget
+	// v1 targets, by namespace
+	byNamespace map[string][]*indexedTarget
+	// admin targets, by a "key=value" requirement of their namespace selector or else of their pod selector
+	byNamespaceLabel map[string][]*indexedTarget
+	byPodLabel       map[string][]*indexedTarget
+	// targets with no such requirement, e.g. admin targets selecting all pods
+	unindexed []*indexedTarget
+	// added counts the targets ever added, so that each gets a distinct order
+	added int
+}
+
+// indexedTarget keeps a Target along with the effects of its peers, which don't change between evaluations
+type indexedTarget struct {
+	// order is when the target was first added: candidates are returned in this order
+	order  int
+	target *Target
+	peers  []targetPeer
+}
+
+// targetPeer is a peer matcher and its effect on traffic it matches, in their Target's order
+type targetPeer struct {
+	matcher PeerMatcher
+	effect  Effect
+}
+
+func newTargetIndex() *targetIndex {
+	return &targetIndex{
+		entries:          map[string]*indexedTarget{},
+		byNamespace:      map[string][]*indexedTarget{},
+		byNamespaceLabel: map[string][]*indexedTarget{},
+		byPodLabel:       map[string][]*indexedTarget{},
+	}
+}
+
+// set adds a target, or replaces the target with the same primary key -- which has the same subject, and
+// therefore the same keys in the index
+func (idx *targetIndex) set(pk string, target *Target) {
+	if entry, ok := idx.entries[pk]; ok {
+		entry.target = target
+		entry.peers = buildTargetPeers(target)
+		return
+	}
+
+	entry := &indexedTarget{order: idx.added, target: target, peers: buildTargetPeers(target)}
+	idx.added++
+	idx.entries[pk] = entry
+	for _, b := range indexBuckets(target.SubjectMatcher) {
+		if b.lists == "" {
+			idx.unindexed = append(idx.unindexed, entry)
+		} else {
+			lists := idx.lists(b.lists)
+			lists[b.key] = append(lists[b.key], entry)
+		}
+	}
+}
+
+// remove removes the target with the primary key, if any
+func (idx *targetIndex) remove(pk string) {
+	entry, ok := idx.entries[pk]
+	if !ok {
+		return
+	}
+	delete(idx.entries, pk)
+	for _, b := range indexBuckets(entry.target.SubjectMatcher) {
+		if b.lists == "" {
+			idx.unindexed = withoutEntry(idx.unindexed, entry)
+			continue
+		}
+		lists := idx.lists(b.lists)
+		if remaining := withoutEntry(lists[b.key], entry); len(remaining) > 0 {
+			lists[b.key] = remaining
+		} else {
+			delete(lists, b.key)
+		}
+	}
+}
+
+func withoutEntry(entries []*indexedTarget, entry *indexedTarget) []*indexedTarget {
+	var remaining []*indexedTarget
+	for _, e := range entries {
+		if e != entry {
+			remaining = append(remaining, e)
+		}
+	}
+	return remaining
+}
+
+type indexList string
+
+const (
+	indexByNamespace      indexList = "namespace"
+	indexByNamespaceLabel indexList = "namespaceLabel"
+	indexByPodLabel       indexList = "podLabel"
+)
+
+func (idx *targetIndex) lists(list indexList) map[string][]*indexedTarget {
+	switch list {
+	case indexByNamespace:
+		return idx.byNamespace
+	case indexByNamespaceLabel:
+		return idx.byNamespaceLabel
+	default:
+		return idx.byPodLabel
+	}
+}
+
+// indexBucket is where a target goes in the index: under key in one of its maps, or in the unindexed list
+// if lists is empty
+type indexBucket struct {
+	lists indexList
+	key   string
+}
+
+// indexBuckets finds where a target with the subject goes in the index
+func indexBuckets(subjectMatcher SubjectMatcher) []indexBucket {
+	switch subject := subjectMatcher.(type) {
+	case *SubjectV1:
+		return []indexBucket{{lists: indexByNamespace, key: subject.namespace}}
+	case *SubjectAdmin:
+		if (subject.subject.Namespaces == nil) == (subject.subject.Pods == nil) {
+			// matches no pods: see SubjectAdmin.Matches
+			return nil
+		}
+		var nsSelector, podSelector metav1.LabelSelector
+		if subject.subject.Namespaces != nil {
+			nsSelector = *subject.subject.Namespaces
+		} else {
+			nsSelector, podSelector = subject.subject.Pods.NamespaceSelector, subject.subject.Pods.PodSelector
+		}
+		var buckets []indexBucket
+		if keys := selectorRequirementKeys(nsSelector); len(keys) > 0 {
+			for _, key := range keys {
+				buckets = append(buckets, indexBucket{lists: indexByNamespaceLabel, key: key})
+			}
+		} else if keys = selectorRequirementKeys(podSelector); len(keys) > 0 {
+			for _, key := range keys {
+				buckets = append(buckets, indexBucket{lists: indexByPodLabel, key: key})
+			}
+		} else {
+			buckets = append(buckets, indexBucket{})
+		}
+		return buckets
+	default:
+		return []indexBucket{{}}
+	}
+}
+
+// selectorRequirementKeys returns "key=value" strings, one of which the labels of anything matching the
+// selector must have: those of a MatchLabels requirement or else of an In expression.  Since labels have one
+// value per key, a target is never found more than once through them.
+func selectorRequirementKeys(selector metav1.LabelSelector) []string {
+	if len(selector.MatchLabels) > 0 {
+		key := slice.Sort(maps.Keys(selector.MatchLabels))[0]
+		return []string{labelKey(key, selector.MatchLabels[key])}
+	}
+	for _, exp := range selector.MatchExpressions {
+		if exp.Operator == metav1.LabelSelectorOpIn {
+			keys := map[string]bool{}
+			for _, value := range exp.Values {
+				keys[labelKey(exp.Key, value)] = true
+			}
+			return maps.Keys(keys)
+		}
+	}
+	return nil
+}
+
+func labelKey(key string, value string) string {
+	return key + "=" + value
+}
+
+// refresh rebuilds the effects of the peers of every target, i.e. after simplifying them
+func (idx *targetIndex) refresh() {
+	for _, entry := range idx.entries {
+		entry.peers = buildTargetPeers(entry.target)
+	}
+}
+
+// matching returns the targets applying to a pod, in the order they were added
+func (idx *targetIndex) matching(subject *InternalPeer) []*indexedTarget {
+	candidates := append([]*indexedTarget{}, idx.byNamespace[subject.Namespace]...)
+	for key, value := range subject.NamespaceLabels {
+		candidates = append(candidates, idx.byNamespaceLabel[labelKey(key, value)]...)
+	}
+	for key, value := range subject.PodLabels {
+		candidates = append(candidates, idx.byPodLabel[labelKey(key, value)]...)
+	}
+	candidates = append(candidates, idx.unindexed...)
+
+	var matching []*indexedTarget
+	for _, entry := range candidates {
+		if entry.target.Matches(subject) {
+			matching = append(matching, entry)
+		}
+	}
+	sort.Slice(matching, func(i, j int) bool { return matching[i].order < matching[j].order })
+	return matching
+}
+
+func buildTargetPeers(target *Target) []targetPeer {
+	names := make([]string, len(target.SourceRules))
+	for i, r := range target.SourceRules {
+		names[i] = string(r)
+	}
+	v1Effect := NewV1Effect(true, names)
+
+	peers := make([]targetPeer, len(target.Peers))
+	for i, m := range target.Peers {
+		peers[i] = targetPeer{matcher: m, effect: v1Effect}
+		if matcherAdmin, ok := m.(*PeerMatcherAdmin); ok {
+			peers[i].effect = matcherAdmin.effectFromMatch
+		}
+	}
+	return peers
+}
@@ -0,0 +1,99 @@
+package matcher
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/network-policy-api/apis/v1alpha1"
+	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/utils"
+)
+
+func RunTargetIndexTests() {
+	Describe("Target index", func() {
+		netpols := []*networkingv1.NetworkPolicy{
+			{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "x", Name: "deny-all"},
+				Spec:       networkingv1.NetworkPolicySpec{PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress}},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "y", Name: "allow-to-a"},
+				Spec: networkingv1.NetworkPolicySpec{
+					PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{"pod": "a"}},
+					Ingress:     []networkingv1.NetworkPolicyIngressRule{{}},
+					PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+				},
+			},
+		}
+		anp := func(name string, priority int32, subject string, action v1alpha1.AdminNetworkPolicyRuleAction) *v1alpha1.AdminNetworkPolicy {
+			policy, err := utils.ParseYaml[v1alpha1.AdminNetworkPolicy]([]byte(subject))
+			utils.DoOrDie(err)
+			policy.Name = name
+			policy.Spec.Priority = priority
+			policy.Spec.Ingress = []v1alpha1.AdminNetworkPolicyIngressRule{{
+				Name:   name + "-rule",
+				Action: action,
+				From:   []v1alpha1.AdminNetworkPolicyIngressPeer{{Namespaces: &metav1.LabelSelector{MatchLabels: map[string]string{"ns": "z"}}}},
+			}}
+			return policy
+		}
+		anps := []*v1alpha1.AdminNetworkPolicy{
+			anp("by-ns-label", 20, `{"spec": {"subject": {"namespaces": {"matchLabels": {"ns": "y", "team": "blue"}}}}}`, v1alpha1.AdminNetworkPolicyRuleActionAllow),
+			anp("by-ns-expression", 10, `{"spec": {"subject": {"namespaces": {"matchExpressions": [{"key": "ns", "operator": "In", "values": ["x", "y", "x"]}]}}}}`, v1alpha1.AdminNetworkPolicyRuleActionPass),
+			anp("by-pod-label", 5, `{"spec": {"subject": {"pods": {"namespaceSelector": {}, "podSelector": {"matchLabels": {"pod": "b"}}}}}}`, v1alpha1.AdminNetworkPolicyRuleActionDeny),
+			anp("by-pod-expression", 30, `{"spec": {"subject": {"pods": {"namespaceSelector": {}, "podSelector": {"matchExpressions": [{"key": "pod", "operator": "NotIn", "values": ["a"]}]}}}}}`, v1alpha1.AdminNetworkPolicyRuleActionAllow),
+			anp("all", 40, `{"spec": {"subject": {"namespaces": {}}}}`, v1alpha1.AdminNetworkPolicyRuleActionDeny),
+			// same subject as by-pod-label: their rules are combined into one target
+			anp("by-pod-label-again", 1, `{"spec": {"subject": {"pods": {"namespaceSelector": {}, "podSelector": {"matchLabels": {"pod": "b"}}}}}}`, v1alpha1.AdminNetworkPolicyRuleActionPass),
+		}
+		policies := BuildV1AndV2NetPols(false, netpols, anps, nil)
+
+		var subjects []*InternalPeer
+		for _, ns := range []string{"x", "y", "z"} {
+			for _, pod := range []string{"a", "b", "c"} {
+				for _, team := range []string{"blue", "red"} {
+					subjects = append(subjects, &InternalPeer{
+						Namespace:       ns,
+						NamespaceLabels: map[string]string{"ns": ns, "team": team},
+						PodLabels:       map[string]string{"pod": pod},
+					})
+				}
+			}
+		}
+
+		It("should find the same targets as checking every target", func() {
+			for _, isIngress := range []bool{true, false} {
+				dict := policies.Egress
+				if isIngress {
+					dict = policies.Ingress
+				}
+				for _, subject := range subjects {
+					var expected []*Target
+					for _, target := range dict {
+						if target.Matches(subject) {
+							expected = append(expected, target)
+						}
+					}
+					Expect(policies.TargetsApplyingToPod(isIngress, subject)).To(ConsistOf(expected))
+				}
+			}
+			Expect(policies.TargetsApplyingToPod(true, subjects[0])).To(HaveLen(3))
+		})
+
+		It("should resolve the rules of combined ANPs by priority", func() {
+			subject := &InternalPeer{Namespace: "y", NamespaceLabels: map[string]string{"ns": "y", "team": "red"}, PodLabels: map[string]string{"pod": "b"}}
+			result := policies.IsTrafficAllowed(&Traffic{
+				Source:       &TrafficPeer{Internal: &InternalPeer{Namespace: "z", NamespaceLabels: map[string]string{"ns": "z"}}},
+				Destination:  &TrafficPeer{Internal: subject},
+				ResolvedPort: 80,
+				Protocol:     v1.ProtocolTCP,
+			})
+			// by-ns-expression, by-pod-label(-again), by-pod-expression, all
+			Expect(result.Ingress).To(HaveLen(5))
+			// the pass at priority 1 takes precedence over the deny at priority 5
+			Expect(result.Ingress.Flow()).To(Equal("[ANP] Pass (by-pod-label-again-rule)"))
+			Expect(result.IsAllowed()).To(BeTrue())
+		})
+	})
+}
@@ -29,7 +29,7 @@ func (t *Traffic) Table() string {
 	table.SetAutoMergeCells(true)
 
 	pp := fmt.Sprintf("%d (%s) on %s", t.ResolvedPort, t.ResolvedPortName, t.Protocol)
-	table.SetHeader([]string{"Port/Protocol", "Source/Dest", "Pod IP", "Namespace", "NS Labels", "Pod Labels"})
+	table.SetHeader([]string{"Port/Protocol", "Source/Dest", "Pod IP", "Namespace", "NS Labels", "Pod Labels", "Node Labels"})
 
 	source := []string{pp, "source", t.Source.IP}
 	if t.Source.Internal != nil {
@@ -38,6 +38,7 @@ func (t *Traffic) Table() string {
 	} else {
 		source = append(source, "", "", "")
 	}
+	source = append(source, labelsToString(t.Source.NodeLabels))
 	table.Append(source)
 
 	dest := []string{pp, "destination", t.Destination.IP}
@@ -47,6 +48,7 @@ func (t *Traffic) Table() string {
 	} else {
 		dest = append(dest, "", "", "")
 	}
+	dest = append(dest, labelsToString(t.Destination.NodeLabels))
 	table.Append(dest)
 
 	table.Render()
@@ -61,6 +63,12 @@ func labelsToString(labels map[string]string) string {
 // Helper function to generate the string for source or destination
 func (t *Traffic) formatPeer(peer *TrafficPeer) string {
 	if peer.Internal == nil {
+		if peer.IsNode() {
+			return fmt.Sprintf("node %s%s", peer.IP, labelsToStringSlim(peer.NodeLabels))
+		}
+		if peer.DomainName != "" {
+			return fmt.Sprintf("%s (%s)", peer.DomainName, peer.IP)
+		}
 		return fmt.Sprintf("%s", peer.IP)
 	}
 
@@ -97,6 +105,12 @@ type TrafficPeer struct {
 	Internal *InternalPeer
 	// IP external to cluster
 	IP string
+	// optional: labels of the Node, if the peer is a cluster Node (or a host-networked Pod)
+	NodeLabels map[string]string
+	// optional: FQDN of a peer external to cluster, for ANP DomainNames rules
+	DomainName string
+	// domain names which IP resolves to; filled in through the Policy's Resolver
+	ResolvedDomainNames []string `json:"-"`
 }
 
 func (p *TrafficPeer) Namespace() string {
@@ -110,6 +124,19 @@ func (p *TrafficPeer) IsExternal() bool {
 	return p.Internal == nil
 }
 
+// AllDomainNames returns the DomainName of the peer along with its resolved domain names
+func (p *TrafficPeer) AllDomainNames() []string {
+	if p.DomainName == "" {
+		return p.ResolvedDomainNames
+	}
+	return append([]string{p.DomainName}, p.ResolvedDomainNames...)
+}
+
+// IsNode returns true if the peer is a cluster Node, which ANP/BANP egress rules can select by node labels
+func (p *TrafficPeer) IsNode() bool {
+	return p.NodeLabels != nil
+}
+
 func CreateTrafficPeer(ip string, internal *InternalPeer) *TrafficPeer {
 	return &TrafficPeer{
 		IP:       ip,
@@ -150,7 +177,8 @@ func GetInternalPeerInfo(workload string) *TrafficPeer {
 			Namespace:       workloadInfo.Internal.Namespace,
 			Workload:        workloadInfo.Internal.Workload,
 		},
-		IP: workloadInfo.Internal.Pods[0].IP,
+		IP:         workloadInfo.Internal.Pods[0].IP,
+		NodeLabels: workloadInfo.Internal.Pods[0].NodeLabels,
 	}
 }
 
@@ -169,35 +197,35 @@ func (p *TrafficPeer) Translate() TrafficPeer {
 		logrus.Fatalf("unable to read pods from kube, ns '%s': %+v", workloadMetadata[0], err)
 	}
 
+	// deployments own pods through replicasets
+	var replicaSetDeployments map[string]string
+	if workloadMetadata[1] == "deployment" {
+		kubeReplicaSets, err := kubeClient.GetReplicaSetsInNamespace(workloadMetadata[0])
+		if err != nil {
+			logrus.Fatalf("unable to read replicasets from kube, ns '%s': %+v", workloadMetadata[0], err)
+		}
+		replicaSetDeployments = kube.ReplicaSetDeployments(kubeReplicaSets)
+	}
+
 	var podsNetworking []*PodNetworking
 	var podLabels map[string]string
 	var namespaceLabels map[string]string
 	workloadOwnerExists := false
-	for _, pod := range kubePods {
-		var workloadOwner string
-		var workloadKind string
-		if workloadMetadata[1] == "deployment" && pod.OwnerReferences != nil && pod.OwnerReferences[0].Kind == "ReplicaSet" {
-			kubeReplicaSets, err := kubeClient.GetReplicaSet(workloadMetadata[0], pod.OwnerReferences[0].Name)
-			if err != nil {
-				logrus.Fatalf("unable to read Replicaset from kube, rs '%s': %+v", pod.OwnerReferences[0].Name, err)
-			}
-			if kubeReplicaSets.OwnerReferences != nil {
-				workloadOwner = kubeReplicaSets.OwnerReferences[0].Name
-				workloadKind = "deployment"
-			}
-
-		} else if (workloadMetadata[1] == "daemonset" || workloadMetadata[1] == "statefulset" || workloadMetadata[1] == "replicaset") && pod.OwnerReferences != nil {
-			workloadOwner = pod.OwnerReferences[0].Name
-			workloadKind = pod.OwnerReferences[0].Kind
-		} else if workloadMetadata[1] == "pod" {
-			workloadOwner = pod.Name
-			workloadKind = "pod"
-		}
-		if strings.ToLower(workloadOwner) == workloadMetadata[2] && strings.ToLower(workloadKind) == workloadMetadata[1] {
+	for i := range kubePods {
+		pod := kubePods[i]
+		if isOwnedByWorkload(&pod, replicaSetDeployments, workloadMetadata[1], workloadMetadata[2]) {
 			podLabels = pod.Labels
 			namespaceLabels = ns.Labels
 			podNetworking := PodNetworking{
-				IP: pod.Status.PodIP,
+				IP:               pod.Status.PodIP,
+				IsHostNetworking: pod.Spec.HostNetwork,
+			}
+			if pod.Spec.HostNetwork {
+				node, err := kubeClient.GetNode(pod.Spec.NodeName)
+				if err != nil {
+					logrus.Fatalf("unable to read node from kube, node '%s': %+v", pod.Spec.NodeName, err)
+				}
+				podNetworking.NodeLabels = node.Labels
 			}
 			podsNetworking = append(podsNetworking, &podNetworking)
 			workloadOwnerExists = true
@@ -229,6 +257,16 @@ func (p *TrafficPeer) Translate() TrafficPeer {
 	return TranslatedPeer
 }
 
+// isOwnedByWorkload is true if the pod belongs to the workload of the lower case kind and name
+func isOwnedByWorkload(pod *v1.Pod, replicaSetDeployments map[string]string, kind string, name string) bool {
+	for _, workload := range kube.PodOwnerWorkloads(pod, replicaSetDeployments) {
+		if workload.Kind == kind && strings.ToLower(workload.Name) == name {
+			return true
+		}
+	}
+	return false
+}
+
 func WorkloadStringToTrafficPeer(workloadString string) TrafficPeer {
 	//Translates a Workload string to a TrafficPeer.
 	//var deploymentPeers []TrafficPeer
@@ -436,7 +474,7 @@ type InternalPeer struct {
 
 type PodNetworking struct {
 	IP string
-	// don't worry about populating below fields right now
+	// only populated for host-networked pods, whose traffic comes from (and goes to) their Node
 	IsHostNetworking bool
-	NodeLabels       []string
+	NodeLabels       map[string]string
 }
@@ -0,0 +1,97 @@
+package telemetry
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	namespace = "policy_assistant"
+
+	RunnerKube      = "kube"
+	RunnerKubeBatch = "kube-batch"
+
+	ProbeResultAllowed  = "allowed"
+	ProbeResultBlocked  = "blocked"
+	ProbeResultTimedOut = "timed-out"
+	ProbeResultFailed   = "failed"
+
+	TestCaseResultPassed = "passed"
+	TestCaseResultFailed = "failed"
+	TestCaseResultError  = "error"
+)
+
+var (
+	probesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "probes_total",
+		Help:      "Kube probes issued, by runner, protocol and result.  Failed probes couldn't be run at all.",
+	}, []string{"runner", "protocol", "result"})
+	probesInFlight = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "probes_in_flight",
+		Help:      "Kube probes -- or batches of probes, for the batch runner -- waiting for the API server.",
+	}, []string{"runner"})
+	probeDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "probe_duration_seconds",
+		Help:      "Time to execute a kube probe -- or a batch of probes, for the batch runner -- in a pod.  Grows when the API server throttles exec requests.",
+		Buckets:   prometheus.ExponentialBuckets(0.05, 2, 12),
+	}, []string{"runner"})
+	stepDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "step_duration_seconds",
+		Help:      "Time to run a test case step: its actions, the wait for them to take effect, and every kube probe try.",
+		Buckets:   prometheus.ExponentialBuckets(1, 2, 12),
+	})
+	testCasesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "test_cases_total",
+		Help:      "Test cases run, by tag and result.  A test case is counted once for each of its tags.",
+	}, []string{"tag", "result"})
+)
+
+// ProbeStarted tracks a kube probe as in flight, until the returned function is called with its result
+func ProbeStarted(runner string) func() {
+	start := time.Now()
+	probesInFlight.WithLabelValues(runner).Inc()
+	return func() {
+		probesInFlight.WithLabelValues(runner).Dec()
+		probeDuration.WithLabelValues(runner).Observe(time.Since(start).Seconds())
+	}
+}
+
+func ObserveProbe(runner string, protocol v1.Protocol, result string) {
+	probesTotal.WithLabelValues(runner, string(protocol), result).Inc()
+}
+
+func ObserveStep(duration time.Duration) {
+	stepDuration.Observe(duration.Seconds())
+}
+
+func ObserveTestCase(tags []string, result string) {
+	for _, tag := range tags {
+		testCasesTotal.WithLabelValues(tag, result).Inc()
+	}
+}
+
+// ServeMetrics serves the metrics on /metrics in the background.  Does nothing if addr is empty.
+func ServeMetrics(addr string) {
+	if addr == "" {
+		return
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	go func() {
+		logrus.Infof("serving metrics on %s/metrics", addr)
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			logrus.Errorf("unable to serve metrics on %s: %+v", addr, err)
+		}
+	}()
+}
@@ -0,0 +1,42 @@
+package telemetry
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	v1 "k8s.io/api/core/v1"
+)
+
+func RunMetricsTests() {
+	Describe("Metrics", func() {
+		It("should count probes by runner, protocol and result", func() {
+			before := testutil.ToFloat64(probesTotal.WithLabelValues(RunnerKube, "UDP", ProbeResultTimedOut))
+
+			ObserveProbe(RunnerKube, v1.ProtocolUDP, ProbeResultTimedOut)
+			ObserveProbe(RunnerKube, v1.ProtocolUDP, ProbeResultTimedOut)
+			ObserveProbe(RunnerKubeBatch, v1.ProtocolUDP, ProbeResultTimedOut)
+
+			Expect(testutil.ToFloat64(probesTotal.WithLabelValues(RunnerKube, "UDP", ProbeResultTimedOut))).To(Equal(before + 2))
+		})
+
+		It("should track probes in flight until they're done", func() {
+			inFlight := probesInFlight.WithLabelValues(RunnerKubeBatch)
+			before := testutil.ToFloat64(inFlight)
+
+			done := ProbeStarted(RunnerKubeBatch)
+			Expect(testutil.ToFloat64(inFlight)).To(Equal(before + 1))
+			done()
+			Expect(testutil.ToFloat64(inFlight)).To(Equal(before))
+		})
+
+		It("should count test cases once per tag", func() {
+			beforeANP := testutil.ToFloat64(testCasesTotal.WithLabelValues("anp", TestCaseResultFailed))
+			beforeSCTP := testutil.ToFloat64(testCasesTotal.WithLabelValues("sctp", TestCaseResultFailed))
+
+			ObserveTestCase([]string{"anp", "sctp"}, TestCaseResultFailed)
+
+			Expect(testutil.ToFloat64(testCasesTotal.WithLabelValues("anp", TestCaseResultFailed))).To(Equal(beforeANP + 1))
+			Expect(testutil.ToFloat64(testCasesTotal.WithLabelValues("sctp", TestCaseResultFailed))).To(Equal(beforeSCTP + 1))
+		})
+	})
+}
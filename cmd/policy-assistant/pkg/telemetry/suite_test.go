@@ -0,0 +1,14 @@
+package telemetry
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestTelemetry(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunMetricsTests()
+	RunSpecs(t, "telemetry suite")
+}
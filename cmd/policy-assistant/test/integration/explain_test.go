@@ -130,12 +130,12 @@ func TestExplain(t *testing.T) {
 			"|         |                                          |                             |                                                                        |                                                                                      |                            |\n" +
 			"+---------+------------------------------------------+-----------------------------+------------------------------------------------------------------------+--------------------------------------------------------------------------------------+----------------------------+\n" +
 			"| Egress  | Namespace:                               | [ANP] default/example-anp   | Namespace:                                                             | BANP:                                                                                | all ports, all protocols   |\n" +
-			"|         |    kubernetes.io/metadata.name Exists [] | [ANP] default/example-anp-2 |    Not Same labels - Test1                                             |    Deny                                                                              |                            |\n" +
+			"|         |    kubernetes.io/metadata.name Exists [] | [ANP] default/example-anp-2 |    Test Exists []                                                      |    Allow                                                                             |                            |\n" +
 			"|         |                                          | [BANP] default/default      | Pod:                                                                   |                                                                                      |                            |\n" +
 			"|         |                                          |                             |    all                                                                 |                                                                                      |                            |\n" +
 			"+         +                                          +                             +------------------------------------------------------------------------+--------------------------------------------------------------------------------------+                            +\n" +
 			"|         |                                          |                             | Namespace:                                                             | BANP:                                                                                |                            |\n" +
-			"|         |                                          |                             |    Same labels - Test                                                  |    Allow                                                                             |                            |\n" +
+			"|         |                                          |                             |    Test1 Exists []                                                     |    Deny                                                                              |                            |\n" +
 			"|         |                                          |                             | Pod:                                                                   |                                                                                      |                            |\n" +
 			"|         |                                          |                             |    all                                                                 |                                                                                      |                            |\n" +
 			"+         +                                          +                             +------------------------------------------------------------------------+--------------------------------------------------------------------------------------+                            +\n" +
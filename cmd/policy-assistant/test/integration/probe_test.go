@@ -5,6 +5,7 @@ import (
 
 	"github.com/stretchr/testify/require"
 	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/cli"
+	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/connectivity/probe"
 	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/kube"
 	"sigs.k8s.io/network-policy-api/policy-assistant/pkg/matcher"
 )
@@ -16,9 +17,21 @@ func TestProbe(t *testing.T) {
 
 		policies := matcher.BuildV1AndV2NetPols(false, npv1, anp, banp)
 
-		cli.ProbeSyntheticConnectivity(policies, "../../examples/demos/kubecon-eu-2024/demo-probe.json", nil, nil)
+		cli.ProbeSyntheticConnectivity(policies, "../../examples/demos/kubecon-eu-2024/demo-probe.json", nil, nil, cli.TableOutput)
+
+		results := cli.SimulateSyntheticConnectivity(policies, "../../examples/demos/kubecon-eu-2024/demo-probe.json", nil, nil)
+		require.Len(t, results, 2)
+		require.Equal(t, "probe on port 80, protocol TCP", results[0].Description)
+		require.Equal(t, probe.ConnectivityBlocked, results[0].Classes.Get("demo/b", "demo/a")["TCP/80"].Combined)
+
+		cli.RunAnalyzeCommand(&cli.AnalyzeArgs{
+			PolicyPath: "../../examples/demos/kubecon-eu-2024/policies/",
+			ProbePath:  "../../examples/demos/kubecon-eu-2024/demo-probe.json",
+		})
 
 		cli.RunAnalyzeCommand(&cli.AnalyzeArgs{
+			Modes:      []string{cli.ExplainMode, cli.ProbeMode},
+			Output:     cli.JSONOutput,
 			PolicyPath: "../../examples/demos/kubecon-eu-2024/policies/",
 			ProbePath:  "../../examples/demos/kubecon-eu-2024/demo-probe.json",
 		})